@@ -0,0 +1,647 @@
+package dot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// successors returns the IDs of the vertices of the graph and its subgraphs
+// in order of appearance, and for each of them the targets of the directed
+// edges leaving it.  Undirected edges are ignored.
+func successors(graph *Graph) ([]string, map[string][]string) {
+	_, order := collectVertices(graph)
+	succ := make(map[string][]string)
+	forEachEdge(graph, func(e *EdgeDescription) {
+		if e.Directed {
+			succ[e.From.ID] = append(succ[e.From.ID], e.To.ID)
+		}
+	})
+	return order, succ
+}
+
+// TopoSort returns the IDs of the vertices of the graph and its subgraphs in
+// topological order, every vertex coming before the targets of its directed
+// edges.  Vertices without an ordering constraint keep their order of
+// appearance.  Undirected edges are ignored.  An error naming the vertices
+// left unsorted is returned if the directed edges form a cycle.
+func (graph *Graph) TopoSort() ([]string, error) {
+	order, succ := successors(graph)
+	indegree := make(map[string]int)
+	for _, targets := range succ {
+		for _, to := range targets {
+			indegree[to]++
+		}
+	}
+
+	sorted := make([]string, 0, len(order))
+	var queue []string
+	for _, id := range order {
+		if indegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		sorted = append(sorted, id)
+		for _, to := range succ[id] {
+			indegree[to]--
+			if indegree[to] == 0 {
+				queue = append(queue, to)
+			}
+		}
+	}
+
+	if len(sorted) < len(order) {
+		var cyclic []string
+		for _, id := range order {
+			if indegree[id] > 0 {
+				cyclic = append(cyclic, id)
+			}
+		}
+		return nil, fmt.Errorf("graph %s has a cycle among vertices %s", graph.Name, strings.Join(cyclic, ", "))
+	}
+	return sorted, nil
+}
+
+// VertexDepths returns the depth of the vertices of the graph and its
+// subgraphs reachable from the given roots by directed edges, the length of
+// the longest path from a root.  Roots have depth 0 unless they can be
+// reached from another root.  Without roots, the vertices without incoming
+// directed edges are used.  An error is returned if a root is not a vertex
+// of the graph or if the directed edges form a cycle.
+func (graph *Graph) VertexDepths(roots ...string) (map[string]int, error) {
+	sorted, err := graph.TopoSort()
+	if err != nil {
+		return nil, err
+	}
+	_, succ := successors(graph)
+	depths := make(map[string]int)
+	if len(roots) == 0 {
+		indegree := make(map[string]int)
+		for _, targets := range succ {
+			for _, to := range targets {
+				indegree[to]++
+			}
+		}
+		for _, id := range sorted {
+			if indegree[id] == 0 {
+				depths[id] = 0
+			}
+		}
+	}
+	for _, root := range roots {
+		if !graph.HasVertex(root) {
+			return nil, fmt.Errorf("graph %s has no vertex %s", graph.Name, root)
+		}
+		depths[root] = 0
+	}
+	for _, id := range sorted {
+		depth, ok := depths[id]
+		if !ok {
+			continue
+		}
+		for _, to := range succ[id] {
+			if d, ok := depths[to]; !ok || d < depth+1 {
+				depths[to] = depth + 1
+			}
+		}
+	}
+	return depths, nil
+}
+
+// RankByDepth adds a rank=same group to the graph for each depth computed by
+// VertexDepths, so that the vertices at the same depth are drawn on the same
+// level.  Groups are added in order of depth, with their vertices in
+// topological order.
+func (graph *Graph) RankByDepth(roots ...string) error {
+	depths, err := graph.VertexDepths(roots...)
+	if err != nil {
+		return err
+	}
+	sorted, _ := graph.TopoSort()
+	var levels [][]string
+	for _, id := range sorted {
+		depth, ok := depths[id]
+		if !ok {
+			continue
+		}
+		for len(levels) <= depth {
+			levels = append(levels, nil)
+		}
+		levels[depth] = append(levels[depth], id)
+	}
+	for _, ids := range levels {
+		graph.Body = append(graph.Body, &RankGroup{Rank: "same", IDs: ids})
+	}
+	return nil
+}
+
+// FindCycles returns cycles formed by the directed edges of the graph and
+// its subgraphs, each as the IDs of the vertices along it.  A cycle [a b c]
+// stands for the edges a -> b, b -> c and c -> a.  Rather than every cycle,
+// one shortest cycle is reported for each strongly connected group of
+// vertices, in the order their first vertex appears.  The result is empty
+// if the directed edges form a DAG.
+func (graph *Graph) FindCycles() [][]string {
+	order, succ := successors(graph)
+	component := stronglyConnected(order, succ)
+	size := make(map[int]int)
+	for _, c := range component {
+		size[c]++
+	}
+
+	var cycles [][]string
+	reported := make(map[int]bool)
+	for _, id := range order {
+		c := component[id]
+		if reported[c] {
+			continue
+		}
+		if size[c] == 1 && !containsString(succ[id], id) {
+			continue
+		}
+		reported[c] = true
+		cycles = append(cycles, shortestCycle(id, succ, func(to string) bool {
+			return component[to] == c
+		}))
+	}
+	return cycles
+}
+
+// HighlightCycles finds the cycles of the graph as FindCycles does and sets
+// the color attribute of the edges along them.  Edge chains with a hop on a
+// cycle are split into separate edges.  It returns the cycles found.
+func (graph *Graph) HighlightCycles(color string) [][]string {
+	cycles := graph.FindCycles()
+	onCycle := make(map[[2]string]bool)
+	for _, cycle := range cycles {
+		for i, id := range cycle {
+			onCycle[[2]string{id, cycle[(i+1)%len(cycle)]}] = true
+		}
+	}
+	graph.rewriteBody(func(elem Element) []Element {
+		switch e := elem.(type) {
+		case *EdgeDescription:
+			if e.Directed && onCycle[[2]string{e.From.ID, e.To.ID}] {
+				e.AddAttribute("color", color)
+			}
+		case *EdgeChain:
+			if !e.Directed {
+				break
+			}
+			edges := e.Edges()
+			highlight := false
+			for _, edge := range edges {
+				highlight = highlight || onCycle[[2]string{edge.From.ID, edge.To.ID}]
+			}
+			if !highlight {
+				break
+			}
+			var split []Element
+			for i := range edges {
+				if onCycle[[2]string{edges[i].From.ID, edges[i].To.ID}] {
+					edges[i].AddAttribute("color", color)
+				}
+				split = append(split, &edges[i])
+			}
+			return split
+		}
+		return []Element{elem}
+	})
+	return cycles
+}
+
+// stronglyConnected numbers the strongly connected components of the
+// vertices using Tarjan's algorithm, returning the component of each vertex
+func stronglyConnected(order []string, succ map[string][]string) map[string]int {
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	component := make(map[string]int)
+	var stack []string
+	next, components := 0, 0
+
+	var visit func(id string)
+	visit = func(id string) {
+		index[id] = next
+		lowlink[id] = next
+		next++
+		stack = append(stack, id)
+		onStack[id] = true
+		for _, to := range succ[id] {
+			if _, ok := index[to]; !ok {
+				visit(to)
+				if lowlink[to] < lowlink[id] {
+					lowlink[id] = lowlink[to]
+				}
+			} else if onStack[to] && index[to] < lowlink[id] {
+				lowlink[id] = index[to]
+			}
+		}
+		if lowlink[id] != index[id] {
+			return
+		}
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component[top] = components
+			if top == id {
+				break
+			}
+		}
+		components++
+	}
+	for _, id := range order {
+		if _, ok := index[id]; !ok {
+			visit(id)
+		}
+	}
+	return component
+}
+
+// shortestCycle returns a shortest cycle through start, following only
+// edges to vertices accepted by within.  start must lie on a cycle.
+func shortestCycle(start string, succ map[string][]string, within func(string) bool) []string {
+	parent := map[string]string{start: ""}
+	queue := []string{start}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, to := range succ[id] {
+			if to == start {
+				var cycle []string
+				for v := id; v != ""; v = parent[v] {
+					cycle = append([]string{v}, cycle...)
+				}
+				return cycle
+			}
+			if _, seen := parent[to]; seen || !within(to) {
+				continue
+			}
+			parent[to] = id
+			queue = append(queue, to)
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, t := range list {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Components splits the graph into its weakly connected components,
+// vertices being connected by edges in either direction.  Each component is
+// returned as a graph with the attributes of the original one, named after
+// it with the index of the component appended, and holding copies of the
+// vertices, edges and subgraphs involving the vertices of the component.
+// Components are ordered by the first appearance of their vertices, and
+// Literal elements are dropped.
+func (graph *Graph) Components() []Graph {
+	_, order := collectVertices(graph)
+	parent := make(map[string]string)
+	var find func(id string) string
+	find = func(id string) string {
+		p, ok := parent[id]
+		if !ok || p == id {
+			return id
+		}
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+	forEachEdge(graph, func(e *EdgeDescription) {
+		from, to := find(e.From.ID), find(e.To.ID)
+		if from != to {
+			parent[to] = from
+		}
+	})
+
+	var components []Graph
+	index := make(map[string]int)
+	for _, id := range order {
+		root := find(id)
+		if _, ok := index[root]; ok {
+			continue
+		}
+		index[root] = len(components)
+		name := graph.Name + "_" + strconv.Itoa(len(components))
+		components = append(components, *graph.subset(name, func(id string) bool {
+			return find(id) == root
+		}))
+	}
+	return components
+}
+
+// subset returns a copy of the graph with the given name, holding copies of
+// the vertices accepted by keep and the edges between them.  Subgraphs are
+// copied recursively and dropped if they end up empty, rank groups keep the
+// accepted vertices only, and Literal elements are dropped.
+func (graph *Graph) subset(name string, keep func(id string) bool) *Graph {
+	sub := *graph
+	sub.Name = name
+	sub.Body = nil
+	// the subset does not share the state of the graph kept for building it
+	sub.sanitized = copyAttrs(graph.sanitized)
+	sub.parent = nil
+	sub.nextID = 0
+	sub.dedupErr = nil
+	sub.idx = nil
+	sub.Attrs = copyAttrs(graph.Attrs)
+	if graph.NodeDefaults != nil {
+		sub.NodeDefaults = copyVertex(graph.NodeDefaults)
+	}
+	if graph.EdgeDefaults != nil {
+		sub.EdgeDefaults = copyEdge(graph.EdgeDefaults)
+	}
+	for _, elem := range graph.Body {
+		switch e := elem.(type) {
+		case *VertexDescription:
+			if keep(e.ID) {
+				sub.Body = append(sub.Body, copyVertex(e))
+			}
+		case *EdgeDescription:
+			if keep(e.From.ID) && keep(e.To.ID) {
+				sub.Body = append(sub.Body, copyEdge(e))
+			}
+		case *EdgeChain:
+			chain := *e
+			chain.Vertices = append([]VertexDescription(nil), e.Vertices...)
+			sub.Body = append(sub.Body, splitChain(&chain, func(from, to *VertexDescription) bool {
+				return keep(from.ID) && keep(to.ID)
+			})...)
+		case *RankGroup:
+			group := &RankGroup{Rank: e.Rank}
+			for _, id := range e.IDs {
+				if keep(id) {
+					group.IDs = append(group.IDs, id)
+				}
+			}
+			if len(group.IDs) > 0 {
+				sub.Body = append(sub.Body, group)
+			}
+		case *Graph:
+			if s := e.subset(e.Name, keep); len(s.Body) > 0 {
+				if e.parent != nil {
+					s.parent = &sub
+				}
+				sub.Body = append(sub.Body, s)
+			}
+		}
+	}
+	return &sub
+}
+
+// copyVertex returns a copy of v which does not share its Attrs or Pos
+func copyVertex(v *VertexDescription) *VertexDescription {
+	c := *v
+	c.Attrs = copyAttrs(v.Attrs)
+	if v.Pos != nil {
+		pos := *v.Pos
+		c.Pos = &pos
+	}
+	return &c
+}
+
+// copyEdge returns a copy of e which does not share its Attrs, Constraint
+// or the attributes of its endpoints
+func copyEdge(e *EdgeDescription) *EdgeDescription {
+	c := *e
+	c.From = *copyVertex(&e.From)
+	c.To = *copyVertex(&e.To)
+	c.Attrs = copyAttrs(e.Attrs)
+	if e.Constraint != nil {
+		c.Constraint = Bool(*e.Constraint)
+	}
+	return &c
+}
+
+func copyAttrs(attrs map[string]string) map[string]string {
+	if attrs == nil {
+		return nil
+	}
+	c := make(map[string]string, len(attrs))
+	for key, value := range attrs {
+		c[key] = value
+	}
+	return c
+}
+
+// Filter returns a copy of the graph holding the vertices for which keep
+// returns true and the edges between them.  keep is called once per vertex
+// ID, with the attributes of all statements for that vertex merged, and for
+// vertices which only appear as edge endpoints with their ID alone.
+// Subgraph structure is kept, with subgraphs left empty dropped, and
+// Literal elements are dropped.
+func (graph *Graph) Filter(keep func(*VertexDescription) bool) Graph {
+	kept := graph.filterIDs(keep)
+	return *graph.subset(graph.Name, func(id string) bool {
+		return kept[id]
+	})
+}
+
+// FilterNeighbors is like Filter, but also keeps the vertices connected by
+// an edge to a vertex for which keep returns true, together with those
+// edges.
+func (graph *Graph) FilterNeighbors(keep func(*VertexDescription) bool) Graph {
+	kept := graph.filterIDs(keep)
+	neighbors := make(map[string]bool)
+	forEachEdge(graph, func(e *EdgeDescription) {
+		if kept[e.From.ID] {
+			neighbors[e.To.ID] = true
+		}
+		if kept[e.To.ID] {
+			neighbors[e.From.ID] = true
+		}
+	})
+	return *graph.subset(graph.Name, func(id string) bool {
+		return kept[id] || neighbors[id]
+	})
+}
+
+// filterIDs returns the set of vertex IDs accepted by keep
+func (graph *Graph) filterIDs(keep func(*VertexDescription) bool) map[string]bool {
+	vertices, order := collectVertices(graph)
+	kept := make(map[string]bool)
+	for _, id := range order {
+		if keep(vertices[id]) {
+			kept[id] = true
+		}
+	}
+	return kept
+}
+
+// TransitiveReduction removes the directed edges of the graph and its
+// subgraphs which are implied by a longer path, an edge a -> c being
+// removed if there are edges a -> b and b -> c.  Parallel edges are kept,
+// as are undirected edges.  Edge chains are split around removed hops.  It
+// returns an error and leaves the graph unchanged if the directed edges
+// form a cycle.
+func (graph *Graph) TransitiveReduction() error {
+	sorted, err := graph.TopoSort()
+	if err != nil {
+		return err
+	}
+	_, succ := successors(graph)
+
+	// reach holds the vertices reachable from each vertex, computed in
+	// reverse topological order
+	reach := make(map[string]map[string]bool)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		id := sorted[i]
+		reach[id] = make(map[string]bool)
+		for _, to := range succ[id] {
+			reach[id][to] = true
+			for r := range reach[to] {
+				reach[id][r] = true
+			}
+		}
+	}
+	redundant := func(from, to string) bool {
+		for _, via := range succ[from] {
+			if via != to && reach[via][to] {
+				return true
+			}
+		}
+		return false
+	}
+
+	graph.rewriteBody(func(elem Element) []Element {
+		switch e := elem.(type) {
+		case *EdgeDescription:
+			if e.Directed && redundant(e.From.ID, e.To.ID) {
+				return nil
+			}
+		case *EdgeChain:
+			if e.Directed {
+				return splitChain(e, func(from, to *VertexDescription) bool {
+					return !redundant(from.ID, to.ID)
+				})
+			}
+		}
+		return []Element{elem}
+	})
+	return nil
+}
+
+// Reverse flips the direction of every directed edge of the graph and its
+// subgraphs, swapping their endpoints, ports and head and tail attributes,
+// including those in Attrs and in the edge defaults of a directed graph.
+// Directed edge chains are reversed.  graphviz lays out the reversed graph
+// with the ranks inverted.
+func (graph *Graph) Reverse() {
+	if !graph.IsUndirected {
+		var walk func(*Graph)
+		walk = func(g *Graph) {
+			if g.EdgeDefaults != nil {
+				reverseEdge(g.EdgeDefaults)
+			}
+			for _, elem := range g.Body {
+				if sub, ok := elem.(*Graph); ok {
+					walk(sub)
+				}
+			}
+		}
+		walk(graph)
+	}
+	graph.rewriteBody(func(elem Element) []Element {
+		switch e := elem.(type) {
+		case *EdgeDescription:
+			if e.Directed {
+				reverseEdge(e)
+			}
+		case *EdgeChain:
+			if e.Directed {
+				for i, j := 0, len(e.Vertices)-1; i < j; i, j = i+1, j-1 {
+					e.Vertices[i], e.Vertices[j] = e.Vertices[j], e.Vertices[i]
+				}
+			}
+		}
+		return []Element{elem}
+	})
+}
+
+// ReverseArrows flips the dir of every directed edge of the graph and its
+// subgraphs between forward and back, so that arrows point the other way
+// while the layout is kept.  Edges with dir both or none are left
+// unchanged.  Edge chains are split into separate edges to carry the
+// attribute.
+func (graph *Graph) ReverseArrows() {
+	graph.rewriteBody(func(elem Element) []Element {
+		switch e := elem.(type) {
+		case *EdgeDescription:
+			if e.Directed {
+				e.Dir = reverseDir(e.Dir)
+			}
+		case *EdgeChain:
+			if e.Directed {
+				var split []Element
+				for _, edge := range e.Edges() {
+					edge := edge
+					edge.Dir = reverseDir(edge.Dir)
+					split = append(split, &edge)
+				}
+				return split
+			}
+		}
+		return []Element{elem}
+	})
+}
+
+// headTailAttrs pairs the head and tail attributes of an edge which Reverse
+// swaps in Attrs
+var headTailAttrs = [][2]string{
+	{"headport", "tailport"},
+	{"headclip", "tailclip"},
+	{"headURL", "tailURL"},
+	{"headhref", "tailhref"},
+	{"headtarget", "tailtarget"},
+	{"headtooltip", "tailtooltip"},
+	{"headlabel", "taillabel"},
+	{"arrowhead", "arrowtail"},
+	{"lhead", "ltail"},
+	{"samehead", "sametail"},
+}
+
+// reverseEdge swaps the endpoints, ports and head and tail attributes of an
+// edge
+func reverseEdge(e *EdgeDescription) {
+	e.From, e.To = e.To, e.From
+	e.FromPort, e.ToPort = e.ToPort, e.FromPort
+	e.FromCompass, e.ToCompass = e.ToCompass, e.FromCompass
+	e.LHead, e.LTail = e.LTail, e.LHead
+	e.HeadLabel, e.TailLabel = e.TailLabel, e.HeadLabel
+	e.ArrowHead, e.ArrowTail = e.ArrowTail, e.ArrowHead
+	e.SameHead, e.SameTail = e.SameTail, e.SameHead
+	for _, pair := range headTailAttrs {
+		head, hasHead := e.Attrs[pair[0]]
+		tail, hasTail := e.Attrs[pair[1]]
+		delete(e.Attrs, pair[0])
+		delete(e.Attrs, pair[1])
+		if hasHead {
+			e.Attrs[pair[1]] = head
+		}
+		if hasTail {
+			e.Attrs[pair[0]] = tail
+		}
+	}
+}
+
+// reverseDir returns the dir of a directed edge with its arrows pointing the
+// other way
+func reverseDir(dir Dir) Dir {
+	switch dir {
+	case "", DirForward:
+		return DirBack
+	case DirBack:
+		return DirForward
+	}
+	return dir
+}
@@ -0,0 +1,464 @@
+package dot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// buildDAG returns a graph of a small build, with an undirected edge and a
+// vertex declared in a subgraph
+func buildDAG() Graph {
+	g := NewGraph("build")
+	app := &VertexDescription{ID: "app"}
+	lib := &VertexDescription{ID: "lib"}
+	util := &VertexDescription{ID: "util"}
+	log := &VertexDescription{ID: "log"}
+	docs := &VertexDescription{ID: "docs"}
+	g.AddVertex(app)
+	g.AddVertex(docs)
+	sub := NewCluster("deps")
+	sub.AddVertex(util)
+	sub.AddEdge(lib, util, true, "")
+	g.AddSubGraph(&sub)
+	g.AddEdgeChain(true, "", app, lib, log)
+	g.AddEdge(app, util, true, "")
+	g.AddEdge(util, log, true, "")
+	g.AddEdge(docs, app, false, "")
+	return g
+}
+
+func TestTopoSort(t *testing.T) {
+	g := buildDAG()
+	sorted, err := g.TopoSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"app", "docs", "lib", "util", "log"}
+	if !reflect.DeepEqual(sorted, expected) {
+		t.Errorf("unexpected order %v", sorted)
+	}
+}
+
+func TestTopoSortCycle(t *testing.T) {
+	g := buildDAG()
+	g.AddEdge(&VertexDescription{ID: "log"}, &VertexDescription{ID: "lib"}, true, "")
+	_, err := g.TopoSort()
+	if err == nil || !strings.HasSuffix(err.Error(), "util, lib, log") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+var highlightedCycles = `digraph build {
+app []
+docs []
+subgraph cluster_deps {
+util []
+lib -> util [ color="red" ]
+}
+app -> lib -> log
+app -> util
+util -> log [ color="red" ]
+docs -- app
+log -> lib [ color="red" ]
+}`
+
+func TestVertexDepths(t *testing.T) {
+	g := buildDAG()
+	depths, err := g.VertexDepths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int{"app": 0, "docs": 0, "lib": 1, "util": 2, "log": 3}
+	if !reflect.DeepEqual(depths, expected) {
+		t.Errorf("unexpected depths %v", depths)
+	}
+
+	depths, err = g.VertexDepths("util", "lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string]int{"lib": 0, "util": 1, "log": 2}
+	if !reflect.DeepEqual(depths, expected) {
+		t.Errorf("unexpected depths %v", depths)
+	}
+
+	if _, err := g.VertexDepths("missing"); err == nil {
+		t.Error("expected an error for an unknown root")
+	}
+	g.AddEdge(&VertexDescription{ID: "log"}, &VertexDescription{ID: "app"}, true, "")
+	if _, err := g.VertexDepths(); err == nil {
+		t.Error("expected an error for a cyclic graph")
+	}
+}
+
+func TestRankByDepth(t *testing.T) {
+	g := NewGraph("dag")
+	root := &VertexDescription{ID: "root"}
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	c := &VertexDescription{ID: "c"}
+	g.AddEdge(root, a, true, "")
+	g.AddEdge(root, b, true, "")
+	g.AddEdge(a, c, true, "")
+	g.AddEdge(root, c, true, "")
+	if err := g.RankByDepth("root"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph dag {
+root -> a
+root -> b
+a -> c
+root -> c
+{ rank=same; root; }
+{ rank=same; a; b; }
+{ rank=same; c; }
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestFindCycles(t *testing.T) {
+	g := buildDAG()
+	if cycles := g.FindCycles(); len(cycles) != 0 {
+		t.Errorf("unexpected cycles in DAG: %v", cycles)
+	}
+
+	log := &VertexDescription{ID: "log"}
+	g.AddEdge(log, &VertexDescription{ID: "lib"}, true, "")
+	g.AddEdge(log, log, true, "")
+	g.AddEdge(&VertexDescription{ID: "z"}, &VertexDescription{ID: "z"}, true, "")
+	g.AddEdge(&VertexDescription{ID: "x"}, &VertexDescription{ID: "y"}, true, "")
+	g.AddEdge(&VertexDescription{ID: "y"}, &VertexDescription{ID: "x"}, true, "")
+	expected := [][]string{{"util", "log", "lib"}, {"z"}, {"x", "y"}}
+	if cycles := g.FindCycles(); !reflect.DeepEqual(cycles, expected) {
+		t.Errorf("unexpected cycles %v", cycles)
+	}
+}
+
+func TestHighlightCycles(t *testing.T) {
+	g := buildDAG()
+	log := &VertexDescription{ID: "log"}
+	g.AddEdge(log, &VertexDescription{ID: "lib"}, true, "")
+	g.HighlightCycles("red")
+	if s := writeString(t, &g); s != highlightedCycles {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+var componentGraphs = []string{`digraph build_0 {
+app []
+docs []
+subgraph cluster_deps {
+util []
+lib -> util
+}
+app -> lib -> log
+app -> util
+util -> log
+docs -- app
+{ rank=same; app; }
+}`, `digraph build_1 {
+x -> y
+}`, `digraph build_2 {
+z []
+{ rank=same; z; }
+}`}
+
+func TestComponents(t *testing.T) {
+	g := buildDAG()
+	g.AddEdge(&VertexDescription{ID: "x"}, &VertexDescription{ID: "y"}, true, "")
+	g.AddVertex(&VertexDescription{ID: "z"})
+	g.AddSameRank(&VertexDescription{ID: "app"}, &VertexDescription{ID: "z"})
+
+	components := g.Components()
+	if len(components) != len(componentGraphs) {
+		t.Fatalf("expected %d components, found %d", len(componentGraphs), len(components))
+	}
+	for i := range components {
+		if s := writeString(t, &components[i]); s != componentGraphs[i] {
+			t.Errorf("unexpected output: \n%s\n", s)
+		}
+	}
+}
+
+func TestComponentsState(t *testing.T) {
+	g := NewGraph("peers")
+	a := &VertexDescription{ID: g.SanitizeID("/ip4/10.0.0.1")}
+	b := &VertexDescription{ID: g.SanitizeID("/ip4/10.0.0.2")}
+	g.AddVertex(a)
+	g.AddVertex(b)
+	components := g.Components()
+	if len(components) != 2 {
+		t.Fatalf("expected 2 components, found %d", len(components))
+	}
+	id := components[0].SanitizeID("/ip4/10.0.0.3")
+	if _, ok := g.SourceID(id); ok {
+		t.Error("SanitizeID on a component changed the source graph")
+	}
+	if _, ok := components[1].SourceID(id); ok {
+		t.Error("SanitizeID on a component changed another component")
+	}
+	if s, ok := components[1].SourceID(b.ID); !ok || s != "/ip4/10.0.0.2" {
+		t.Errorf("unexpected source %q of %s", s, b.ID)
+	}
+}
+
+var filteredGraph = `digraph build {
+app []
+subgraph cluster_deps {
+util []
+}
+app -> util
+}`
+
+var filteredNeighborsGraph = `digraph build {
+app []
+subgraph cluster_deps {
+util []
+lib -> util
+}
+app -> lib -> log
+app -> util
+util -> log
+}`
+
+func TestFilter(t *testing.T) {
+	g := buildDAG()
+	g.FindVertex("util").Color = "red"
+	g.FindVertex("app").Color = "red"
+	red := func(v *VertexDescription) bool {
+		return v.Color == "red"
+	}
+
+	filtered := g.Filter(red)
+	if s := writeString(t, &filtered); s != strings.Replace(filteredGraph, "[]", `[color="red" ]`, -1) {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+	filtered.FindVertex("app").Color = "blue"
+	if v := g.FindVertex("app"); v.Color != "red" {
+		t.Errorf("original vertex modified: %+v", v)
+	}
+}
+
+func TestFilterNeighbors(t *testing.T) {
+	g := buildDAG()
+	util := func(v *VertexDescription) bool {
+		return v.ID == "util"
+	}
+	filtered := g.FilterNeighbors(util)
+	if s := writeString(t, &filtered); s != filteredNeighborsGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+var reducedGraph = `digraph build {
+app []
+docs []
+subgraph cluster_deps {
+util []
+lib -> util
+}
+app -> lib
+util -> log
+docs -- app
+}`
+
+func TestTransitiveReduction(t *testing.T) {
+	g := buildDAG()
+	g.AddEdge(&VertexDescription{ID: "app"}, &VertexDescription{ID: "log"}, true, "")
+	if err := g.TransitiveReduction(); err != nil {
+		t.Fatal(err)
+	}
+	if s := writeString(t, &g); s != reducedGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	g = buildDAG()
+	g.AddEdge(&VertexDescription{ID: "log"}, &VertexDescription{ID: "app"}, true, "")
+	before := writeString(t, &g)
+	if err := g.TransitiveReduction(); err == nil {
+		t.Error("expected an error for a cyclic graph")
+	}
+	if s := writeString(t, &g); s != before {
+		t.Errorf("cyclic graph modified: \n%s\n", s)
+	}
+}
+
+var reversedGraph = `digraph build {
+app []
+docs []
+subgraph cluster_deps {
+util []
+util -> lib
+}
+log -> lib -> app
+util -> app
+log:n -> util
+docs -- app
+}`
+
+var reversedArrowsGraph = `digraph build {
+app []
+docs []
+subgraph cluster_deps {
+util []
+lib -> util [ dir="back" ]
+}
+app -> lib [ dir="back" ]
+lib -> log [ dir="back" ]
+app -> util [ dir="back" ]
+util -> log [ dir="back" ]
+docs -- app
+}`
+
+func TestReverse(t *testing.T) {
+	g := buildDAG()
+	for _, elem := range g.Body {
+		if e, ok := elem.(*EdgeDescription); ok && e.To.ID == "log" {
+			e.ToCompass = CompassN
+		}
+	}
+	g.Reverse()
+	if s := writeString(t, &g); s != reversedGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestReverseClusterEdge(t *testing.T) {
+	g := NewGraph("testGraph")
+	front := NewCluster("front")
+	front.AddVertex(&VertexDescription{ID: "web"})
+	back := NewCluster("back")
+	back.AddVertex(&VertexDescription{ID: "db"})
+	g.AddSubGraph(&front)
+	g.AddSubGraph(&back)
+	if err := g.AddClusterEdge(&front, &back, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	g.Reverse()
+	e := g.Body[2].(*EdgeDescription)
+	if e.From.ID != "db" || e.LTail != "cluster_back" || e.LHead != "cluster_front" {
+		t.Errorf("unexpected reversed edge %+v", e)
+	}
+}
+
+func TestReverseEndLabels(t *testing.T) {
+	g := NewGraph("uml")
+	g.Body = append(g.Body, &EdgeDescription{
+		From:      VertexDescription{ID: "order"},
+		To:        VertexDescription{ID: "item"},
+		Directed:  true,
+		TailLabel: "1",
+		HeadLabel: "0..*",
+	})
+	g.Reverse()
+	expected := `digraph uml {
+item -> order [ headlabel="1" taillabel="0..*" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestReverseArrowStyles(t *testing.T) {
+	g := NewGraph("uml")
+	g.Body = append(g.Body, &EdgeDescription{
+		From:      VertexDescription{ID: "car"},
+		To:        VertexDescription{ID: "wheel"},
+		Directed:  true,
+		Dir:       DirBoth,
+		ArrowTail: ArrowDiamond,
+		ArrowHead: ArrowNone,
+	})
+	g.Reverse()
+	e := g.Body[0].(*EdgeDescription)
+	if e.From.ID != "wheel" || e.ArrowHead != ArrowDiamond || e.ArrowTail != ArrowNone {
+		t.Errorf("unexpected reversed edge %+v", e)
+	}
+}
+
+func TestReverseAttrs(t *testing.T) {
+	g := NewGraph("uml")
+	g.EdgeDefaults = &EdgeDescription{Attrs: map[string]string{"arrowtail": "odiamond"}}
+	sub := NewCluster("sub")
+	sub.EdgeDefaults = &EdgeDescription{Attrs: map[string]string{"headclip": "false"}}
+	g.AddSubGraph(&sub)
+	g.Body = append(g.Body, &EdgeDescription{
+		From:     VertexDescription{ID: "order"},
+		To:       VertexDescription{ID: "item"},
+		Directed: true,
+		Attrs: map[string]string{
+			"headURL":   "item.html",
+			"headlabel": "0..*",
+			"taillabel": "1",
+			"tailport":  "id",
+		},
+	})
+	g.Reverse()
+	if a := g.EdgeDefaults.Attrs; a["arrowhead"] != "odiamond" || a["arrowtail"] != "" {
+		t.Errorf("unexpected reversed edge defaults %v", a)
+	}
+	if a := sub.EdgeDefaults.Attrs; a["tailclip"] != "false" || a["headclip"] != "" {
+		t.Errorf("unexpected reversed subgraph edge defaults %v", a)
+	}
+	e := g.Body[1].(*EdgeDescription)
+	expected := map[string]string{
+		"tailURL":   "item.html",
+		"taillabel": "0..*",
+		"headlabel": "1",
+		"headport":  "id",
+	}
+	if e.From.ID != "item" || !reflect.DeepEqual(e.Attrs, expected) {
+		t.Errorf("unexpected reversed edge %+v", e)
+	}
+}
+
+func TestReverseSameHead(t *testing.T) {
+	g := NewGraph("fanin")
+	for _, from := range []string{"a", "b"} {
+		g.Body = append(g.Body, &EdgeDescription{
+			From:     VertexDescription{ID: from},
+			To:       VertexDescription{ID: "sink"},
+			Directed: true,
+			SameHead: "in",
+		})
+	}
+	g.Reverse()
+	expected := `digraph fanin {
+sink -> a [ sametail="in" ]
+sink -> b [ sametail="in" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestReverseArrows(t *testing.T) {
+	g := buildDAG()
+	g.ReverseArrows()
+	if s := writeString(t, &g); s != reversedArrowsGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	g = NewGraph("dirs")
+	for _, dir := range []Dir{"", DirForward, DirBack, DirBoth, DirNone} {
+		g.Body = append(g.Body, &EdgeDescription{From: VertexDescription{ID: "a"}, To: VertexDescription{ID: "b"}, Directed: true, Dir: dir})
+	}
+	g.AddEdgeChain(true, "", &VertexDescription{ID: "a"}, &VertexDescription{ID: "b"}, &VertexDescription{ID: "c"})
+	g.ReverseArrows()
+	g.ReverseArrows()
+	expected := `digraph dirs {
+a -> b [ dir="forward" ]
+a -> b [ dir="forward" ]
+a -> b [ dir="back" ]
+a -> b [ dir="both" ]
+a -> b [ dir="none" ]
+a -> b [ dir="forward" ]
+b -> c [ dir="forward" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
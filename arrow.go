@@ -0,0 +1,43 @@
+package dot
+
+// Arrow is the shape of an arrowhead.  Shapes may be prefixed with "o" for
+// an open shape and "l" or "r" to clip them to one side, and up to four may
+// be combined, as in "lteeoldiamond".
+type Arrow string
+
+// Arrow shapes supported by graphviz
+const (
+	ArrowNormal   Arrow = "normal"
+	ArrowInv      Arrow = "inv"
+	ArrowDot      Arrow = "dot"
+	ArrowInvDot   Arrow = "invdot"
+	ArrowODot     Arrow = "odot"
+	ArrowInvODot  Arrow = "invodot"
+	ArrowNone     Arrow = "none"
+	ArrowTee      Arrow = "tee"
+	ArrowEmpty    Arrow = "empty"
+	ArrowInvEmpty Arrow = "invempty"
+	ArrowDiamond  Arrow = "diamond"
+	ArrowODiamond Arrow = "odiamond"
+	ArrowEDiamond Arrow = "ediamond"
+	ArrowCrow     Arrow = "crow"
+	ArrowBox      Arrow = "box"
+	ArrowOBox     Arrow = "obox"
+	ArrowOpen     Arrow = "open"
+	ArrowHalfOpen Arrow = "halfopen"
+	ArrowVee      Arrow = "vee"
+	ArrowCurve    Arrow = "curve"
+	ArrowICurve   Arrow = "icurve"
+)
+
+// Dir sets which ends of an edge get an arrowhead
+type Dir string
+
+// Edge directions.  Directed edges default to DirForward and undirected
+// edges to DirNone.
+const (
+	DirForward Dir = "forward"
+	DirBack    Dir = "back"
+	DirBoth    Dir = "both"
+	DirNone    Dir = "none"
+)
@@ -0,0 +1,35 @@
+package dot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEdgeArrows(t *testing.T) {
+	g := NewGraph("testGraph")
+	client := &VertexDescription{ID: "client"}
+	server := &VertexDescription{ID: "server"}
+	g.AddEdge(client, server, true, "")
+	e := g.Body[0].(*EdgeDescription)
+	e.ArrowHead = ArrowODiamond
+	e.ArrowTail = "lteeoldiamond"
+	e.ArrowSize = 0.5
+	e.Dir = DirBoth
+
+	expected := `digraph testGraph {
+client -> server [ arrowhead="odiamond" arrowtail="lteeoldiamond" arrowsize="0.5" dir="both" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pe := parsed.Body[0].(*EdgeDescription)
+	if pe.ArrowHead != ArrowODiamond || pe.ArrowTail != "lteeoldiamond" ||
+		pe.ArrowSize != 0.5 || pe.Dir != DirBoth || len(pe.Attrs) != 0 {
+		t.Errorf("unexpected parsed edge %+v", pe)
+	}
+}
@@ -0,0 +1,56 @@
+package dot
+
+import (
+	"fmt"
+)
+
+// AddBipartite adds two sets of vertices and the edges between them to the
+// graph, each set on its own rank: with the RankDir of the graph, set to LR
+// when the graph is not a subgraph and has none, left becomes the left
+// column and right the right column.  An invisible edge keeps the columns
+// in order.  Edges from right to left do not constrain the ranking.  An
+// error is returned, and the graph left unchanged, if an edge has an
+// endpoint outside the sets or connects two vertices of the same set.
+func (graph *Graph) AddBipartite(left, right []*VertexDescription, edges []*EdgeDescription) error {
+	side := make(map[string]int, len(left)+len(right))
+	for _, v := range left {
+		side[v.ID] = -1
+	}
+	for _, v := range right {
+		side[v.ID] = 1
+	}
+	for _, e := range edges {
+		from, to := side[e.From.ID], side[e.To.ID]
+		switch {
+		case from == 0 || to == 0:
+			return fmt.Errorf("edge %s -> %s: endpoint outside the vertex sets", e.From.ID, e.To.ID)
+		case from == to:
+			return fmt.Errorf("edge %s -> %s: endpoints in the same vertex set", e.From.ID, e.To.ID)
+		}
+	}
+
+	if !graph.IsSubGraph && graph.RankDir == "" {
+		graph.RankDir = RankDirLR
+	}
+	for _, v := range left {
+		graph.AddVertex(v)
+	}
+	for _, v := range right {
+		graph.AddVertex(v)
+	}
+	for _, set := range [][]*VertexDescription{left, right} {
+		if len(set) > 0 {
+			graph.AddSameRank(set...)
+		}
+	}
+	if len(left) > 0 && len(right) > 0 {
+		graph.AddInvisibleEdge(left[0], right[0])
+	}
+	for _, e := range edges {
+		if side[e.From.ID] > 0 && e.Constraint == nil {
+			e.Constraint = Bool(false)
+		}
+		graph.addEdge(e)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package dot
+
+import (
+	"testing"
+)
+
+var bipartiteGraph = `digraph allocations {
+rankdir="LR"
+pin1 [label="QmPin1" ]
+pin2 [label="QmPin2" ]
+peer1 [shape="box" ]
+peer2 [shape="box" ]
+{ rank=same; pin1; pin2; }
+{ rank=same; peer1; peer2; }
+pin1 -> peer1 [ style="invis" ]
+pin1 -> peer1
+pin2 -> peer1
+peer2 -> pin2 [ constraint="false" ]
+}`
+
+func TestAddBipartite(t *testing.T) {
+	pins := []*VertexDescription{{ID: "pin1", Label: "QmPin1"}, {ID: "pin2", Label: "QmPin2"}}
+	peers := []*VertexDescription{{ID: "peer1", Shape: ShapeBox}, {ID: "peer2", Shape: ShapeBox}}
+	edges := []*EdgeDescription{
+		{From: *pins[0], To: *peers[0], Directed: true},
+		{From: *pins[1], To: *peers[0], Directed: true},
+		{From: *peers[1], To: *pins[1], Directed: true},
+	}
+	g := NewGraph("allocations")
+	if err := g.AddBipartite(pins, peers, edges); err != nil {
+		t.Fatal(err)
+	}
+	if s := writeString(t, &g); s != bipartiteGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	for _, e := range []*EdgeDescription{
+		{From: *pins[0], To: *pins[1], Directed: true},
+		{From: *pins[0], To: VertexDescription{ID: "other"}, Directed: true},
+	} {
+		g := NewGraph("allocations")
+		if err := g.AddBipartite(pins, peers, []*EdgeDescription{e}); err == nil {
+			t.Errorf("expected an error for edge %s -> %s", e.From.ID, e.To.ID)
+		}
+		if len(g.Body) != 0 {
+			t.Error("graph changed by a failed AddBipartite")
+		}
+	}
+}
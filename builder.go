@@ -0,0 +1,138 @@
+package dot
+
+import "fmt"
+
+// Builder builds a graph with chained calls, collecting the errors found on
+// the way and returning them from Build:
+//
+//	g, err := dot.New("g").Node("a").Attr("shape", "box").Node("b").Edge("a", "b").Build()
+type Builder struct {
+	root *Graph
+	// stack holds the graphs enclosing the current one, which is last
+	stack []*Graph
+	// last is the element Attr applies to: a vertex, an edge or a graph
+	last interface{}
+	ids  map[string]bool
+	errs ErrorList
+}
+
+// New returns a builder for a directed graph with the given name
+func New(name string) *Builder {
+	g := NewGraph(name)
+	return &Builder{
+		root:  &g,
+		stack: []*Graph{&g},
+		last:  &g,
+		ids:   make(map[string]bool),
+	}
+}
+
+func (b *Builder) current() *Graph {
+	return b.stack[len(b.stack)-1]
+}
+
+func (b *Builder) errorf(format string, args ...interface{}) *Builder {
+	b.errs = append(b.errs, fmt.Errorf(format, args...))
+	return b
+}
+
+// Undirected makes the graph undirected.  Edges added afterwards are
+// undirected.
+func (b *Builder) Undirected() *Builder {
+	b.root.IsUndirected = true
+	return b
+}
+
+// Strict makes the graph strict
+func (b *Builder) Strict() *Builder {
+	b.root.IsStrict = true
+	return b
+}
+
+// Node adds a vertex with the given ID to the current graph
+func (b *Builder) Node(id string) *Builder {
+	return b.Vertex(VertexDescription{ID: id})
+}
+
+// Vertex adds a copy of v to the current graph
+func (b *Builder) Vertex(v VertexDescription) *Builder {
+	if v.ID == "" {
+		return b.errorf("vertex without ID")
+	}
+	if b.ids[v.ID] {
+		return b.errorf("vertex %s added twice", v.ID)
+	}
+	b.ids[v.ID] = true
+	b.current().AddVertex(&v)
+	b.last = &v
+	return b
+}
+
+// Edge adds an edge between two vertices to the current graph.  The
+// vertices must be added to the graph, before or after the edge.
+func (b *Builder) Edge(from, to string) *Builder {
+	e := &EdgeDescription{
+		From:     VertexDescription{ID: from},
+		To:       VertexDescription{ID: to},
+		Directed: !b.root.IsUndirected,
+	}
+	b.current().Body = append(b.current().Body, e)
+	b.last = e
+	return b
+}
+
+// Attr sets an attribute on the vertex, edge or graph added last.  Right
+// after New or Cluster it applies to the graph or cluster.
+func (b *Builder) Attr(name, value string) *Builder {
+	var err error
+	switch e := b.last.(type) {
+	case *VertexDescription:
+		err = setVertexAttr(e, name, value)
+	case *EdgeDescription:
+		err = setEdgeAttr(e, name, value)
+	case *Graph:
+		err = setGraphAttr(e, name, value)
+	}
+	if err != nil {
+		return b.errorf("%v", err)
+	}
+	return b
+}
+
+// Cluster starts a cluster with the given name nested in the current graph.
+// Following vertices and edges are added to it until the matching End.
+func (b *Builder) Cluster(name string) *Builder {
+	c := NewCluster(name)
+	b.current().AddSubGraph(&c)
+	b.stack = append(b.stack, &c)
+	b.last = &c
+	return b
+}
+
+// End ends the cluster started last
+func (b *Builder) End() *Builder {
+	if len(b.stack) == 1 {
+		return b.errorf("End without Cluster")
+	}
+	b.stack = b.stack[:len(b.stack)-1]
+	b.last = b.current()
+	return b
+}
+
+// Build returns the graph, along with an ErrorList of the problems found
+// while building it: duplicate vertices, unbalanced clusters, invalid
+// attribute values and the problems reported by Graph.Validate, such as
+// edges to unknown vertices.  The graph is returned even when there are errors.
+func (b *Builder) Build() (*Graph, error) {
+	errs := append(ErrorList(nil), b.errs...)
+	if len(b.stack) > 1 {
+		errs = append(errs, fmt.Errorf("cluster %s not ended", b.current().Name))
+	}
+	if err := b.root.Validate(); err != nil {
+		errs = append(errs, err.(ErrorList)...)
+	}
+	if len(errs) > 0 {
+		return b.root, errs
+	}
+	return b.root, nil
+}
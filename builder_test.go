@@ -0,0 +1,57 @@
+package dot
+
+import (
+	"testing"
+)
+
+var builtGraph = `digraph g {
+rankdir="LR"
+subgraph cluster_peers {
+label="Peers"
+a [shape="box" ]
+b []
+}
+a -> b [ style="dashed" ]
+}`
+
+func TestBuilder(t *testing.T) {
+	g, err := New("g").Attr("rankdir", "LR").
+		Cluster("peers").Attr("label", "Peers").
+		Node("a").Attr("shape", "box").
+		Node("b").
+		End().
+		Edge("a", "b").Attr("style", "dashed").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := writeString(t, g); s != builtGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	g, err := New("g").
+		Node("a").Attr("peripheries", "two").
+		Node("a").
+		Vertex(VertexDescription{ID: "b", Shape: "bx"}).
+		Edge("a", "c").
+		End().
+		Cluster("open").
+		Build()
+	if g == nil {
+		t.Fatal("expected a graph")
+	}
+	expected := `invalid value "two" for attribute peripheries
+vertex a added twice
+End without Cluster
+cluster cluster_open not ended
+vertex b: unknown shape "bx"
+edge a -> c: undeclared vertex c`
+	if err == nil || err.Error() != expected {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if errs, ok := err.(ErrorList); !ok || len(errs) != 6 {
+		t.Errorf("expected 6 errors, found %#v", err)
+	}
+}
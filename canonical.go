@@ -0,0 +1,148 @@
+package dot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// Canonical returns a normalized copy of the graph, which is written the
+// same regardless of the order its elements were added in.  In the graph
+// and each subgraph, repeated statements for a vertex are merged with the
+// later values winning, edge chains are split into edges, the endpoints of
+// undirected edges are ordered, and Literal elements and the Comment of
+// vertices and edges are dropped.  The body is then sorted: vertices by
+// ID, edges by endpoints and attributes, rank groups by their vertices and
+// subgraphs by name.
+// IDs are quoted the same when written, however they were quoted when
+// parsed.
+func (graph *Graph) Canonical() *Graph {
+	c := graph.Clone()
+	c.canonicalize()
+	return c
+}
+
+func (graph *Graph) canonicalize() {
+	vertices := make(map[string]*VertexDescription)
+	var body []Element
+	addEdge := func(e *EdgeDescription) {
+		e.Comment = ""
+		if !e.Directed && (e.To.ID < e.From.ID || (e.To.ID == e.From.ID && e.ToPort+e.ToCompass < e.FromPort+e.FromCompass)) {
+			e.From, e.To = e.To, e.From
+			e.FromPort, e.ToPort = e.ToPort, e.FromPort
+			e.FromCompass, e.ToCompass = e.ToCompass, e.FromCompass
+		}
+		body = append(body, e)
+	}
+	for _, elem := range graph.Body {
+		switch e := elem.(type) {
+		case *Literal:
+		case *VertexDescription:
+			e.Comment = ""
+			if existing, ok := vertices[e.ID]; ok {
+				mergeVertex(existing, e, true)
+				continue
+			}
+			vertices[e.ID] = e
+			body = append(body, e)
+		case *EdgeDescription:
+			addEdge(e)
+		case *EdgeChain:
+			for _, edge := range e.Edges() {
+				edge := edge
+				addEdge(&edge)
+			}
+		case *RankGroup:
+			sort.Strings(e.IDs)
+			var ids []string
+			for i, id := range e.IDs {
+				if i == 0 || id != e.IDs[i-1] {
+					ids = append(ids, id)
+				}
+			}
+			e.IDs = ids
+			body = append(body, e)
+		case *Graph:
+			e.canonicalize()
+			body = append(body, e)
+		default:
+			body = append(body, elem)
+		}
+	}
+
+	keys := make([]string, len(body))
+	for i, elem := range body {
+		keys[i] = canonicalKey(elem)
+	}
+	sort.Stable(canonicalBody{body, keys})
+	graph.Body = body
+	graph.resetIndex()
+}
+
+// canonicalBody sorts the body of a graph by kind of element and key
+type canonicalBody struct {
+	body []Element
+	keys []string
+}
+
+func (c canonicalBody) Len() int {
+	return len(c.body)
+}
+
+func (c canonicalBody) Less(i, j int) bool {
+	ri, rj := elementRank(c.body[i]), elementRank(c.body[j])
+	if ri != rj {
+		return ri < rj
+	}
+	return c.keys[i] < c.keys[j]
+}
+
+func (c canonicalBody) Swap(i, j int) {
+	c.body[i], c.body[j] = c.body[j], c.body[i]
+	c.keys[i], c.keys[j] = c.keys[j], c.keys[i]
+}
+
+// canonicalKey returns the key by which elements of the same kind are
+// sorted by Canonical: the key used by WriteOptions.SortBody followed by
+// the written element, to order elements with the same key
+func canonicalKey(elem Element) string {
+	buf := new(bytes.Buffer)
+	if g, ok := elem.(*Graph); ok {
+		buf.WriteString(g.Name)
+	} else {
+		buf.WriteString(elementKey(elem))
+		buf.WriteByte(0)
+		elem.Write(buf)
+	}
+	return buf.String()
+}
+
+// Equal reports whether two graphs are the same once written in their
+// Canonical form, with the attributes of each element sorted by name.  The
+// order of the elements of the bodies, the way IDs were quoted, comments,
+// and whether attributes were set as fields or in Attrs do not matter.
+func Equal(a, b *Graph) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return canonicalString(a) == canonicalString(b)
+}
+
+// canonicalString returns the Canonical form of the graph written with
+// sorted attributes
+func canonicalString(graph *Graph) string {
+	buf := new(bytes.Buffer)
+	if err := graph.Canonical().WriteWithOptions(buf, WriteOptions{Canonical: true}); err != nil {
+		return "error: " + err.Error()
+	}
+	return buf.String()
+}
+
+// Hash returns a hex encoded SHA-256 digest of the graph in the form
+// compared by Equal, so that graphs which are Equal have the same hash.  It
+// is suitable as a cache key for rendered output.
+func (graph *Graph) Hash() string {
+	sum := sha256.Sum256([]byte(canonicalString(graph)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,141 @@
+package dot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	a, err := Parse(strings.NewReader(`graph g {
+// comment
+"b" [label="B"]
+a [color=red]
+subgraph cluster_y { y }
+subgraph cluster_x { x }
+b -- a [label="2"]
+a -- b [label="1"]
+c -- a -- b
+a [shape=box]
+{ rank=same; b; a; b }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse(strings.NewReader(`graph g {
+a [color=red shape=box] // merged
+b [label=B]
+{ rank=same; a; b }
+a -- c
+a -- b [label="1"]
+a -- b
+a -- b [label="2"]
+subgraph cluster_x { x }
+subgraph cluster_y { y }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `graph g {
+a [color="red" shape="box" ]
+b [label="B" ]
+a -- b
+a -- b [ label="1" ]
+a -- b [ label="2" ]
+a -- c
+subgraph  {
+rank="same"
+a []
+b []
+}
+subgraph cluster_x {
+x []
+}
+subgraph cluster_y {
+y []
+}
+}`
+	for _, g := range []*Graph{a, b} {
+		if s := g.Canonical().String(); s != expected {
+			t.Errorf("unexpected output: \n%s\n", s)
+		}
+	}
+	// the original is left unchanged
+	if !strings.Contains(a.String(), "// comment") {
+		t.Errorf("unexpected original: \n%s\n", a)
+	}
+
+	g := NewGraph("g")
+	x := &VertexDescription{ID: "x"}
+	y := &VertexDescription{ID: "y"}
+	g.AddSameRank(y, x, y)
+	g.AddEdgeChain(true, "", y, x, y)
+	expected = `digraph g {
+x -> y
+y -> x
+{ rank=same; x; y; }
+}`
+	if s := g.Canonical().String(); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a := NewGraph("g")
+	a.AddVertex(&VertexDescription{ID: "a", Label: "A"})
+	a.AddVertex(&VertexDescription{ID: "b"})
+	a.AddEdge(&VertexDescription{ID: "a"}, &VertexDescription{ID: "b"}, true, "")
+
+	b, err := Parse(strings.NewReader(`digraph "g" {
+"b"
+a -> b
+a [label="A"] // first vertex
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(&a, b) {
+		t.Errorf("expected graphs to be equal: \n%s\n%s", a.String(), b)
+	}
+
+	// attributes set in Attrs compare equal to fields
+	c := NewGraph("g")
+	c.AddEdge(&VertexDescription{ID: "a"}, &VertexDescription{ID: "b"}, true, "")
+	v := &VertexDescription{ID: "a"}
+	v.AddAttribute("label", "A")
+	c.AddVertex(v)
+	c.AddVertex(&VertexDescription{ID: "b"})
+	if !Equal(&a, &c) {
+		t.Errorf("expected graphs to be equal: \n%s\n%s", a.String(), c.String())
+	}
+
+	c.AddEdge(&VertexDescription{ID: "b"}, &VertexDescription{ID: "a"}, true, "")
+	if Equal(&a, &c) {
+		t.Error("expected graphs with different edges to differ")
+	}
+	b.Body[2].(*VertexDescription).Label = "B"
+	if Equal(&a, b) {
+		t.Error("expected graphs with different attributes to differ")
+	}
+	if Equal(&a, nil) || !Equal(nil, nil) {
+		t.Error("unexpected comparison with nil")
+	}
+}
+
+func TestHash(t *testing.T) {
+	a := NewGraph("g")
+	a.AddVertex(&VertexDescription{ID: "a"})
+	a.AddVertex(&VertexDescription{ID: "b"})
+	b := NewGraph("g")
+	b.AddVertex(&VertexDescription{ID: "b"})
+	b.AddVertex(&VertexDescription{ID: "a"})
+
+	h := a.Hash()
+	if len(h) != 64 || h != b.Hash() || h != a.Hash() {
+		t.Errorf("unexpected hashes %s and %s", h, b.Hash())
+	}
+	b.AddEdge(&VertexDescription{ID: "a"}, &VertexDescription{ID: "b"}, true, "")
+	if b.Hash() == h {
+		t.Error("expected different graphs to have different hashes")
+	}
+}
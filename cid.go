@@ -0,0 +1,29 @@
+package dot
+
+import "strings"
+
+// cidSuffixLength is the number of trailing characters of a CID kept in the
+// label of a CID vertex
+const cidSuffixLength = 4
+
+// NewCIDVertex returns a new VertexDescription for an IPFS content
+// identifier.  The label shows the multibase prefix and the last characters
+// of the CID, such as "Qm…abcd" or "bafy…abcd", the tooltip holds the full
+// CID and the ID is computed by SanitizeID.
+func NewCIDVertex(cid string) VertexDescription {
+	return NewVertexDescription(SanitizeID(cid), WithLabel(ShortCID(cid)), WithTooltip(cid))
+}
+
+// ShortCID shortens a CID to its prefix, "Qm" for CIDv0 and the first four
+// characters otherwise, and its last characters, separated by an Ellipsis.
+// CIDs too short to gain from it are returned unchanged.
+func ShortCID(cid string) string {
+	prefix := 4
+	if strings.HasPrefix(cid, "Qm") {
+		prefix = 2
+	}
+	if len(cid) <= prefix+cidSuffixLength+1 {
+		return cid
+	}
+	return cid[:prefix] + Ellipsis + cid[len(cid)-cidSuffixLength:]
+}
@@ -0,0 +1,31 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewCIDVertex(t *testing.T) {
+	tests := map[string]string{
+		"QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG":              "Qm…PbdG",
+		"bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi": "bafy…bzdi",
+		"bafk1234": "bafk1234",
+		"Qm12345":  "Qm12345",
+	}
+	for cid, label := range tests {
+		v := NewCIDVertex(cid)
+		if v.Label != label || v.Tooltip != cid || v.ID != cid {
+			t.Errorf("unexpected vertex %+v for %s", v, cid)
+		}
+	}
+
+	v := NewCIDVertex("bafkreidgvpkjawlxz6sffxzwgooowe5yt7i6wsyg236mfoks77nywkptdq")
+	buf := new(bytes.Buffer)
+	if err := v.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `bafkreidgvpkjawlxz6sffxzwgooowe5yt7i6wsyg236mfoks77nywkptdq [label="bafk…ptdq" tooltip="bafkreidgvpkjawlxz6sffxzwgooowe5yt7i6wsyg236mfoks77nywkptdq" ]`
+	if s := buf.String(); s != expected {
+		t.Errorf("unexpected output %s", s)
+	}
+}
@@ -0,0 +1,76 @@
+package dot
+
+// Clone returns a deep copy of the graph.  The elements of the body, nested
+// subgraphs, defaults and attributes are copied, so that the copy can be
+// changed without affecting the original.  Elements of types defined
+// outside this package are shared.
+func (graph *Graph) Clone() *Graph {
+	return graph.clone(graph.parent)
+}
+
+// clone returns a deep copy of the graph, with parent as the parent of the
+// copy if the graph has one
+func (graph *Graph) clone(parent *Graph) *Graph {
+	c := *graph
+	if graph.parent != nil {
+		c.parent = parent
+	}
+	c.Attrs = copyAttrs(graph.Attrs)
+	c.sanitized = copyAttrs(graph.sanitized)
+	if graph.NodeDefaults != nil {
+		c.NodeDefaults = copyVertex(graph.NodeDefaults)
+	}
+	if graph.EdgeDefaults != nil {
+		c.EdgeDefaults = copyEdge(graph.EdgeDefaults)
+	}
+	if graph.Margin != nil {
+		margin := *graph.Margin
+		c.Margin = &margin
+	}
+	if graph.Pad != nil {
+		pad := *graph.Pad
+		c.Pad = &pad
+	}
+	if graph.Size != nil {
+		size := *graph.Size
+		c.Size = &size
+	}
+	if graph.Layers != nil {
+		c.Layers = append([]string(nil), graph.Layers...)
+	}
+	if graph.Body != nil {
+		c.Body = make([]Element, 0, len(graph.Body))
+	}
+	for _, elem := range graph.Body {
+		c.Body = append(c.Body, cloneElement(elem, &c))
+	}
+	return &c
+}
+
+// cloneElement returns a deep copy of an element of the body of parent
+func cloneElement(elem Element, parent *Graph) Element {
+	switch e := elem.(type) {
+	case *VertexDescription:
+		return copyVertex(e)
+	case *EdgeDescription:
+		return copyEdge(e)
+	case *EdgeChain:
+		chain := *e
+		chain.Vertices = make([]VertexDescription, len(e.Vertices))
+		for i := range e.Vertices {
+			chain.Vertices[i] = *copyVertex(&e.Vertices[i])
+		}
+		return &chain
+	case *RankGroup:
+		group := *e
+		group.IDs = append([]string(nil), e.IDs...)
+		return &group
+	case *Literal:
+		lit := *e
+		return &lit
+	case *Graph:
+		return e.clone(parent)
+	default:
+		return elem
+	}
+}
@@ -0,0 +1,57 @@
+package dot
+
+import (
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.Margin = &Margin{X: 1, Y: 1}
+	g.Layers = []string{"a", "b"}
+	g.SetNodeDefaults(VertexDescription{Shape: "box"})
+	cluster := g.AddCluster("peers")
+	a := &VertexDescription{ID: "a"}
+	a.PinAt(1, 2)
+	b := &VertexDescription{ID: "b"}
+	cluster.AddVertex(a)
+	cluster.AddVertex(b)
+	g.AddEdge(a, b, true, "")
+	g.Body[1].(*EdgeDescription).Constraint = Bool(true)
+	g.AddEdgeChain(true, "", a, b)
+	g.AddSameRank(a, b)
+	g.AddComment("topology")
+
+	c := g.Clone()
+	expected := writeString(t, &g)
+	if s := writeString(t, c); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	// change everything reachable from the clone
+	c.Name = "clone"
+	c.Margin.X = 2
+	c.Layers[0] = "c"
+	c.NodeDefaults.Shape = "ellipse"
+	cc := c.Body[0].(*Graph)
+	cc.Label = "changed"
+	cc.Body[0].(*VertexDescription).Pos.X = 5
+	cc.Body[1].(*VertexDescription).AddAttribute("k", "v")
+	e := c.Body[1].(*EdgeDescription)
+	*e.Constraint = false
+	e.From.ID = "x"
+	c.Body[2].(*EdgeChain).Vertices[0].ID = "y"
+	c.Body[3].(*RankGroup).IDs[0] = "z"
+	c.Body[4].(*Literal).Line = "changed"
+	c.AddVertex(&VertexDescription{ID: "new"})
+	cc.AddVertex(&VertexDescription{ID: "new"})
+
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("original changed by the clone: \n%s\n", s)
+	}
+	if cc.Parent() != c || cluster.Parent() != &g {
+		t.Error("unexpected parents after clone")
+	}
+	if nested := cc.Clone(); nested.Parent() != c || nested.Depth() != 1 {
+		t.Error("unexpected parent of cloned cluster")
+	}
+}
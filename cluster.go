@@ -0,0 +1,47 @@
+package dot
+
+import "strconv"
+
+// AddCluster adds a new cluster with the given label to the graph and
+// returns it.  Clusters are named after their position in the hierarchy:
+// the clusters of the root graph are cluster_0, cluster_1, …, and those
+// nested in cluster_1 are cluster_1_0, cluster_1_1, and so on.  Names
+// already taken by other subgraphs of the graph are skipped.  Nested
+// clusters are indented when written with WriteOptions.Indent.
+func (graph *Graph) AddCluster(label string) *Graph {
+	prefix := "cluster_"
+	if graph.IsCluster() {
+		prefix = graph.Name + "_"
+	}
+	name := ""
+	for i := 0; ; i++ {
+		name = prefix + strconv.Itoa(i)
+		if findSubGraph(graph, name) == nil {
+			break
+		}
+	}
+	cluster := &Graph{
+		Name:       name,
+		IsSubGraph: true,
+		Label:      label,
+		parent:     graph,
+	}
+	graph.AddSubGraph(cluster)
+	return cluster
+}
+
+// Depth returns the number of clusters enclosing a cluster created with
+// AddCluster, starting at 1 for clusters of the root graph.  Other graphs
+// have depth 0.
+func (graph *Graph) Depth() int {
+	depth := 0
+	for g := graph; g.parent != nil; g = g.parent {
+		depth++
+	}
+	return depth
+}
+
+// Parent returns the graph a cluster was added to with AddCluster, or nil
+func (graph *Graph) Parent() *Graph {
+	return graph.parent
+}
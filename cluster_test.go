@@ -0,0 +1,60 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+var nestedClusters = `digraph testGraph {
+  subgraph cluster_0 {
+    label="eu-west"
+    subgraph cluster_0_0 {
+      label="dc1"
+      subgraph cluster_0_0_0 {
+        label="host1"
+        peer1 []
+      }
+      subgraph cluster_0_0_1 {
+        label="host2"
+        peer2 []
+      }
+    }
+  }
+  subgraph cluster_1 {
+  }
+  subgraph cluster_2 {
+    label="us-east"
+  }
+}`
+
+func TestAddCluster(t *testing.T) {
+	g := NewGraph("testGraph")
+	region := g.AddCluster("eu-west")
+	dc := region.AddCluster("dc1")
+	host1 := dc.AddCluster("host1")
+	host1.AddVertex(&VertexDescription{ID: "peer1"})
+	host2 := dc.AddCluster("host2")
+	host2.AddVertex(&VertexDescription{ID: "peer2"})
+
+	// a manually named cluster takes the next name
+	manual := NewCluster("1")
+	g.Body = append(g.Body, &manual)
+	us := g.AddCluster("us-east")
+
+	if us.Name != "cluster_2" {
+		t.Errorf("unexpected name %s", us.Name)
+	}
+	if g.Depth() != 0 || region.Depth() != 1 || host2.Depth() != 3 {
+		t.Errorf("unexpected depths %d %d %d", g.Depth(), region.Depth(), host2.Depth())
+	}
+	if host2.Parent() != dc || g.Parent() != nil {
+		t.Error("unexpected parent")
+	}
+	buf := new(bytes.Buffer)
+	if err := g.WriteWithOptions(buf, WriteOptions{Indent: "  "}); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != nestedClusters {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
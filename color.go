@@ -0,0 +1,189 @@
+package dot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Color is a graphviz color value: a color name, an "#RRGGBB" or
+// "#RRGGBBAA" hex value, an "H,S,V" triple of numbers between 0 and 1, or a
+// "/scheme/color" reference to a color of a named scheme.  Its string form
+// is assigned to the color fields of vertices, edges and graphs.
+type Color string
+
+// RGB returns the color with the given red, green and blue components
+func RGB(r, g, b uint8) Color {
+	return Color(fmt.Sprintf("#%02x%02x%02x", r, g, b))
+}
+
+// RGBA returns the color with the given red, green, blue and alpha
+// components
+func RGBA(r, g, b, a uint8) Color {
+	return Color(fmt.Sprintf("#%02x%02x%02x%02x", r, g, b, a))
+}
+
+// HSV returns the color with the given hue, saturation and value, each
+// between 0 and 1
+func HSV(h, s, v float64) Color {
+	return Color(fmt.Sprintf("%.3f %.3f %.3f", h, s, v))
+}
+
+// SchemeColor returns a reference to a color of a named scheme, such as the
+// third color of the Brewer scheme "blues9" or the "red" of the x11 scheme.
+func SchemeColor(scheme, color string) Color {
+	return Color("/" + scheme + "/" + color)
+}
+
+// ColorStop is an element of a color list.  Weight is the fraction of the
+// edge or fill given to the color, and a zero weight shares the remaining
+// fraction with the other stops without a weight.
+type ColorStop struct {
+	Color  Color
+	Weight float64
+}
+
+// ColorList returns a list of colors, drawn as parallel lines on edges and
+// as stripes or wedges of the fill of vertices and clusters
+func ColorList(stops ...ColorStop) Color {
+	parts := make([]string, len(stops))
+	for i, stop := range stops {
+		parts[i] = string(stop.Color)
+		if stop.Weight != 0 {
+			parts[i] += ";" + formatFloat(stop.Weight)
+		}
+	}
+	return Color(strings.Join(parts, ":"))
+}
+
+// Gradient returns a fill fading from one color to another.  It applies to
+// filled vertices and clusters, whose GradientAngle sets its direction.
+func Gradient(from, to Color) Color {
+	return Color(string(from) + ":" + string(to))
+}
+
+// String returns the color as written in an attribute
+func (c Color) String() string {
+	return string(c)
+}
+
+// brewerSchemes holds the largest number of colors of each Brewer color
+// scheme.  Schemes are named by appending a number of colors, from 3 up to
+// that maximum, to the base name.
+var brewerSchemes = map[string]int{
+	"accent": 8, "blues": 9, "brbg": 11, "bugn": 9, "bupu": 9, "dark2": 8,
+	"gnbu": 9, "greens": 9, "greys": 9, "oranges": 9, "orrd": 9, "paired": 12,
+	"pastel1": 9, "pastel2": 8, "piyg": 11, "prgn": 11, "pubu": 9, "pubugn": 9,
+	"puor": 11, "purd": 9, "purples": 9, "rdbu": 11, "rdgy": 11, "rdpu": 9,
+	"rdylbu": 11, "rdylgn": 11, "reds": 9, "set1": 9, "set2": 8, "set3": 12,
+	"spectral": 11, "ylgn": 9, "ylgnbu": 9, "ylorbr": 9, "ylorrd": 9,
+}
+
+// Validate reports whether graphviz understands the color.  Names are
+// checked against the x11 and svg color schemes, and scheme references
+// against the x11, svg and Brewer schemes.  Colors relying on a colorscheme
+// attribute, such as a bare Brewer index, do not validate and should be
+// written as scheme references instead.
+func (c Color) Validate() error {
+	s := string(c)
+	if strings.ContainsAny(s, ":;") {
+		return validateColorList(s)
+	}
+	switch {
+	case s == "":
+		return fmt.Errorf("empty color")
+	case s[0] == '#':
+		return validateHexColor(s)
+	case s[0] == '/':
+		return validateSchemeColor(s)
+	case s[0] == '.' || (s[0] >= '0' && s[0] <= '9'):
+		return validateHSVColor(s)
+	case colorNames[strings.ToLower(s)]:
+		return nil
+	}
+	return fmt.Errorf("unknown color name %q", s)
+}
+
+// validateColorList checks each color of a list and the weights given to
+// them, which must add up to at most 1
+func validateColorList(s string) error {
+	total := 0.0
+	for _, part := range strings.Split(s, ":") {
+		color := part
+		if i := strings.IndexByte(part, ';'); i >= 0 {
+			color = part[:i]
+			w, err := strconv.ParseFloat(part[i+1:], 64)
+			if err != nil || w < 0 || w > 1 {
+				return fmt.Errorf("color list %q: weights must be numbers between 0 and 1", s)
+			}
+			total += w
+		}
+		if err := Color(color).Validate(); err != nil {
+			return fmt.Errorf("color list %q: %v", s, err)
+		}
+	}
+	if total > 1 {
+		return fmt.Errorf("color list %q: weights add up to more than 1", s)
+	}
+	return nil
+}
+
+func validateHexColor(s string) error {
+	digits := s[1:]
+	if len(digits) != 6 && len(digits) != 8 {
+		return fmt.Errorf("color %q: expected 6 or 8 hex digits", s)
+	}
+	if _, err := strconv.ParseUint(digits, 16, 32); err != nil {
+		return fmt.Errorf("color %q: invalid hex digits", s)
+	}
+	return nil
+}
+
+func validateHSVColor(s string) error {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if len(parts) != 3 && len(parts) != 4 {
+		return fmt.Errorf("color %q: expected 3 or 4 HSV components", s)
+	}
+	for _, part := range parts {
+		f, err := strconv.ParseFloat(part, 64)
+		if err != nil || f < 0 || f > 1 {
+			return fmt.Errorf("color %q: HSV components must be numbers between 0 and 1", s)
+		}
+	}
+	return nil
+}
+
+func validateSchemeColor(s string) error {
+	parts := strings.Split(s[1:], "/")
+	if len(parts) != 2 || parts[1] == "" {
+		return fmt.Errorf("color %q: expected /scheme/color", s)
+	}
+	scheme, color := strings.ToLower(parts[0]), strings.ToLower(parts[1])
+	switch scheme {
+	case "", "x11", "svg":
+		if !colorNames[color] {
+			return fmt.Errorf("color %q: unknown color name %q", s, parts[1])
+		}
+		return nil
+	}
+
+	// schemes such as set39 append the number of colors to a base name
+	// ending in a digit, so match against every base name
+	for base, max := range brewerSchemes {
+		if !strings.HasPrefix(scheme, base) {
+			continue
+		}
+		n, err := strconv.Atoi(scheme[len(base):])
+		if err != nil || n < 3 || n > max {
+			continue
+		}
+		index, err := strconv.Atoi(color)
+		if err != nil || index < 1 || index > n {
+			return fmt.Errorf("color %q: scheme %s has colors 1 to %d", s, scheme, n)
+		}
+		return nil
+	}
+	return fmt.Errorf("color %q: unknown color scheme %q", s, parts[0])
+}
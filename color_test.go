@@ -0,0 +1,72 @@
+package dot
+
+import (
+	"testing"
+)
+
+func TestColorHelpers(t *testing.T) {
+	tests := map[Color]string{
+		RGB(255, 0, 16):                "#ff0010",
+		RGBA(1, 2, 3, 128):             "#01020380",
+		HSV(0.5, 1, 0.25):              "0.500 1.000 0.250",
+		SchemeColor("blues9", "3"):     "/blues9/3",
+		SchemeColor("x11", "navyblue"): "/x11/navyblue",
+	}
+	for c, expected := range tests {
+		if c.String() != expected {
+			t.Errorf("expected %s, got %s", expected, c)
+		}
+		if err := c.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestColorList(t *testing.T) {
+	tests := map[Color]string{
+		ColorList(ColorStop{Color: "red"}, ColorStop{Color: RGB(0, 0, 255)}):              "red:#0000ff",
+		ColorList(ColorStop{Color: "red", Weight: 0.25}, ColorStop{Color: "blue"}):        "red;0.25:blue",
+		ColorList(ColorStop{"red", 0.5}, ColorStop{"green", 0.3}, ColorStop{"blue", 0.2}): "red;0.5:green;0.3:blue;0.2",
+		Gradient("white", SchemeColor("blues9", "7")):                                     "white:/blues9/7",
+	}
+	for c, expected := range tests {
+		if c.String() != expected {
+			t.Errorf("expected %s, got %s", expected, c)
+		}
+		if err := c.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	invalid := []Color{"red:", "red:bleu", "red;2", "red;x:blue", "red;0.7:blue;0.7"}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("color list %q should be invalid", c)
+		}
+	}
+}
+
+func TestColorValidate(t *testing.T) {
+	valid := []Color{
+		"red", "Red", "navyblue", "gray42", "transparent", "crimson",
+		"#00ff00", "#00FF00aa",
+		"0.1,0.2,0.3", "0.1 0.2 0.3", ".5, 1, 0",
+		"//red", "/svg/aqua", "/set39/9", "/paired12/12", "/pubugn3/1",
+	}
+	for _, c := range valid {
+		if err := c.Validate(); err != nil {
+			t.Errorf("color %q should be valid: %s", c, err)
+		}
+	}
+
+	invalid := []Color{
+		"", "rde", "#00ff0", "#00gg00",
+		"0.1,0.2", "0.1,0.2,1.5",
+		"/blues/3", "/blues10/3", "/blues9/10", "/blues9/x", "/nope9/1", "/x11/rde", "/x11",
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("color %q should be invalid", c)
+		}
+	}
+}
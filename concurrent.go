@@ -0,0 +1,98 @@
+package dot
+
+import (
+	"io"
+	"sync"
+)
+
+// ConcurrentGraph wraps a Graph so that elements can be added to it from
+// several goroutines.  The methods of ConcurrentGraph are serialized by a
+// mutex.  The wrapped graph must only be accessed through them, or through
+// Do, until all goroutines adding to it are done.
+type ConcurrentGraph struct {
+	mu    sync.Mutex
+	graph *Graph
+}
+
+// NewConcurrentGraph returns a ConcurrentGraph adding elements to graph
+func NewConcurrentGraph(graph *Graph) *ConcurrentGraph {
+	return &ConcurrentGraph{graph: graph}
+}
+
+// Do calls fn with the wrapped graph while holding the lock, for any access
+// not covered by the other methods.  fn must not call methods of c.
+func (c *ConcurrentGraph) Do(fn func(graph *Graph)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn(c.graph)
+}
+
+// AddVertex schedules the vertex on the wrapped graph, see Graph.AddVertex
+func (c *ConcurrentGraph) AddVertex(v *VertexDescription) {
+	c.Do(func(graph *Graph) {
+		graph.AddVertex(v)
+	})
+}
+
+// AddEdge schedules an edge on the wrapped graph, see Graph.AddEdge
+func (c *ConcurrentGraph) AddEdge(v1 *VertexDescription, v2 *VertexDescription, directed bool, style string) {
+	c.Do(func(graph *Graph) {
+		graph.AddEdge(v1, v2, directed, style)
+	})
+}
+
+// AddEdgeChain schedules an edge chain on the wrapped graph, see
+// Graph.AddEdgeChain
+func (c *ConcurrentGraph) AddEdgeChain(directed bool, style string, vs ...*VertexDescription) {
+	c.Do(func(graph *Graph) {
+		graph.AddEdgeChain(directed, style, vs...)
+	})
+}
+
+// AddSameRank schedules a rank group on the wrapped graph, see
+// Graph.AddSameRank
+func (c *ConcurrentGraph) AddSameRank(vertices ...*VertexDescription) {
+	c.Do(func(graph *Graph) {
+		graph.AddSameRank(vertices...)
+	})
+}
+
+// AddComment schedules a comment on the wrapped graph
+func (c *ConcurrentGraph) AddComment(text string) {
+	c.Do(func(graph *Graph) {
+		graph.AddComment(text)
+	})
+}
+
+// AddSubGraph schedules a subgraph on the wrapped graph.  The subgraph must
+// not be modified afterwards, other than through Do.
+func (c *ConcurrentGraph) AddSubGraph(sGraph *Graph) {
+	c.Do(func(graph *Graph) {
+		graph.AddSubGraph(sGraph)
+	})
+}
+
+// AddAttribute sets an arbitrary graphviz attribute on the wrapped graph
+func (c *ConcurrentGraph) AddAttribute(key, value string) {
+	c.Do(func(graph *Graph) {
+		graph.AddAttribute(key, value)
+	})
+}
+
+// Write writes the wrapped graph to a writer while holding the lock
+func (c *ConcurrentGraph) Write(w io.Writer) error {
+	var err error
+	c.Do(func(graph *Graph) {
+		err = graph.Write(w)
+	})
+	return err
+}
+
+// String returns the dot representation of the wrapped graph
+func (c *ConcurrentGraph) String() string {
+	var s string
+	c.Do(func(graph *Graph) {
+		s = graph.String()
+	})
+	return s
+}
@@ -0,0 +1,46 @@
+package dot
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentGraph(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.Dedup = DedupMerge
+	c := NewConcurrentGraph(&g)
+	root := &VertexDescription{ID: "root"}
+	c.AddVertex(root)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v := &VertexDescription{ID: "v" + strconv.Itoa(i)}
+			c.AddVertex(v)
+			c.AddVertex(&VertexDescription{ID: "root", Color: "red"})
+			c.AddEdge(root, v, true, "")
+		}(i)
+	}
+	wg.Wait()
+
+	vertices, edges := 0, 0
+	c.Do(func(graph *Graph) {
+		for _, elem := range graph.Body {
+			switch elem.(type) {
+			case *VertexDescription:
+				vertices++
+			case *EdgeDescription:
+				edges++
+			}
+		}
+	})
+	if vertices != 51 || edges != 50 {
+		t.Errorf("expected 51 vertices and 50 edges, found %d and %d", vertices, edges)
+	}
+	if v := g.FindVertex("root"); v.Color != "red" {
+		t.Errorf("vertices not merged: %+v", v)
+	}
+}
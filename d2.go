@@ -0,0 +1,216 @@
+package dot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// d2Shapes maps graphviz node shapes to D2 shapes
+var d2Shapes = map[Shape]string{
+	"":              "oval",
+	"ellipse":       "oval",
+	"oval":          "oval",
+	"box":           "rectangle",
+	"rect":          "rectangle",
+	"rectangle":     "rectangle",
+	"record":        "rectangle",
+	"Mrecord":       "rectangle",
+	"square":        "square",
+	"circle":        "circle",
+	"doublecircle":  "circle",
+	"diamond":       "diamond",
+	"hexagon":       "hexagon",
+	"cylinder":      "cylinder",
+	"parallelogram": "parallelogram",
+	"note":          "page",
+	"folder":        "package",
+	"tab":           "package",
+}
+
+// d2Directions maps graphviz rank directions to D2 directions
+var d2Directions = map[string]string{
+	RankDirTB: "down",
+	RankDirBT: "up",
+	RankDirLR: "right",
+	RankDirRL: "left",
+}
+
+// WriteD2 writes the graph in the D2 diagram language.  Clusters become D2
+// containers, while the contents of other subgraphs are merged into their
+// parent.  Vertex shapes, colors and edge styles are mapped to their closest
+// D2 equivalent.  Edges are written after all vertices and refer to
+// vertices declared in a container by their full path.  Literal and
+// RankGroup elements are skipped.
+func (graph *Graph) WriteD2(w io.Writer) error {
+	d := &d2Writer{
+		paths: make(map[string]string),
+	}
+	if dir, ok := d2Directions[graph.RankDir]; ok {
+		d.line(0, "direction: %s", dir)
+	}
+	d.body(graph, "", 0)
+	for _, e := range d.edges {
+		d.edge(e)
+	}
+	_, err := io.WriteString(w, d.buf.String())
+	return err
+}
+
+type d2Writer struct {
+	buf strings.Builder
+	// paths maps vertex IDs to their D2 path
+	paths map[string]string
+	edges []*EdgeDescription
+}
+
+func (d *d2Writer) line(depth int, format string, args ...interface{}) {
+	d.buf.WriteString(strings.Repeat("  ", depth))
+	fmt.Fprintf(&d.buf, format, args...)
+	d.buf.WriteByte('\n')
+}
+
+// block writes a D2 object declaration with an optional label and map of
+// properties
+func (d *d2Writer) block(depth int, key, label string, props []string, content func()) {
+	decl := key
+	if label != "" {
+		decl += ": " + d2String(label)
+	}
+	if len(props) == 0 && content == nil {
+		d.line(depth, "%s", decl)
+		return
+	}
+	d.line(depth, "%s {", decl)
+	for _, prop := range props {
+		d.line(depth+1, "%s", prop)
+	}
+	if content != nil {
+		content()
+	}
+	d.line(depth, "}")
+}
+
+func (d *d2Writer) body(graph *Graph, prefix string, depth int) {
+	for _, elem := range graph.Body {
+		switch e := elem.(type) {
+		case *VertexDescription:
+			d.vertex(e, prefix, depth)
+		case *EdgeDescription:
+			d.edges = append(d.edges, e)
+		case *EdgeChain:
+			for _, edge := range e.Edges() {
+				edge := edge
+				d.edges = append(d.edges, &edge)
+			}
+		case *Graph:
+			if !e.IsCluster() {
+				d.body(e, prefix, depth)
+				continue
+			}
+			key := d2String(e.Name)
+			var props []string
+			if e.BgColor != "" {
+				props = append(props, "style.fill: "+d2String(e.BgColor))
+			}
+			if e.Color != "" {
+				props = append(props, "style.stroke: "+d2String(e.Color))
+			}
+			d.block(depth, key, e.Label, props, func() {
+				d.body(e, prefix+key+".", depth+1)
+			})
+		}
+	}
+}
+
+func (d *d2Writer) vertex(v *VertexDescription, prefix string, depth int) {
+	key := d2String(v.ID)
+	if _, ok := d.paths[v.ID]; !ok {
+		d.paths[v.ID] = prefix + key
+	}
+	attrs := v.attributes()
+	label, _ := findAttribute(attrs, "label")
+	shapeName, _ := findAttribute(attrs, "shape")
+	color, _ := findAttribute(attrs, "color")
+	fontColor, _ := findAttribute(attrs, "fontcolor")
+	style, _ := findAttribute(attrs, "style")
+	var props []string
+	shape, ok := d2Shapes[Shape(shapeName)]
+	if !ok {
+		shape = "rectangle"
+	}
+	if shape != "rectangle" {
+		props = append(props, "shape: "+shape)
+	}
+	if color != "" {
+		props = append(props, "style.stroke: "+d2String(color))
+		if strings.Contains(style, "filled") {
+			props = append(props, "style.fill: "+d2String(color))
+		}
+	}
+	if fontColor != "" {
+		props = append(props, "style.font-color: "+d2String(fontColor))
+	}
+	props = append(props, d2StyleProps(style)...)
+	d.block(depth, key, label, props, nil)
+}
+
+func (d *d2Writer) edge(e *EdgeDescription) {
+	link := "--"
+	if e.Directed {
+		link = "->"
+	}
+	var label string
+	for _, attr := range e.attributes() {
+		if attr.name == "label" {
+			label = attr.value
+		}
+	}
+	key := fmt.Sprintf("%s %s %s", d.path(e.From.ID), link, d.path(e.To.ID))
+	d.block(0, key, label, d2StyleProps(e.Style), nil)
+}
+
+// path returns the D2 path of a vertex, which is just its key if it was
+// never declared
+func (d *d2Writer) path(id string) string {
+	if path, ok := d.paths[id]; ok {
+		return path
+	}
+	return d2String(id)
+}
+
+// d2StyleProps maps graphviz line styles to D2 style properties
+func d2StyleProps(style string) []string {
+	var props []string
+	if strings.Contains(style, "dashed") {
+		props = append(props, "style.stroke-dash: 5")
+	}
+	if strings.Contains(style, "dotted") {
+		props = append(props, "style.stroke-dash: 2")
+	}
+	if strings.Contains(style, "bold") {
+		props = append(props, "style.stroke-width: 3")
+	}
+	if strings.Contains(style, "invis") {
+		props = append(props, "style.opacity: 0")
+	}
+	return props
+}
+
+// d2String returns s unchanged if it is a plain D2 key, otherwise double
+// quoted with quotes and backslashes escaped
+func d2String(s string) string {
+	plain := s != ""
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return s
+	}
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+}
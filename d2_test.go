@@ -0,0 +1,56 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+var d2Graph = `direction: right
+a: Peer {
+  shape: oval
+  style.stroke: red
+}
+cluster_ipfs: IPFS {
+  style.fill: lightgrey
+  "data store": "the store" {
+    shape: cylinder
+  }
+  b
+}
+a -> cluster_ipfs."data store"
+a -> cluster_ipfs.b: replica {
+  style.stroke-dash: 5
+}
+cluster_ipfs.b -- c
+`
+
+func TestWriteD2(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.RankDir = RankDirLR
+	a := &VertexDescription{ID: "a", Label: "Peer", Color: "red"}
+	store := &VertexDescription{ID: "data store", Label: "the store"}
+	store.AddAttribute("shape", "cylinder")
+	b := &VertexDescription{ID: "b", Shape: "box"}
+	c := &VertexDescription{ID: "c"}
+	g.AddVertex(a)
+	sub := NewCluster("ipfs")
+	sub.Label = "IPFS"
+	sub.BgColor = "lightgrey"
+	sub.AddVertex(store)
+	sub.AddVertex(b)
+	g.AddSubGraph(&sub)
+	g.AddEdge(a, store, true, "")
+	e := &EdgeDescription{From: *a, To: *b, Directed: true, Style: "dashed"}
+	e.AddAttribute("label", "replica")
+	g.Body = append(g.Body, e)
+	g.AddEdge(b, c, false, "")
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteD2(buf); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != d2Graph {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", d2Graph)
+	}
+}
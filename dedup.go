@@ -0,0 +1,56 @@
+package dot
+
+import (
+	"fmt"
+)
+
+// DedupMode selects how a Graph handles vertices added with an ID which is
+// already present
+type DedupMode int
+
+const (
+	// DedupNone schedules every added vertex, so the output may contain
+	// several node statements for the same ID
+	DedupNone DedupMode = iota
+	// DedupMerge merges the attributes of the added vertex into the
+	// existing one, with the values of the later vertex winning
+	DedupMerge
+	// DedupError merges the attributes of the added vertex into the
+	// existing one.  Setting an attribute to a different value than it
+	// already has is a conflict, reported when the graph is written.
+	DedupError
+)
+
+// mergeVertex copies the attributes set on src to dst.  When overwrite is
+// false, attributes with different values on both are left unchanged and an
+// error describing the first conflict is returned.
+func mergeVertex(dst, src *VertexDescription, overwrite bool) error {
+	var conflict error
+	conflictf := func(name, old, new string) {
+		if conflict == nil {
+			conflict = fmt.Errorf("vertex %s: conflicting values %q and %q for attribute %s", dst.ID, old, new, name)
+		}
+	}
+
+	for _, field := range vertexFields {
+		value := field.get(src)
+		if value == "" {
+			continue
+		}
+		old := field.get(dst)
+		if old != "" && old != value && !overwrite {
+			conflictf(field.name, old, value)
+			continue
+		}
+		field.set(dst, value)
+	}
+	for _, key := range sortedKeys(src.Attrs) {
+		value := src.Attrs[key]
+		if old, ok := dst.Attrs[key]; ok && old != value && !overwrite {
+			conflictf(key, old, value)
+			continue
+		}
+		dst.AddAttribute(key, value)
+	}
+	return conflict
+}
@@ -0,0 +1,49 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDedupMerge(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.Dedup = DedupMerge
+	g.AddVertex(&VertexDescription{ID: "a", Label: "first", Color: "red"})
+	sub := NewCluster("sub")
+	g.AddSubGraph(&sub)
+	v := &VertexDescription{ID: "a", Label: "second", Shape: "box"}
+	v.AddAttribute("tooltip", "tip")
+	g.AddVertex(v)
+	g.AddVertex(&VertexDescription{ID: "b"})
+
+	expected := `digraph testGraph {
+a [label="second" color="red" shape="box" tooltip="tip" ]
+subgraph cluster_sub {
+}
+b []
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", expected)
+	}
+}
+
+func TestDedupError(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.Dedup = DedupError
+	g.AddVertex(&VertexDescription{ID: "a", Label: "first"})
+	// identical and new attributes are not conflicts
+	g.AddVertex(&VertexDescription{ID: "a", Label: "first", Peripheries: 2})
+	if len(g.Body) != 1 || g.Body[0].(*VertexDescription).Peripheries != 2 {
+		t.Fatalf("unexpected body %+v", g.Body)
+	}
+	writeString(t, &g)
+
+	g.AddVertex(&VertexDescription{ID: "a", Label: "second"})
+	if g.Body[0].(*VertexDescription).Label != "first" {
+		t.Error("conflicting attribute overwritten")
+	}
+	if err := g.Write(new(bytes.Buffer)); err == nil {
+		t.Error("expected conflict error")
+	}
+}
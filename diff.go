@@ -0,0 +1,233 @@
+package dot
+
+import (
+	"reflect"
+)
+
+// Colors used by GraphDiff.Graph to mark differences
+const (
+	DiffAddedColor   = "green"
+	DiffRemovedColor = "red"
+	DiffChangedColor = "orange"
+)
+
+// VertexChange is a vertex present in both graphs with different
+// attributes
+type VertexChange struct {
+	Old, New *VertexDescription
+}
+
+// EdgeChange is an edge present in both graphs with different attributes
+type EdgeChange struct {
+	Old, New *EdgeDescription
+}
+
+// GraphDiff lists the vertices and edges which differ between two graphs
+type GraphDiff struct {
+	AddedVertices   []*VertexDescription
+	RemovedVertices []*VertexDescription
+	ChangedVertices []VertexChange
+	AddedEdges      []*EdgeDescription
+	RemovedEdges    []*EdgeDescription
+	ChangedEdges    []EdgeChange
+
+	directed bool
+	// all vertices and edges of both graphs, with their difference color
+	vertices []diffVertex
+	edges    []diffEdge
+}
+
+type diffVertex struct {
+	v     *VertexDescription
+	color string
+}
+
+type diffEdge struct {
+	e     *EdgeDescription
+	color string
+}
+
+// Diff compares graph a to graph b.  Vertices are matched by ID and edges
+// by their endpoints, ports and direction, in the order they appear when
+// several edges connect the same endpoints.  Repeated vertex statements are
+// merged and vertices which only appear as edge endpoints are included.
+// Subgraph structure and graph attributes are not compared.
+func Diff(a, b *Graph) *GraphDiff {
+	d := &GraphDiff{directed: !a.IsUndirected || !b.IsUndirected}
+	oldVertices, oldOrder := collectVertices(a)
+	newVertices, newOrder := collectVertices(b)
+	for _, id := range newOrder {
+		v := newVertices[id]
+		old, ok := oldVertices[id]
+		switch {
+		case !ok:
+			d.AddedVertices = append(d.AddedVertices, v)
+			d.vertices = append(d.vertices, diffVertex{v, DiffAddedColor})
+		case !reflect.DeepEqual(old.attributes(), v.attributes()):
+			d.ChangedVertices = append(d.ChangedVertices, VertexChange{old, v})
+			d.vertices = append(d.vertices, diffVertex{v, DiffChangedColor})
+		default:
+			d.vertices = append(d.vertices, diffVertex{v, ""})
+		}
+	}
+	for _, id := range oldOrder {
+		if _, ok := newVertices[id]; !ok {
+			v := oldVertices[id]
+			d.RemovedVertices = append(d.RemovedVertices, v)
+			d.vertices = append(d.vertices, diffVertex{v, DiffRemovedColor})
+		}
+	}
+
+	oldEdges := make(map[edgeKey][]*EdgeDescription)
+	var oldEdgeOrder []*EdgeDescription
+	forEachEdge(a, func(e *EdgeDescription) {
+		oldEdges[newEdgeKey(e)] = append(oldEdges[newEdgeKey(e)], e)
+		oldEdgeOrder = append(oldEdgeOrder, e)
+	})
+	matched := make(map[*EdgeDescription]bool)
+	forEachEdge(b, func(e *EdgeDescription) {
+		key := newEdgeKey(e)
+		candidates := oldEdges[key]
+		if len(candidates) == 0 {
+			d.AddedEdges = append(d.AddedEdges, e)
+			d.edges = append(d.edges, diffEdge{e, DiffAddedColor})
+			return
+		}
+		old := candidates[0]
+		oldEdges[key] = candidates[1:]
+		matched[old] = true
+		if reflect.DeepEqual(old.attributes(), e.attributes()) {
+			d.edges = append(d.edges, diffEdge{e, ""})
+			return
+		}
+		d.ChangedEdges = append(d.ChangedEdges, EdgeChange{old, e})
+		d.edges = append(d.edges, diffEdge{e, DiffChangedColor})
+	})
+	for _, e := range oldEdgeOrder {
+		if !matched[e] {
+			d.RemovedEdges = append(d.RemovedEdges, e)
+			d.edges = append(d.edges, diffEdge{e, DiffRemovedColor})
+		}
+	}
+	return d
+}
+
+// Empty reports whether the compared graphs have the same vertices and
+// edges
+func (d *GraphDiff) Empty() bool {
+	return len(d.AddedVertices) == 0 && len(d.RemovedVertices) == 0 && len(d.ChangedVertices) == 0 &&
+		len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0 && len(d.ChangedEdges) == 0
+}
+
+// Graph returns a graph with the given name holding every vertex and edge
+// of both compared graphs, colored by DiffAddedColor, DiffRemovedColor or
+// DiffChangedColor when they differ.  Vertices and edges are copied, with
+// the new attributes of changed ones, and subgraphs are flattened.
+func (d *GraphDiff) Graph(name string) Graph {
+	g := NewGraph(name)
+	g.IsUndirected = !d.directed
+	for _, dv := range d.vertices {
+		v := &VertexDescription{ID: dv.v.ID}
+		mergeVertex(v, dv.v, true)
+		if dv.color != "" {
+			v.Color = dv.color
+			v.FontColor = dv.color
+			// Attrs take precedence over the fields when written
+			delete(v.Attrs, "color")
+			delete(v.Attrs, "fontcolor")
+		}
+		g.Body = append(g.Body, v)
+	}
+	for _, de := range d.edges {
+		e := *de.e
+		e.Attrs = nil
+		for key, value := range de.e.Attrs {
+			e.AddAttribute(key, value)
+		}
+		if de.color != "" {
+			e.AddAttribute("color", de.color)
+			e.AddAttribute("fontcolor", de.color)
+		}
+		g.Body = append(g.Body, &e)
+	}
+	return g
+}
+
+// collectVertices returns the vertices of graph by ID, merging repeated
+// statements, and the IDs in order of appearance
+func collectVertices(graph *Graph) (map[string]*VertexDescription, []string) {
+	vertices := make(map[string]*VertexDescription)
+	var order []string
+	add := func(v *VertexDescription, attrs bool) {
+		existing, ok := vertices[v.ID]
+		if !ok {
+			existing = &VertexDescription{ID: v.ID}
+			vertices[v.ID] = existing
+			order = append(order, v.ID)
+		}
+		if attrs {
+			mergeVertex(existing, v, true)
+		}
+	}
+	var walk func(*Graph)
+	walk = func(g *Graph) {
+		for _, elem := range g.Body {
+			switch e := elem.(type) {
+			case *VertexDescription:
+				add(e, true)
+			case *EdgeDescription:
+				add(&e.From, false)
+				add(&e.To, false)
+			case *EdgeChain:
+				for i := range e.Vertices {
+					add(&e.Vertices[i], false)
+				}
+			case *Graph:
+				walk(e)
+			}
+		}
+	}
+	walk(graph)
+	return vertices, order
+}
+
+// forEachEdge calls fn for every edge of the graph and its subgraphs, with
+// edge chains expanded into their hops
+func forEachEdge(graph *Graph, fn func(*EdgeDescription)) {
+	for _, elem := range graph.Body {
+		switch e := elem.(type) {
+		case *EdgeDescription:
+			fn(e)
+		case *EdgeChain:
+			for _, edge := range e.Edges() {
+				edge := edge
+				fn(&edge)
+			}
+		case *Graph:
+			forEachEdge(e, fn)
+		}
+	}
+}
+
+// edgeKey identifies an edge by its endpoints.  The endpoints of undirected
+// edges are ordered.
+type edgeKey struct {
+	from, fromPort string
+	to, toPort     string
+	directed       bool
+}
+
+func newEdgeKey(e *EdgeDescription) edgeKey {
+	key := edgeKey{
+		from:     e.From.ID,
+		fromPort: portString(e.FromPort, e.FromCompass),
+		to:       e.To.ID,
+		toPort:   portString(e.ToPort, e.ToCompass),
+		directed: e.Directed,
+	}
+	if !e.Directed && (key.to < key.from || (key.to == key.from && key.toPort < key.fromPort)) {
+		key.from, key.to = key.to, key.from
+		key.fromPort, key.toPort = key.toPort, key.fromPort
+	}
+	return key
+}
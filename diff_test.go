@@ -0,0 +1,113 @@
+package dot
+
+import (
+	"testing"
+)
+
+var diffGraph = `digraph diff {
+a [label="A2" color="orange" fontcolor="orange" ]
+b []
+e [color="green" fontcolor="green" ]
+d []
+c [color="red" fontcolor="red" ]
+a -> b [ style="dashed" color="orange" fontcolor="orange" ]
+b -> e [ color="green" fontcolor="green" ]
+a -> d
+b -> c [ color="red" fontcolor="red" ]
+}`
+
+func buildDiffGraphs() (Graph, Graph) {
+	prev := NewGraph("prev")
+	a := &VertexDescription{ID: "a", Label: "A"}
+	b := &VertexDescription{ID: "b"}
+	c := &VertexDescription{ID: "c"}
+	d := &VertexDescription{ID: "d"}
+	e := &VertexDescription{ID: "e"}
+	prev.AddVertex(a)
+	prev.AddEdge(a, b, true, "")
+	prev.AddEdge(b, c, true, "")
+	prev.AddEdge(a, d, true, "")
+
+	cur := NewGraph("cur")
+	a2 := &VertexDescription{ID: "a", Label: "A2"}
+	cur.AddVertex(a2)
+	sub := NewCluster("sub")
+	sub.AddVertex(b)
+	sub.AddEdge(a2, b, true, "dashed")
+	cur.AddSubGraph(&sub)
+	cur.AddEdgeChain(true, "", b, e)
+	cur.AddEdge(a2, d, true, "")
+	return prev, cur
+}
+
+func TestDiff(t *testing.T) {
+	prev, cur := buildDiffGraphs()
+	d := Diff(&prev, &cur)
+
+	if len(d.AddedVertices) != 1 || d.AddedVertices[0].ID != "e" {
+		t.Errorf("unexpected added vertices %v", d.AddedVertices)
+	}
+	if len(d.RemovedVertices) != 1 || d.RemovedVertices[0].ID != "c" {
+		t.Errorf("unexpected removed vertices %v", d.RemovedVertices)
+	}
+	if len(d.ChangedVertices) != 1 || d.ChangedVertices[0].Old.Label != "A" || d.ChangedVertices[0].New.Label != "A2" {
+		t.Errorf("unexpected changed vertices %v", d.ChangedVertices)
+	}
+	if len(d.AddedEdges) != 1 || d.AddedEdges[0].From.ID != "b" || d.AddedEdges[0].To.ID != "e" {
+		t.Errorf("unexpected added edges %v", d.AddedEdges)
+	}
+	if len(d.RemovedEdges) != 1 || d.RemovedEdges[0].To.ID != "c" {
+		t.Errorf("unexpected removed edges %v", d.RemovedEdges)
+	}
+	if len(d.ChangedEdges) != 1 || d.ChangedEdges[0].New.Style != "dashed" {
+		t.Errorf("unexpected changed edges %v", d.ChangedEdges)
+	}
+	if d.Empty() {
+		t.Error("diff should not be empty")
+	}
+	if !Diff(&cur, &cur).Empty() {
+		t.Error("diff of a graph with itself should be empty")
+	}
+}
+
+func TestDiffUndirected(t *testing.T) {
+	prev := NewUndirectedGraph("prev")
+	cur := NewUndirectedGraph("cur")
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	prev.AddEdge(a, b, false, "")
+	cur.AddEdge(b, a, false, "")
+	d := Diff(&prev, &cur)
+	if !d.Empty() {
+		t.Errorf("reversed undirected edge reported as a difference: %+v", d)
+	}
+	if g := d.Graph("diff"); !g.IsUndirected {
+		t.Error("diff of undirected graphs should be undirected")
+	}
+}
+
+func TestDiffGraph(t *testing.T) {
+	prev, cur := buildDiffGraphs()
+	g := Diff(&prev, &cur).Graph("diff")
+	if s := writeString(t, &g); s != diffGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", diffGraph)
+	}
+}
+
+func TestDiffGraphColoredVertex(t *testing.T) {
+	prev := NewGraph("prev")
+	prev.AddVertex(&VertexDescription{ID: "a", Label: "A", Attrs: map[string]string{"color": "blue", "fontcolor": "blue"}})
+	cur := NewGraph("cur")
+	cur.AddVertex(&VertexDescription{ID: "a", Label: "A2", Attrs: map[string]string{"color": "blue", "fontcolor": "blue"}})
+	g := Diff(&prev, &cur).Graph("diff")
+	expected := `digraph diff {
+a [label="A2" color="orange" fontcolor="orange" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+	if v := cur.FindVertex("a"); v.Attrs["color"] != "blue" {
+		t.Errorf("compared graph was modified: %+v", v)
+	}
+}
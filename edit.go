@@ -0,0 +1,188 @@
+package dot
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// FindVertex returns the first vertex with the given ID scheduled on the graph
+// or any of its subgraphs, or nil if there is none.
+func (graph *Graph) FindVertex(id string) *VertexDescription {
+	idx := graph.index()
+	found, ok := idx.vertices[id]
+	for _, sub := range idx.subgraphs {
+		if ok && sub.pos > found.pos {
+			break
+		}
+		if v := sub.g.FindVertex(id); v != nil {
+			return v
+		}
+	}
+	return found.v
+}
+
+// RemoveVertex removes every vertex with the given ID from the graph and its
+// subgraphs, together with all edges attached to it.  Edge chains passing
+// through the vertex are split around it.  It reports whether anything was
+// removed.
+func (graph *Graph) RemoveVertex(id string) bool {
+	return graph.rewriteBody(func(elem Element) []Element {
+		switch e := elem.(type) {
+		case *VertexDescription:
+			if e.ID == id {
+				return nil
+			}
+		case *EdgeDescription:
+			if e.From.ID == id || e.To.ID == id {
+				return nil
+			}
+		case *EdgeChain:
+			return splitChain(e, func(from, to *VertexDescription) bool {
+				return from.ID != id && to.ID != id
+			})
+		case *RankGroup:
+			var ids []string
+			for _, rankID := range e.IDs {
+				if rankID != id {
+					ids = append(ids, rankID)
+				}
+			}
+			if len(ids) != len(e.IDs) {
+				return []Element{&RankGroup{Rank: e.Rank, IDs: ids}}
+			}
+		}
+		return []Element{elem}
+	})
+}
+
+// RemoveEdge removes every edge from the vertex with ID from to the vertex
+// with ID to from the graph and its subgraphs.  Undirected edges are removed
+// regardless of the order of their endpoints.  It reports whether anything
+// was removed.
+func (graph *Graph) RemoveEdge(from, to string) bool {
+	matches := func(f, t string, directed bool) bool {
+		return (f == from && t == to) || (!directed && f == to && t == from)
+	}
+	return graph.rewriteBody(func(elem Element) []Element {
+		switch e := elem.(type) {
+		case *EdgeDescription:
+			if matches(e.From.ID, e.To.ID, e.Directed) {
+				return nil
+			}
+		case *EdgeChain:
+			return splitChain(e, func(f, t *VertexDescription) bool {
+				return !matches(f.ID, t.ID, e.Directed)
+			})
+		}
+		return []Element{elem}
+	})
+}
+
+// rewriteBody replaces every element of the graph and its subgraphs with the
+// elements returned by fn.  fn is not called for subgraphs, which are
+// rewritten recursively instead.  It reports whether any element was changed.
+func (graph *Graph) rewriteBody(fn func(Element) []Element) bool {
+	changed := false
+	body := make([]Element, 0, len(graph.Body))
+	for _, elem := range graph.Body {
+		if sub, ok := elem.(*Graph); ok {
+			if sub.rewriteBody(fn) {
+				changed = true
+			}
+			body = append(body, sub)
+			continue
+		}
+		replacement := fn(elem)
+		if len(replacement) != 1 || !sameElement(replacement[0], elem) {
+			changed = true
+		}
+		body = append(body, replacement...)
+	}
+	graph.Body = body
+	graph.resetIndex()
+	return changed
+}
+
+// splitChain returns the edge chains left after removing the hops of c for
+// which keep returns false.  c itself is returned if all hops are kept.
+func splitChain(c *EdgeChain, keep func(from, to *VertexDescription) bool) []Element {
+	var chains []Element
+	var cur *EdgeChain
+	removed := false
+	for i := 1; i < len(c.Vertices); i++ {
+		if !keep(&c.Vertices[i-1], &c.Vertices[i]) {
+			removed = true
+			cur = nil
+			continue
+		}
+		if cur == nil {
+			cur = &EdgeChain{
+				Vertices: []VertexDescription{c.Vertices[i-1]},
+				Directed: c.Directed,
+				Style:    c.Style,
+			}
+			chains = append(chains, cur)
+		}
+		cur.Vertices = append(cur.Vertices, c.Vertices[i])
+	}
+	if !removed {
+		return []Element{c}
+	}
+	return chains
+}
+
+// InsertAt schedules elem to be written before the element at index i of the
+// body of the graph.  i may be the length of the body, appending elem.
+func (graph *Graph) InsertAt(i int, elem Element) error {
+	if i < 0 || i > len(graph.Body) {
+		return fmt.Errorf("index %d out of range [0, %d]", i, len(graph.Body))
+	}
+	graph.Body = append(graph.Body, nil)
+	copy(graph.Body[i+1:], graph.Body[i:])
+	graph.Body[i] = elem
+	graph.resetIndex()
+	return nil
+}
+
+// RemoveAt removes the element at index i of the body of the graph and
+// returns it
+func (graph *Graph) RemoveAt(i int) (Element, error) {
+	if i < 0 || i >= len(graph.Body) {
+		return nil, fmt.Errorf("index %d out of range [0, %d)", i, len(graph.Body))
+	}
+	elem := graph.Body[i]
+	copy(graph.Body[i:], graph.Body[i+1:])
+	graph.Body[len(graph.Body)-1] = nil
+	graph.Body = graph.Body[:len(graph.Body)-1]
+	graph.resetIndex()
+	return elem, nil
+}
+
+// ReplaceElement replaces old, compared by identity, with new in the body of
+// the graph or of its subgraphs.  It reports whether old was found.  Only
+// elements of pointer types have an identity, so other elements are never
+// found.
+func (graph *Graph) ReplaceElement(old, new Element) bool {
+	for i, elem := range graph.Body {
+		if sameElement(elem, old) {
+			graph.Body[i] = new
+			graph.resetIndex()
+			return true
+		}
+		if sub, ok := elem.(*Graph); ok && sub.ReplaceElement(old, new) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameElement reports whether a and b are the same element of pointer type.
+// Comparing elements of other types could panic, as for structs holding a
+// slice or a map.
+func sameElement(a, b Element) bool {
+	t := reflect.TypeOf(a)
+	if t == nil || t.Kind() != reflect.Ptr || t != reflect.TypeOf(b) {
+		return false
+	}
+	return a == b
+}
@@ -0,0 +1,196 @@
+package dot
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func buildEditGraph() Graph {
+	g := NewGraph("testGraph")
+	a := &VertexDescription{ID: "a", Label: "A"}
+	b := &VertexDescription{ID: "b"}
+	c := &VertexDescription{ID: "c"}
+	d := &VertexDescription{ID: "d"}
+	g.AddVertex(a)
+	sub := NewCluster("sub")
+	sub.AddVertex(b)
+	sub.AddEdge(b, c, true, "")
+	g.AddSubGraph(&sub)
+	g.AddEdge(a, b, true, "")
+	g.AddEdge(c, a, false, "")
+	g.AddEdgeChain(true, "", a, b, c, d)
+	g.AddSameRank(a, d)
+	return g
+}
+
+func writeString(t *testing.T, g *Graph) string {
+	buf := new(bytes.Buffer)
+	if err := g.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestFindVertex(t *testing.T) {
+	g := buildEditGraph()
+	if v := g.FindVertex("a"); v == nil || v.Label != "A" {
+		t.Errorf("unexpected vertex %+v", v)
+	}
+	if v := g.FindVertex("b"); v == nil || v.ID != "b" {
+		t.Errorf("vertex in subgraph not found: %+v", v)
+	}
+	if v := g.FindVertex("x"); v != nil {
+		t.Errorf("unexpected vertex %+v", v)
+	}
+}
+
+func TestRemoveVertex(t *testing.T) {
+	g := buildEditGraph()
+	if !g.RemoveVertex("b") {
+		t.Fatal("expected vertex to be removed")
+	}
+	expected := `digraph testGraph {
+a [label="A" ]
+subgraph cluster_sub {
+}
+c -- a
+c -> d
+{ rank=same; a; d; }
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", expected)
+	}
+	if g.RemoveVertex("b") {
+		t.Error("vertex removed twice")
+	}
+}
+
+func TestRemoveEdge(t *testing.T) {
+	g := buildEditGraph()
+	if !g.RemoveEdge("b", "c") {
+		t.Fatal("expected edge to be removed")
+	}
+	// undirected edges match in either direction
+	if !g.RemoveEdge("a", "c") {
+		t.Fatal("expected undirected edge to be removed")
+	}
+	expected := `digraph testGraph {
+a [label="A" ]
+subgraph cluster_sub {
+b []
+}
+a -> b
+a -> b
+c -> d
+{ rank=same; a; d; }
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", expected)
+	}
+	if g.RemoveEdge("b", "a") {
+		t.Error("directed edge removed in reverse direction")
+	}
+}
+
+func TestInsertRemoveAt(t *testing.T) {
+	g := NewGraph("testGraph")
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	g.AddVertex(a)
+	cluster := g.AddCluster("peers")
+	cluster.AddVertex(b)
+
+	legend := &Literal{Line: "// legend"}
+	if err := g.InsertAt(1, legend); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.InsertAt(3, &Literal{Line: "// end"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.InsertAt(5, legend); err == nil {
+		t.Error("expected an error inserting out of range")
+	}
+	expected := `digraph testGraph {
+a []
+// legend
+subgraph cluster_0 {
+label="peers"
+b []
+}
+// end
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	elem, err := g.RemoveAt(0)
+	if err != nil || elem != Element(a) {
+		t.Errorf("unexpected removed element %v: %v", elem, err)
+	}
+	if _, err := g.RemoveAt(3); err == nil {
+		t.Error("expected an error removing out of range")
+	}
+	if len(g.Body) != 3 || g.Body[0] != Element(legend) {
+		t.Errorf("unexpected body %v", g.Body)
+	}
+}
+
+func TestReplaceElement(t *testing.T) {
+	g := buildEditGraph()
+	sub := g.Body[1].(*Graph)
+	old := sub.Body[0]
+	if !g.ReplaceElement(old, &VertexDescription{ID: "b", Label: "B"}) {
+		t.Error("expected the vertex to be replaced")
+	}
+	if g.ReplaceElement(old, &VertexDescription{ID: "b"}) {
+		t.Error("expected the replaced vertex not to be found")
+	}
+	if v := sub.FindVertex("b"); v == nil || v.Label != "B" {
+		t.Errorf("unexpected vertex %+v", v)
+	}
+	if !g.ReplaceElement(sub, &Literal{Line: "// no cluster"}) {
+		t.Error("expected the subgraph to be replaced")
+	}
+	expected := `digraph testGraph {
+a [label="A" ]
+// no cluster
+a -> b
+c -- a
+a -> b -> c -> d
+{ rank=same; a; d; }
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+// rowsElement is an element of a non-comparable type
+type rowsElement struct {
+	rows []string
+}
+
+func (r rowsElement) Write(w io.Writer) error {
+	_, err := io.WriteString(w, strings.Join(r.rows, "\n"))
+	return err
+}
+
+func TestReplaceElementNotComparable(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.Body = append(g.Body, rowsElement{rows: []string{"a;"}})
+	v := &VertexDescription{ID: "b"}
+	g.AddVertex(v)
+	if g.ReplaceElement(rowsElement{rows: []string{"a;"}}, v) {
+		t.Error("unexpected replacement of an element without identity")
+	}
+	if !g.ReplaceElement(v, &VertexDescription{ID: "c"}) {
+		t.Error("expected the vertex to be replaced")
+	}
+	g.Reverse()
+	if s := writeString(t, &g); s != "digraph testGraph {\na;\nc []\n}" {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
@@ -0,0 +1,129 @@
+package dot
+
+import (
+	"io"
+	"strings"
+)
+
+// DeltaFormat selects how the changes between two graphs are written by
+// WriteDelta and Emitter
+type DeltaFormat int
+
+const (
+	// DeltaScript writes a line per vertex or edge which differs: its
+	// statement prefixed with "+ " when it was added, "~ " when its
+	// attributes changed, both with the new attributes, and "- " when it
+	// was removed, without attributes.  Edges are removed before vertices
+	// and vertices added before edges.
+	DeltaScript DeltaFormat = iota
+	// DeltaGraph writes a dot-file holding the added and changed vertices
+	// and edges, with their new attributes.  Removed vertices and edges
+	// are listed in comments.
+	DeltaGraph
+)
+
+// WriteDelta writes the vertices and edges which differ between prev and
+// cur, as found by Diff, in the given format.  Added and changed elements
+// are written in their order in cur.
+func WriteDelta(w io.Writer, prev, cur *Graph, format DeltaFormat) error {
+	d := Diff(prev, cur)
+	prefixes := map[string]string{
+		DiffAddedColor:   "+ ",
+		DiffChangedColor: "~ ",
+	}
+	var removed, changed []Element
+	var changedPrefixes []string
+	for _, de := range d.edges {
+		if de.color == DiffRemovedColor {
+			removed = append(removed, endpointsOnly(de.e))
+		}
+	}
+	for _, dv := range d.vertices {
+		switch dv.color {
+		case DiffRemovedColor:
+			removed = append(removed, &VertexDescription{ID: dv.v.ID})
+		case DiffAddedColor, DiffChangedColor:
+			changed = append(changed, dv.v)
+			changedPrefixes = append(changedPrefixes, prefixes[dv.color])
+		}
+	}
+	for _, de := range d.edges {
+		if de.color == DiffAddedColor || de.color == DiffChangedColor {
+			changed = append(changed, de.e)
+			changedPrefixes = append(changedPrefixes, prefixes[de.color])
+		}
+	}
+
+	if format == DeltaGraph {
+		g := NewGraph(cur.Name)
+		g.IsUndirected = cur.IsUndirected
+		g.IsStrict = cur.IsStrict
+		for _, elem := range removed {
+			g.Body = append(g.Body, &Literal{Line: "// removed " + statementString(elem)})
+		}
+		g.Body = append(g.Body, changed...)
+		if err := g.Write(w); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+
+	for _, elem := range removed {
+		if _, err := io.WriteString(w, "- "+statementString(elem)+"\n"); err != nil {
+			return err
+		}
+	}
+	for i, elem := range changed {
+		if _, err := io.WriteString(w, changedPrefixes[i]+statementString(elem)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// endpointsOnly returns an edge with the endpoints of e and no attributes
+func endpointsOnly(e *EdgeDescription) *EdgeDescription {
+	return &EdgeDescription{
+		From:        VertexDescription{ID: e.From.ID},
+		To:          VertexDescription{ID: e.To.ID},
+		Directed:    e.Directed,
+		FromPort:    e.FromPort,
+		FromCompass: e.FromCompass,
+		ToPort:      e.ToPort,
+		ToCompass:   e.ToCompass,
+	}
+}
+
+// statementString returns the statement of a vertex or edge, without
+// attributes for a vertex with none
+func statementString(elem Element) string {
+	if v, ok := elem.(*VertexDescription); ok && len(v.attributes()) == 0 {
+		return QuoteID(v.ID)
+	}
+	var b strings.Builder
+	elem.Write(&b)
+	return b.String()
+}
+
+// Emitter writes the changes of a graph which is updated over time, such
+// as a dot-file watched by a dashboard
+type Emitter struct {
+	Format DeltaFormat
+	prev   *Graph
+}
+
+// Emit writes the changes of graph since the graph passed to the previous
+// call, or all of its vertices and edges on the first call.  A copy of the
+// graph is kept for the next call.
+func (em *Emitter) Emit(w io.Writer, graph *Graph) error {
+	prev := em.prev
+	if prev == nil {
+		prev = &Graph{Name: graph.Name, IsUndirected: graph.IsUndirected}
+	}
+	if err := WriteDelta(w, prev, graph, em.Format); err != nil {
+		return err
+	}
+	em.prev = graph.Clone()
+	return nil
+}
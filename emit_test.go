@@ -0,0 +1,80 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEmitter(t *testing.T) {
+	g := NewGraph("live")
+	a := &VertexDescription{ID: "a", Label: "A"}
+	b := &VertexDescription{ID: "b"}
+	g.AddVertex(a)
+	g.AddVertex(b)
+	g.AddEdge(a, b, true, "")
+
+	em := &Emitter{}
+	buf := new(bytes.Buffer)
+	if err := em.Emit(buf, &g); err != nil {
+		t.Fatal(err)
+	}
+	expected := `+ a [label="A" ]
++ b
++ a -> b
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected output: \n%s\n", buf)
+	}
+
+	// no changes
+	buf.Reset()
+	if err := em.Emit(buf, &g); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output: \n%s\n", buf)
+	}
+
+	a.Label = "A2"
+	g.RemoveVertex("b")
+	c := &VertexDescription{ID: "c"}
+	g.AddVertex(c)
+	g.AddEdge(a, c, true, "dashed")
+	buf.Reset()
+	if err := em.Emit(buf, &g); err != nil {
+		t.Fatal(err)
+	}
+	expected = `- a -> b
+- b
+~ a [label="A2" ]
++ c
++ a -> c [ style="dashed" ]
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected output: \n%s\n", buf)
+	}
+}
+
+func TestWriteDeltaGraph(t *testing.T) {
+	prev := NewUndirectedGraph("live")
+	prev.AddEdge(&VertexDescription{ID: "a"}, &VertexDescription{ID: "b"}, false, "")
+	cur := NewUndirectedGraph("live")
+	cur.AddVertex(&VertexDescription{ID: "a", Color: "red"})
+	cur.AddEdge(&VertexDescription{ID: "a"}, &VertexDescription{ID: "c"}, false, "")
+
+	buf := new(bytes.Buffer)
+	if err := WriteDelta(buf, &prev, &cur, DeltaGraph); err != nil {
+		t.Fatal(err)
+	}
+	expected := `graph live {
+// removed a -- b
+// removed b
+a [color="red" ]
+c []
+a -- c
+}
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected output: \n%s\n", buf)
+	}
+}
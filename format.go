@@ -0,0 +1,23 @@
+package dot
+
+import (
+	"bytes"
+)
+
+// Format parses a dot-file and writes it back in the format of this
+// package: one statement per line, indented by a tab per nesting level,
+// IDs quoted only when needed and attributes in the order of the typed
+// fields followed by the others sorted by name.  Comments within the graph
+// are kept.
+func Format(src []byte) ([]byte, error) {
+	graph, err := Parse(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	if err := graph.WriteWithOptions(buf, WriteOptions{Indent: "\t"}); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,47 @@
+package dot
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	src := `// peers
+digraph   "cluster"{ rankdir = LR ;
+  subgraph cluster_peers { label="peers"
+"a" [shape=box, label="A"]; b   // bootstrap
+}
+a->b [color=red weight=2]
+  "c d" -> a
+}`
+	expected := `digraph cluster {
+	rankdir="LR"
+	subgraph cluster_peers {
+		label="peers"
+		a [label="A" shape="box" ]
+		b [] // bootstrap
+	}
+	a -> b [ weight="2" color="red" ]
+	"c d" -> a
+}
+`
+	out, err := Format([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != expected {
+		t.Errorf("unexpected output: \n%s\n", out)
+	}
+
+	// formatting is idempotent
+	again, err := Format(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != expected {
+		t.Errorf("unexpected output: \n%s\n", again)
+	}
+
+	if _, err := Format([]byte("digraph {")); err == nil {
+		t.Error("expected an error for an unterminated graph")
+	}
+}
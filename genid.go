@@ -0,0 +1,58 @@
+package dot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// IDScheme selects how NewVertex generates the IDs of the vertices
+type IDScheme int
+
+const (
+	// IDSequential numbers the vertices in the order they are added: n0,
+	// n1, …  Numbers already taken by other vertices are skipped.
+	IDSequential IDScheme = iota
+	// IDContentHash derives the ID from a hash of the label, so that the
+	// same label gets the same ID across runs.  Adding a vertex whose ID is
+	// already taken is an error.
+	IDContentHash
+)
+
+// idHashLength is the number of hex digits of the hash used in the IDs
+// generated by IDContentHash
+const idHashLength = 12
+
+// NewVertex adds a vertex with the given label and an ID generated
+// according to the IDScheme of the root graph, and returns it.  IDs are
+// unique among the vertices of the root graph and the clusters added with
+// AddCluster.
+func (graph *Graph) NewVertex(label string) (*VertexDescription, error) {
+	root := graph
+	for root.parent != nil {
+		root = root.parent
+	}
+	var id string
+	switch root.IDScheme {
+	case IDSequential:
+		for {
+			id = "n" + strconv.Itoa(root.nextID)
+			root.nextID++
+			if !root.HasVertex(id) {
+				break
+			}
+		}
+	case IDContentHash:
+		sum := sha256.Sum256([]byte(label))
+		id = "n" + hex.EncodeToString(sum[:])[:idHashLength]
+		if root.HasVertex(id) {
+			return nil, fmt.Errorf("vertex %s: ID generated for label %q is already taken", id, label)
+		}
+	default:
+		return nil, fmt.Errorf("unknown ID scheme %d", root.IDScheme)
+	}
+	v := NewVertexDescription(id, WithLabel(label))
+	graph.AddVertex(&v)
+	return &v, nil
+}
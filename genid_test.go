@@ -0,0 +1,65 @@
+package dot
+
+import (
+	"testing"
+)
+
+func TestNewVertexSequential(t *testing.T) {
+	g := NewGraph("g")
+	taken := NewVertexDescription("n1")
+	g.AddVertex(&taken)
+	cluster := g.AddCluster("peers")
+	var ids []string
+	for _, label := range []string{"a", "b", "a"} {
+		v, err := cluster.NewVertex(label)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Label != label {
+			t.Errorf("unexpected label %s", v.Label)
+		}
+		ids = append(ids, v.ID)
+	}
+	v, err := g.NewVertex("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids = append(ids, v.ID)
+	expected := []string{"n0", "n2", "n3", "n4"}
+	for i := range expected {
+		if ids[i] != expected[i] {
+			t.Errorf("unexpected IDs %v, expected %v", ids, expected)
+			break
+		}
+	}
+	if !cluster.HasVertex("n3") || cluster.HasVertex("n4") {
+		t.Error("vertices not added to the graph they were created in")
+	}
+}
+
+func TestNewVertexContentHash(t *testing.T) {
+	g := NewGraph("g")
+	g.IDScheme = IDContentHash
+	a, err := g.NewVertex("QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ID != "n293fa9f31972" {
+		t.Errorf("unexpected ID %s", a.ID)
+	}
+	other := NewGraph("other")
+	other.IDScheme = IDContentHash
+	b, err := other.NewVertex(a.Label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ID != a.ID {
+		t.Errorf("IDs %s and %s differ for the same label", a.ID, b.ID)
+	}
+	if _, err := g.NewVertex(a.Label); err == nil {
+		t.Error("expected an error for a colliding ID")
+	}
+	if _, err := g.NewVertex("other"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,259 @@
+package dot
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// GEXFOptions configures the GEXF output of a graph
+type GEXFOptions struct {
+	// TimeFormat is the GEXF time format of the spells, "integer",
+	// "double", "date" or "dateTime".  Defaults to "double".
+	TimeFormat string
+	// VertexSpell returns the start and end times of a vertex.  Empty
+	// values leave the interval open.
+	VertexSpell func(v *VertexDescription) (start, end string)
+	// EdgeSpell returns the start and end times of an edge
+	EdgeSpell func(e *EdgeDescription) (start, end string)
+}
+
+// WriteGEXF writes the graph in GEXF 1.3 format, readable by Gephi.  Vertex
+// and edge attributes other than the label are written as string
+// attributes.  Subgraphs are flattened into the graph, vertices which only
+// appear as edge endpoints are declared and Literal elements are skipped.
+// When a spell function is set in opts the graph is written in dynamic mode,
+// with the start and end times of each vertex or edge.
+func (graph *Graph) WriteGEXF(w io.Writer, opts GEXFOptions) error {
+	enc := &gexfEncoder{
+		opts:       opts,
+		nodes:      make(map[string]*gexfNode),
+		nodeAttrs:  make(map[string]bool),
+		edgeAttrs:  make(map[string]bool),
+		defaultDir: !graph.IsUndirected,
+	}
+	enc.body(graph)
+	for _, id := range enc.undeclared {
+		if enc.nodes[id] == nil {
+			enc.nodes[id] = &gexfNode{ID: id, Label: id}
+			enc.graph.Nodes = append(enc.graph.Nodes, enc.nodes[id])
+		}
+	}
+
+	enc.graph.DefaultEdgeType = "undirected"
+	if enc.defaultDir {
+		enc.graph.DefaultEdgeType = "directed"
+	}
+	if opts.VertexSpell != nil || opts.EdgeSpell != nil {
+		enc.graph.Mode = "dynamic"
+		enc.graph.TimeFormat = opts.TimeFormat
+		if enc.graph.TimeFormat == "" {
+			enc.graph.TimeFormat = "double"
+		}
+	} else {
+		enc.graph.Mode = "static"
+	}
+	if len(enc.nodeAttrs) > 0 {
+		enc.graph.Attributes = append(enc.graph.Attributes, gexfDeclare("node", enc.nodeAttrs))
+	}
+	if len(enc.edgeAttrs) > 0 {
+		enc.graph.Attributes = append(enc.graph.Attributes, gexfDeclare("edge", enc.edgeAttrs))
+	}
+
+	doc := gexfDoc{
+		Xmlns:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Graph:   &enc.graph,
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	xenc := xml.NewEncoder(w)
+	xenc.Indent("", "  ")
+	if err := xenc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type gexfDoc struct {
+	XMLName xml.Name   `xml:"gexf"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Version string     `xml:"version,attr"`
+	Graph   *gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string            `xml:"defaultedgetype,attr"`
+	Mode            string            `xml:"mode,attr"`
+	TimeFormat      string            `xml:"timeformat,attr,omitempty"`
+	Attributes      []*gexfAttributes `xml:"attributes"`
+	Nodes           []*gexfNode       `xml:"nodes>node"`
+	Edges           []*gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	Start     string         `xml:"start,attr,omitempty"`
+	End       string         `xml:"end,attr,omitempty"`
+	AttValues *gexfAttValues `xml:"attvalues"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	Type      string         `xml:"type,attr,omitempty"`
+	Label     string         `xml:"label,attr,omitempty"`
+	Start     string         `xml:"start,attr,omitempty"`
+	End       string         `xml:"end,attr,omitempty"`
+	AttValues *gexfAttValues `xml:"attvalues"`
+}
+
+type gexfAttValues struct {
+	Values []gexfAttValue `xml:"attvalue"`
+}
+
+func (values *gexfAttValues) list() []gexfAttValue {
+	if values == nil {
+		return nil
+	}
+	return values.Values
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// gexfDeclare declares the attributes of a class, sorted by name
+func gexfDeclare(class string, names map[string]bool) *gexfAttributes {
+	attrs := &gexfAttributes{Class: class}
+	for name := range names {
+		attrs.Attributes = append(attrs.Attributes, gexfAttribute{
+			ID:    name,
+			Title: name,
+			Type:  "string",
+		})
+	}
+	sort.Slice(attrs.Attributes, func(i, j int) bool {
+		return attrs.Attributes[i].ID < attrs.Attributes[j].ID
+	})
+	return attrs
+}
+
+// gexfEncoder converts a Graph into the GEXF document structure
+type gexfEncoder struct {
+	opts       GEXFOptions
+	graph      gexfGraph
+	defaultDir bool
+	nodes      map[string]*gexfNode
+	undeclared []string
+	nodeAttrs  map[string]bool
+	edgeAttrs  map[string]bool
+}
+
+// attValues splits the label from attrs and returns the remaining ones as
+// attribute values, registering their names in declared.  The values are
+// nil if there are none.
+func (enc *gexfEncoder) attValues(attrs []attribute, declared map[string]bool) (string, *gexfAttValues) {
+	var label string
+	var values *gexfAttValues
+	for _, attr := range attrs {
+		if attr.name == "label" {
+			label = attr.value
+			continue
+		}
+		declared[attr.name] = true
+		if values == nil {
+			values = &gexfAttValues{}
+		}
+		values.Values = append(values.Values, gexfAttValue{For: attr.name, Value: attr.value})
+	}
+	return label, values
+}
+
+func (enc *gexfEncoder) body(graph *Graph) {
+	for _, elem := range graph.Body {
+		switch e := elem.(type) {
+		case *VertexDescription:
+			enc.vertex(e)
+		case *EdgeDescription:
+			enc.edge(e)
+		case *EdgeChain:
+			for _, edge := range e.Edges() {
+				enc.edge(&edge)
+			}
+		case *Graph:
+			enc.body(e)
+		}
+	}
+}
+
+func (enc *gexfEncoder) vertex(v *VertexDescription) {
+	label, values := enc.attValues(v.attributes(), enc.nodeAttrs)
+	node, ok := enc.nodes[v.ID]
+	if !ok {
+		node = &gexfNode{ID: v.ID, Label: v.ID}
+		enc.nodes[v.ID] = node
+		enc.graph.Nodes = append(enc.graph.Nodes, node)
+	}
+	if label != "" {
+		node.Label = label
+	}
+	// merge repeated vertex statements into the first one
+	if values != nil && node.AttValues == nil {
+		node.AttValues = &gexfAttValues{}
+	}
+	for _, value := range values.list() {
+		replaced := false
+		for i, old := range node.AttValues.Values {
+			if old.For == value.For {
+				node.AttValues.Values[i] = value
+				replaced = true
+			}
+		}
+		if !replaced {
+			node.AttValues.Values = append(node.AttValues.Values, value)
+		}
+	}
+	if enc.opts.VertexSpell != nil {
+		node.Start, node.End = enc.opts.VertexSpell(v)
+	}
+}
+
+func (enc *gexfEncoder) edge(e *EdgeDescription) {
+	label, values := enc.attValues(e.attributes(), enc.edgeAttrs)
+	edge := &gexfEdge{
+		ID:        "e" + strconv.Itoa(len(enc.graph.Edges)),
+		Source:    e.From.ID,
+		Target:    e.To.ID,
+		Label:     label,
+		AttValues: values,
+	}
+	if e.Directed != enc.defaultDir {
+		edge.Type = "undirected"
+		if e.Directed {
+			edge.Type = "directed"
+		}
+	}
+	if enc.opts.EdgeSpell != nil {
+		edge.Start, edge.End = enc.opts.EdgeSpell(e)
+	}
+	enc.graph.Edges = append(enc.graph.Edges, edge)
+	enc.undeclared = append(enc.undeclared, e.From.ID, e.To.ID)
+}
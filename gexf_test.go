@@ -0,0 +1,104 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+var gexfOutput = `<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://gexf.net/1.3" version="1.3">
+  <graph defaultedgetype="directed" mode="static">
+    <attributes class="node">
+      <attribute id="color" title="color" type="string"></attribute>
+    </attributes>
+    <attributes class="edge">
+      <attribute id="style" title="style" type="string"></attribute>
+    </attributes>
+    <nodes>
+      <node id="a" label="A &amp; B">
+        <attvalues>
+          <attvalue for="color" value="red"></attvalue>
+        </attvalues>
+      </node>
+      <node id="b" label="b"></node>
+      <node id="c" label="c"></node>
+    </nodes>
+    <edges>
+      <edge id="e0" source="a" target="b">
+        <attvalues>
+          <attvalue for="style" value="dashed"></attvalue>
+        </attvalues>
+      </edge>
+      <edge id="e1" source="b" target="c" type="undirected"></edge>
+    </edges>
+  </graph>
+</gexf>
+`
+
+var gexfDynamicOutput = `<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://gexf.net/1.3" version="1.3">
+  <graph defaultedgetype="directed" mode="dynamic" timeformat="integer">
+    <nodes>
+      <node id="a" label="a" start="1"></node>
+      <node id="b" label="b" start="2" end="5"></node>
+    </nodes>
+    <edges>
+      <edge id="e0" source="a" target="b" start="2"></edge>
+    </edges>
+  </graph>
+</gexf>
+`
+
+func TestWriteGEXF(t *testing.T) {
+	g := NewGraph("testGraph")
+	a := &VertexDescription{ID: "a", Label: "A & B"}
+	b := &VertexDescription{ID: "b"}
+	c := &VertexDescription{ID: "c"}
+	g.AddVertex(a)
+	g.AddEdge(a, b, true, "dashed")
+	sub := NewCluster("sub")
+	sub.AddVertex(b)
+	sub.AddEdge(b, c, false, "")
+	g.AddSubGraph(&sub)
+	g.AddVertex(&VertexDescription{ID: "a", Color: "red"})
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteGEXF(buf, GEXFOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != gexfOutput {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", gexfOutput)
+	}
+}
+
+func TestWriteGEXFDynamic(t *testing.T) {
+	g := NewGraph("testGraph")
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	g.AddVertex(a)
+	g.AddVertex(b)
+	g.AddEdge(a, b, true, "")
+
+	spells := map[string][2]string{
+		"a": {"1", ""},
+		"b": {"2", "5"},
+	}
+	opts := GEXFOptions{
+		TimeFormat: "integer",
+		VertexSpell: func(v *VertexDescription) (string, string) {
+			return spells[v.ID][0], spells[v.ID][1]
+		},
+		EdgeSpell: func(e *EdgeDescription) (string, string) {
+			return spells[e.To.ID][0], ""
+		},
+	}
+	buf := new(bytes.Buffer)
+	if err := g.WriteGEXF(buf, opts); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != gexfDynamicOutput {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", gexfDynamicOutput)
+	}
+}
@@ -0,0 +1,7 @@
+// Package gonum builds dot graphs from gonum graphs.  It depends on
+// gonum.org/v1/gonum, which go-dot does not require, so it is only built
+// with the gonum build tag:
+//
+//	go get gonum.org/v1/gonum/graph
+//	go build -tags gonum github.com/zenground0/go-dot/gonum
+package gonum
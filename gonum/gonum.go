@@ -0,0 +1,71 @@
+//go:build gonum
+// +build gonum
+
+package gonum
+
+import (
+	"sort"
+	"strconv"
+
+	dot "github.com/zenground0/go-dot"
+	"gonum.org/v1/gonum/graph"
+)
+
+// VertexFunc derives the description of a vertex from a gonum node.  The
+// returned ID is used for the endpoints of the node's edges.
+type VertexFunc func(n graph.Node) dot.VertexDescription
+
+// DefaultVertex describes a node by its numeric ID only
+func DefaultVertex(n graph.Node) dot.VertexDescription {
+	return dot.NewVertexDescription(strconv.FormatInt(n.ID(), 10))
+}
+
+// FromDirected returns a digraph with the given name holding a vertex for
+// every node of g and a directed edge for every edge of g.  Vertices are
+// described by fn, or DefaultVertex if fn is nil.  Nodes are added in order
+// of their IDs so that the output is stable.
+func FromDirected(name string, g graph.Directed, fn VertexFunc) dot.Graph {
+	dg := dot.NewGraph(name)
+	addGraph(&dg, g, fn, true)
+	return dg
+}
+
+// FromUndirected returns an undirected graph with the given name holding a
+// vertex for every node of g and an undirected edge for every edge of g.
+// Vertices are described by fn, or DefaultVertex if fn is nil.  Nodes are
+// added in order of their IDs so that the output is stable.
+func FromUndirected(name string, g graph.Undirected, fn VertexFunc) dot.Graph {
+	dg := dot.NewUndirectedGraph(name)
+	addGraph(&dg, g, fn, false)
+	return dg
+}
+
+func addGraph(dg *dot.Graph, g graph.Graph, fn VertexFunc, directed bool) {
+	if fn == nil {
+		fn = DefaultVertex
+	}
+	nodes := sortedNodes(g.Nodes())
+	vertices := make(map[int64]*dot.VertexDescription, len(nodes))
+	for _, n := range nodes {
+		v := fn(n)
+		vertices[n.ID()] = &v
+		dg.AddVertex(&v)
+	}
+	for _, u := range nodes {
+		for _, v := range sortedNodes(g.From(u.ID())) {
+			// undirected edges are reported from both ends
+			if !directed && v.ID() < u.ID() {
+				continue
+			}
+			dg.AddEdge(vertices[u.ID()], vertices[v.ID()], directed, "")
+		}
+	}
+}
+
+func sortedNodes(it graph.Nodes) []graph.Node {
+	nodes := graph.NodesOf(it)
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID() < nodes[j].ID()
+	})
+	return nodes
+}
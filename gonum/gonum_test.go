@@ -0,0 +1,60 @@
+//go:build gonum
+// +build gonum
+
+package gonum
+
+import (
+	"fmt"
+	"testing"
+
+	dot "github.com/zenground0/go-dot"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func TestFromDirected(t *testing.T) {
+	g := simple.NewDirectedGraph()
+	g.SetEdge(simple.Edge{F: simple.Node(2), T: simple.Node(1)})
+	g.SetEdge(simple.Edge{F: simple.Node(1), T: simple.Node(3)})
+	g.SetEdge(simple.Edge{F: simple.Node(1), T: simple.Node(2)})
+
+	dg := FromDirected("g", g, func(n graph.Node) dot.VertexDescription {
+		return dot.VertexDescription{
+			ID:    fmt.Sprintf("n%d", n.ID()),
+			Label: fmt.Sprintf("node %d", n.ID()),
+		}
+	})
+	expected := `digraph g {
+n1 [label="node 1" ]
+n2 [label="node 2" ]
+n3 [label="node 3" ]
+n1 -> n2
+n1 -> n3
+n2 -> n1
+}`
+	if s := dg.String(); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", expected)
+	}
+}
+
+func TestFromUndirected(t *testing.T) {
+	g := simple.NewUndirectedGraph()
+	g.SetEdge(simple.Edge{F: simple.Node(2), T: simple.Node(1)})
+	g.SetEdge(simple.Edge{F: simple.Node(3), T: simple.Node(1)})
+	g.AddNode(simple.Node(4))
+
+	dg := FromUndirected("g", g, nil)
+	expected := `graph g {
+1 []
+2 []
+3 []
+4 []
+1 -- 2
+1 -- 3
+}`
+	if s := dg.String(); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", expected)
+	}
+}
@@ -3,9 +3,13 @@
 package dot
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -40,49 +44,439 @@ type VertexDescription struct {
 	ColorScheme string
 	FontColor   string
 	FontName    string
-	Shape       string
+	Shape       Shape
 
 	// int attributes
 	Peripheries int
+
+	// link attributes, making the vertex clickable in SVG and image map
+	// output
+	URL     string
+	Target  string
+	Tooltip string
+
+	// FillColor fills the vertex when Style includes "filled".  A Gradient
+	// fill is drawn at GradientAngle degrees, or radially with the "radial"
+	// style.
+	FillColor     string
+	GradientAngle int
+
+	// float attributes.  Width and Height are in inches and FontSize in
+	// points.
+	PenWidth float64
+	FontSize float64
+	Width    float64
+	Height   float64
+
+	// FixedSize keeps the vertex at Width and Height regardless of its
+	// label
+	FixedSize FixedSize
+
+	// Image is the path or URL of an image drawn inside the vertex, such
+	// as the icon of a service
+	Image      string
+	ImageScale ImageScale
+	ImagePos   ImagePos
+
+	// XLabel is written outside the vertex and placed after the layout, so
+	// it does not move other elements
+	XLabel string
+
+	// Pos places the vertex in layouts other than dot.  It is left to the
+	// layout engine when nil.
+	Pos *Position
+
+	// Ordering keeps the out or in edges of the vertex in the order they
+	// are written, such as the children of a node in a tree
+	Ordering string
+
+	// Class and SVGID are written as the class and id of the element of
+	// the vertex in SVG output, for styling and scripting it
+	Class string
+	SVGID string
+
+	// Layer is the layer or range of layers of the graph Layers the vertex
+	// is drawn in, such as "data", "1:3" or LayerAll
+	Layer string
+
+	// Comment is written as a line comment at the end of the statement of
+	// the vertex.  It is not a graphviz attribute.
+	Comment string
+
+	// Attrs holds other graphviz attributes and zero values the fields
+	// above cannot write, such as peripheries=0
+	Attrs map[string]string
+}
+
+// PinAt pins the vertex at the given position
+func (v *VertexDescription) PinAt(x, y float64) {
+	v.Pos = &Position{X: x, Y: y, Pinned: true}
 }
 
-// NewVertexDescription returns a new VertexDescription with the given ID.
-func NewVertexDescription(id string) VertexDescription {
-	return VertexDescription{
+// FixedSize controls whether a vertex grows to fit its label
+type FixedSize string
+
+// Valid values for VertexDescription.FixedSize.  With FixedSizeShape only
+// the shape is fixed, and the label may overflow it while still being
+// taken into account to avoid overlaps.
+const (
+	FixedSizeTrue  FixedSize = "true"
+	FixedSizeFalse FixedSize = "false"
+	FixedSizeShape FixedSize = "shape"
+)
+
+// SetSize fixes the vertex at the given width and height in inches.  Set on
+// NodeDefaults, it gives every vertex of the graph the same size.
+func (v *VertexDescription) SetSize(width, height float64) {
+	v.Width = width
+	v.Height = height
+	v.FixedSize = FixedSizeTrue
+}
+
+// NewVertexDescription returns a new VertexDescription with the given ID,
+// with the options applied in order.
+func NewVertexDescription(id string, opts ...VertexOption) VertexDescription {
+	v := VertexDescription{
 		ID: id,
 	}
+	for _, opt := range opts {
+		opt(&v)
+	}
+	return v
+}
+
+// StyleInvisible is the style of vertices and edges that take part in the
+// layout without being drawn
+const StyleInvisible = "invis"
+
+// NewInvisibleVertex returns a new VertexDescription with the given ID
+// which is not drawn, such as a spacer used to align other vertices
+func NewInvisibleVertex(id string) VertexDescription {
+	return NewVertexDescription(id, WithStyle(StyleInvisible))
+}
+
+// VertexOption sets an attribute of a vertex built by NewVertexDescription
+type VertexOption func(v *VertexDescription)
+
+// WithLabel sets the label of the vertex
+func WithLabel(label string) VertexOption {
+	return func(v *VertexDescription) { v.Label = label }
+}
+
+// WithColor sets the color of the vertex
+func WithColor(color Color) VertexOption {
+	return func(v *VertexDescription) { v.Color = string(color) }
+}
+
+// WithFillColor sets the fill color of the vertex and adds the "filled"
+// style
+func WithFillColor(color Color) VertexOption {
+	return func(v *VertexDescription) {
+		v.FillColor = string(color)
+		if !strings.Contains(v.Style, "filled") {
+			v.Style = strings.TrimPrefix(v.Style+",filled", ",")
+		}
+	}
+}
+
+// WithShape sets the shape of the vertex
+func WithShape(shape Shape) VertexOption {
+	return func(v *VertexDescription) { v.Shape = shape }
+}
+
+// WithStyle sets the style of the vertex
+func WithStyle(style string) VertexOption {
+	return func(v *VertexDescription) { v.Style = style }
+}
+
+// WithGroup sets the group of the vertex
+func WithGroup(group string) VertexOption {
+	return func(v *VertexDescription) { v.Group = group }
+}
+
+// WithTooltip sets the tooltip of the vertex
+func WithTooltip(tooltip string) VertexOption {
+	return func(v *VertexDescription) { v.Tooltip = tooltip }
+}
+
+// WithTruncatedLabel truncates the label of the vertex, see TruncateLabel.
+// It must follow the option setting the label.
+func WithTruncatedLabel(n int, tooltip bool) VertexOption {
+	return func(v *VertexDescription) { v.TruncateLabel(n, tooltip) }
+}
+
+// WithAttribute sets an arbitrary graphviz attribute on the vertex
+func WithAttribute(key, value string) VertexOption {
+	return func(v *VertexDescription) { v.AddAttribute(key, value) }
 }
 
 // Write writes the vertex description to a writer
 func (v *VertexDescription) Write(w io.Writer) error {
-	nodeStr := fmt.Sprintf("%s ", v.ID)
-	vertexR := reflect.ValueOf(*v)
-	nodeStr += "["
-	for i := 1; i < vertexR.NumField(); i++ {
-		field := vertexR.Field(i)
-		name := strings.ToLower(vertexR.Type().Field(i).Name)
-
-		switch field.Kind() {
-		case reflect.String:
-			value := field.String()
-			if value != "" {
-				// for html like tags
-				if value[0] == '<' {
-					nodeStr += fmt.Sprintf("%s=%s ", name, value)
-				} else {
-					nodeStr += fmt.Sprintf("%s=\"%s\" ", name, value)
-				}
+	return v.writeWithOptions(w, WriteOptions{})
+}
+
+func (v *VertexDescription) writeWithOptions(w io.Writer, opts WriteOptions) error {
+	if err := opts.checkIDs(v.ID); err != nil {
+		return err
+	}
+	id := QuoteID(v.ID)
+	if pad := opts.idWidth - len(id); pad > 0 {
+		id += strings.Repeat(" ", pad)
+	}
+	nodeStr := opts.statement(id, v.attributes(), false)
+	_, err := io.WriteString(w, nodeStr+trailingComment(v.Comment))
+	return err
+}
+
+// attributes returns the attributes set on the vertex, fields first in
+// the order of VertexAttributeOrder followed by Attrs
+func (v *VertexDescription) attributes() []attribute {
+	var attrs []attribute
+	for _, field := range vertexFields {
+		if _, ok := v.Attrs[field.name]; ok {
+			continue
+		}
+		if value := field.get(v); value != "" {
+			attrs = append(attrs, attribute{field.name, value})
+		}
+	}
+	return appendAttrs(attrs, v.Attrs)
+}
+
+// vertexField binds a graphviz attribute to a VertexDescription field.  get
+// returns the empty string for a field left at its zero value.
+type vertexField struct {
+	name string
+	get  func(v *VertexDescription) string
+	set  func(v *VertexDescription, value string) error
+}
+
+// stringField returns a vertexField for a string field
+func stringField(name string, field func(v *VertexDescription) *string) vertexField {
+	return vertexField{
+		name: name,
+		get: func(v *VertexDescription) string {
+			return *field(v)
+		},
+		set: func(v *VertexDescription, value string) error {
+			*field(v) = value
+			return nil
+		},
+	}
+}
+
+// intField returns a vertexField for an int field
+func intField(name string, field func(v *VertexDescription) *int) vertexField {
+	return vertexField{
+		name: name,
+		get: func(v *VertexDescription) string {
+			if *field(v) == 0 {
+				return ""
+			}
+			return strconv.Itoa(*field(v))
+		},
+		set: func(v *VertexDescription, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for attribute %s", value, name)
+			}
+			*field(v) = n
+			return nil
+		},
+	}
+}
+
+// floatField returns a vertexField for a float64 field
+func floatField(name string, field func(v *VertexDescription) *float64) vertexField {
+	return vertexField{
+		name: name,
+		get: func(v *VertexDescription) string {
+			if *field(v) == 0 {
+				return ""
 			}
-		case reflect.Int:
-			value := field.Int()
-			if value != 0 {
-				nodeStr += fmt.Sprintf("%s=\"%d\" ", name, value)
+			return formatFloat(*field(v))
+		},
+		set: func(v *VertexDescription, value string) error {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for attribute %s", value, name)
 			}
+			*field(v) = f
+			return nil
+		},
+	}
+}
+
+// formatFloat formats a float attribute value with the precision of a
+// float32.  That is more than graphviz uses, and avoids writing rounding
+// errors such as 0.30000000000000004.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 32)
+}
+
+// vertexFields lists the VertexDescription fields in the order their
+// attributes are written
+var vertexFields = []vertexField{
+	stringField("label", func(v *VertexDescription) *string { return &v.Label }),
+	stringField("group", func(v *VertexDescription) *string { return &v.Group }),
+	stringField("color", func(v *VertexDescription) *string { return &v.Color }),
+	stringField("style", func(v *VertexDescription) *string { return &v.Style }),
+	stringField("colorscheme", func(v *VertexDescription) *string { return &v.ColorScheme }),
+	stringField("fontcolor", func(v *VertexDescription) *string { return &v.FontColor }),
+	stringField("fontname", func(v *VertexDescription) *string { return &v.FontName }),
+	stringField("shape", func(v *VertexDescription) *string { return (*string)(&v.Shape) }),
+	intField("peripheries", func(v *VertexDescription) *int { return &v.Peripheries }),
+	stringField("URL", func(v *VertexDescription) *string { return &v.URL }),
+	stringField("target", func(v *VertexDescription) *string { return &v.Target }),
+	stringField("tooltip", func(v *VertexDescription) *string { return &v.Tooltip }),
+	stringField("fillcolor", func(v *VertexDescription) *string { return &v.FillColor }),
+	intField("gradientangle", func(v *VertexDescription) *int { return &v.GradientAngle }),
+	floatField("penwidth", func(v *VertexDescription) *float64 { return &v.PenWidth }),
+	floatField("fontsize", func(v *VertexDescription) *float64 { return &v.FontSize }),
+	floatField("width", func(v *VertexDescription) *float64 { return &v.Width }),
+	floatField("height", func(v *VertexDescription) *float64 { return &v.Height }),
+	stringField("fixedsize", func(v *VertexDescription) *string { return (*string)(&v.FixedSize) }),
+	stringField("image", func(v *VertexDescription) *string { return &v.Image }),
+	stringField("imagescale", func(v *VertexDescription) *string { return (*string)(&v.ImageScale) }),
+	stringField("imagepos", func(v *VertexDescription) *string { return (*string)(&v.ImagePos) }),
+	stringField("xlabel", func(v *VertexDescription) *string { return &v.XLabel }),
+	{
+		name: "pos",
+		get: func(v *VertexDescription) string {
+			if v.Pos == nil {
+				return ""
+			}
+			return v.Pos.String()
+		},
+		set: func(v *VertexDescription, value string) error {
+			p, err := parsePosition(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for attribute pos", value)
+			}
+			v.Pos = p
+			return nil
+		},
+	},
+	stringField("ordering", func(v *VertexDescription) *string { return &v.Ordering }),
+	stringField("class", func(v *VertexDescription) *string { return &v.Class }),
+	stringField("id", func(v *VertexDescription) *string { return &v.SVGID }),
+	stringField("layer", func(v *VertexDescription) *string { return &v.Layer }),
+}
+
+// vertexFieldIndex maps attribute names to their entry in vertexFields
+var vertexFieldIndex = make(map[string]*vertexField)
+
+func init() {
+	for i := range vertexFields {
+		vertexFieldIndex[vertexFields[i].name] = &vertexFields[i]
+	}
+}
+
+// VertexAttributeOrder returns the names of the attributes backed by
+// VertexDescription fields, in the order they are written.  Attributes
+// from Attrs are written after them, sorted by name.  The order is part of
+// the output format and only changes by appending new fields.
+func VertexAttributeOrder() []string {
+	names := make([]string, len(vertexFields))
+	for i, field := range vertexFields {
+		names[i] = field.name
+	}
+	return names
+}
+
+// AddAttribute sets an arbitrary graphviz attribute on the vertex.  Setting
+// the same key twice overwrites the previous value.
+func (v *VertexDescription) AddAttribute(key, value string) {
+	if v.Attrs == nil {
+		v.Attrs = make(map[string]string)
+	}
+	v.Attrs[key] = value
+}
+
+// attribute is a single name=value pair of an attribute list
+type attribute struct {
+	name, value string
+}
+
+// appendAttrs appends the contents of an attribute map to attrs, sorted by
+// name
+func appendAttrs(attrs []attribute, m map[string]string) []attribute {
+	for _, key := range sortedKeys(m) {
+		attrs = append(attrs, attribute{key, m[key]})
+	}
+	return attrs
+}
+
+// sortedKeys returns the keys of an attribute map in sorted order
+func sortedKeys(attrs map[string]string) []string {
+	if len(attrs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// attrString formats a single name=value attribute pair.  Values enclosed in
+// '<' and '>' are treated as html like labels and are not quoted.
+func attrString(name, value string) string {
+	var b strings.Builder
+	writeAttr(&b, name, value)
+	return b.String()
+}
+
+// htmlAttrs are the attributes which accept HTML strings.  A quoted value of
+// these attributes which looks like an HTML string is stored with its quotes
+// by Parse, and written as it is.
+var htmlAttrs = map[string]bool{
+	"label":     true,
+	"xlabel":    true,
+	"headlabel": true,
+	"taillabel": true,
+}
+
+// writeAttr writes a single name=value attribute pair to b, see attrString
+func writeAttr(b *strings.Builder, name, value string) {
+	b.WriteString(name)
+	if isHTML(value) || (htmlAttrs[name] && isQuoted(value) && isHTML(value[1:len(value)-1])) {
+		b.WriteByte('=')
+		b.WriteString(value)
+		return
+	}
+	b.WriteString(`="`)
+	b.WriteString(escapeQuotes(value))
+	b.WriteByte('"')
+}
+
+// escapeQuotes escapes the double quotes of a value which are not escaped
+// yet, and a trailing backslash which would escape the closing quote.  Other
+// escape sequences, such as the \n and \l line breaks of labels, are kept.
+// Raw line breaks are written as \n.
+func escapeQuotes(value string) string {
+	if !strings.ContainsAny(value, "\"\\\n") {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value):
+			b.WriteString(value[i : i+2])
+			i++
+			continue
+		case value[i] == '\r' && i+1 < len(value) && value[i+1] == '\n':
+			continue
+		case value[i] == '\n':
+			b.WriteString(`\n`)
+			continue
+		case value[i] == '\\', value[i] == '"':
+			b.WriteByte('\\')
 		}
+		b.WriteByte(value[i])
 	}
-	nodeStr += "]"
-	_, err := io.WriteString(w, nodeStr)
-	return err
+	return b.String()
 }
 
 // EdgeDescription is an element containing all the information needed to
@@ -92,22 +486,379 @@ type EdgeDescription struct {
 	To       VertexDescription
 	Directed bool
 
+	// Ports and compass points the edge attaches to on its endpoints.
+	// Ports name record fields or HTML table cells.
+	FromPort    string
+	FromCompass string
+	ToPort      string
+	ToCompass   string
+
+	// Label is written next to the edge.  Double quotes in it are
+	// escaped.
+	Label string
 	Style string
+
+	// HeadLabel and TailLabel are written near the ends of the edge, at
+	// LabelDistance times their default distance and LabelAngle degrees
+	// from the edge
+	HeadLabel     string
+	TailLabel     string
+	LabelDistance float64
+	LabelAngle    float64
+
+	// ArrowHead and ArrowTail are drawn at the ends selected by Dir, scaled
+	// by ArrowSize
+	ArrowHead Arrow
+	ArrowTail Arrow
+	ArrowSize float64
+	Dir       Dir
+
+	// Weight, Constraint and MinLen steer the layout of the edge.  A
+	// heavier edge is kept shorter and straighter, an edge with Constraint
+	// set to false does not affect the ranking of its endpoints, and MinLen
+	// is the least number of ranks between them.  Constraint is left to
+	// graphviz when nil.
+	Weight     float64
+	Constraint *bool
+	MinLen     int
+
+	// LHead and LTail name clusters the edge is clipped at, so that it
+	// appears to connect the clusters rather than their vertices.  They
+	// require Compound to be set on the root graph.
+	LHead string
+	LTail string
+
+	// Color may be a ColorList, drawing the edge as parallel lines of each
+	// color, or as consecutive segments when the colors have weights
+	Color string
+
+	// PenWidth is the width of the line and FontSize the size of its labels
+	// in points
+	PenWidth float64
+	FontSize float64
+
+	// XLabel is written near the middle of the edge and placed after the
+	// layout, so it does not move other elements
+	XLabel string
+
+	// Edges with the same SameHead group share their end point at the head
+	// vertex, and edges with the same SameTail group their start point at
+	// the tail vertex, drawing fan-in and fan-out edges as one
+	SameHead string
+	SameTail string
+
+	// Class and SVGID are written as the class and id of the element of
+	// the edge in SVG output
+	Class string
+	SVGID string
+
+	// Layer is the layer or range of layers the edge is drawn in
+	Layer string
+
+	// Comment is written as a line comment at the end of the statement of
+	// the edge.  It is not a graphviz attribute.
+	Comment string
+
+	// Key identifies the edge among parallel edges between the same
+	// vertices.  It is not written to the output.
+	Key string
+	// count is the number of edges collapsed into the edge by
+	// ParallelCollapse
+	count int
+
+	// Attrs holds other graphviz attributes and zero values the fields
+	// above cannot write, such as weight=0
+	Attrs map[string]string
+}
+
+// AddAttribute sets an arbitrary graphviz attribute on the edge.  Setting
+// the same key twice overwrites the previous value.
+func (e *EdgeDescription) AddAttribute(key, value string) {
+	if e.Attrs == nil {
+		e.Attrs = make(map[string]string)
+	}
+	e.Attrs[key] = value
 }
 
 // Write writes the edge description to a writer
 func (e *EdgeDescription) Write(w io.Writer) error {
+	return e.writeWithOptions(w, WriteOptions{})
+}
+
+func (e *EdgeDescription) writeWithOptions(w io.Writer, opts WriteOptions) error {
+	if err := opts.checkIDs(e.From.ID, e.FromPort, e.To.ID, e.ToPort); err != nil {
+		return err
+	}
 	var arrow string
 	if e.Directed {
 		arrow = "->"
 	} else {
 		arrow = "--"
 	}
-	edgeStr := fmt.Sprintf("%s %s %s", e.From.ID, arrow, e.To.ID)
-	if e.Style != "" {
-		edgeStr += fmt.Sprintf(" [ style=\"%s\" ]", e.Style)
+	from := endpointString(e.From.ID, e.FromPort, e.FromCompass)
+	to := endpointString(e.To.ID, e.ToPort, e.ToCompass)
+	edgeStr := opts.statement(from+" "+arrow+" "+to, e.attributes(), true)
+	_, err := io.WriteString(w, edgeStr+trailingComment(e.Comment))
+	return err
+}
+
+// attributes returns the attributes set on the edge, fields first in the
+// order of EdgeAttributeOrder followed by Attrs
+func (e *EdgeDescription) attributes() []attribute {
+	var attrs []attribute
+	for _, field := range edgeFields {
+		if _, ok := e.Attrs[field.name]; ok {
+			continue
+		}
+		if value := field.get(e); value != "" {
+			attrs = append(attrs, attribute{field.name, value})
+		}
+	}
+	return appendAttrs(attrs, e.Attrs)
+}
+
+// edgeField binds a graphviz attribute to an EdgeDescription field.  get
+// returns the empty string for a field left at its zero value.
+type edgeField struct {
+	name string
+	get  func(e *EdgeDescription) string
+	set  func(e *EdgeDescription, value string) error
+}
+
+// edgeStringField returns an edgeField for a string field
+func edgeStringField(name string, field func(e *EdgeDescription) *string) edgeField {
+	return edgeField{
+		name: name,
+		get: func(e *EdgeDescription) string {
+			return *field(e)
+		},
+		set: func(e *EdgeDescription, value string) error {
+			*field(e) = value
+			return nil
+		},
+	}
+}
+
+// edgeFloatField returns an edgeField for a float64 field
+func edgeFloatField(name string, field func(e *EdgeDescription) *float64) edgeField {
+	return edgeField{
+		name: name,
+		get: func(e *EdgeDescription) string {
+			if *field(e) == 0 {
+				return ""
+			}
+			return formatFloat(*field(e))
+		},
+		set: func(e *EdgeDescription, value string) error {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for attribute %s", value, name)
+			}
+			*field(e) = f
+			return nil
+		},
+	}
+}
+
+// edgeIntField returns an edgeField for an int field
+func edgeIntField(name string, field func(e *EdgeDescription) *int) edgeField {
+	return edgeField{
+		name: name,
+		get: func(e *EdgeDescription) string {
+			if *field(e) == 0 {
+				return ""
+			}
+			return strconv.Itoa(*field(e))
+		},
+		set: func(e *EdgeDescription, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for attribute %s", value, name)
+			}
+			*field(e) = n
+			return nil
+		},
 	}
-	_, err := io.WriteString(w, edgeStr)
+}
+
+// edgeBoolField returns an edgeField for a *bool field, where nil leaves
+// the attribute unset
+func edgeBoolField(name string, field func(e *EdgeDescription) **bool) edgeField {
+	return edgeField{
+		name: name,
+		get: func(e *EdgeDescription) string {
+			if *field(e) == nil {
+				return ""
+			}
+			return strconv.FormatBool(**field(e))
+		},
+		set: func(e *EdgeDescription, value string) error {
+			b, err := parseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for attribute %s", value, name)
+			}
+			*field(e) = &b
+			return nil
+		},
+	}
+}
+
+// parseBool parses a graphviz boolean, which besides true and false may be
+// yes, no or an integer
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes":
+		return true, nil
+	case "false", "no":
+		return false, nil
+	}
+	n, err := strconv.Atoi(value)
+	return n != 0, err
+}
+
+// Bool returns a pointer to b, for setting optional boolean fields such as
+// EdgeDescription.Constraint
+func Bool(b bool) *bool {
+	return &b
+}
+
+// edgeFields lists the EdgeDescription fields in the order their
+// attributes are written
+var edgeFields = []edgeField{
+	edgeStringField("label", func(e *EdgeDescription) *string { return &e.Label }),
+	edgeStringField("style", func(e *EdgeDescription) *string { return &e.Style }),
+	edgeStringField("headlabel", func(e *EdgeDescription) *string { return &e.HeadLabel }),
+	edgeStringField("taillabel", func(e *EdgeDescription) *string { return &e.TailLabel }),
+	edgeFloatField("labeldistance", func(e *EdgeDescription) *float64 { return &e.LabelDistance }),
+	edgeFloatField("labelangle", func(e *EdgeDescription) *float64 { return &e.LabelAngle }),
+	edgeStringField("arrowhead", func(e *EdgeDescription) *string { return (*string)(&e.ArrowHead) }),
+	edgeStringField("arrowtail", func(e *EdgeDescription) *string { return (*string)(&e.ArrowTail) }),
+	edgeFloatField("arrowsize", func(e *EdgeDescription) *float64 { return &e.ArrowSize }),
+	edgeStringField("dir", func(e *EdgeDescription) *string { return (*string)(&e.Dir) }),
+	edgeFloatField("weight", func(e *EdgeDescription) *float64 { return &e.Weight }),
+	edgeBoolField("constraint", func(e *EdgeDescription) **bool { return &e.Constraint }),
+	edgeIntField("minlen", func(e *EdgeDescription) *int { return &e.MinLen }),
+	edgeStringField("lhead", func(e *EdgeDescription) *string { return &e.LHead }),
+	edgeStringField("ltail", func(e *EdgeDescription) *string { return &e.LTail }),
+	edgeStringField("color", func(e *EdgeDescription) *string { return &e.Color }),
+	edgeFloatField("penwidth", func(e *EdgeDescription) *float64 { return &e.PenWidth }),
+	edgeFloatField("fontsize", func(e *EdgeDescription) *float64 { return &e.FontSize }),
+	edgeStringField("xlabel", func(e *EdgeDescription) *string { return &e.XLabel }),
+	edgeStringField("samehead", func(e *EdgeDescription) *string { return &e.SameHead }),
+	edgeStringField("sametail", func(e *EdgeDescription) *string { return &e.SameTail }),
+	edgeStringField("class", func(e *EdgeDescription) *string { return &e.Class }),
+	edgeStringField("id", func(e *EdgeDescription) *string { return &e.SVGID }),
+	edgeStringField("layer", func(e *EdgeDescription) *string { return &e.Layer }),
+}
+
+// edgeFieldIndex maps attribute names to their entry in edgeFields
+var edgeFieldIndex = make(map[string]*edgeField)
+
+func init() {
+	for i := range edgeFields {
+		edgeFieldIndex[edgeFields[i].name] = &edgeFields[i]
+	}
+}
+
+// EdgeAttributeOrder returns the names of the attributes backed by
+// EdgeDescription fields, in the order they are written.  Attributes from
+// Attrs are written after them, sorted by name.  The order is part of the
+// output format and only changes by appending new fields.
+func EdgeAttributeOrder() []string {
+	names := make([]string, len(edgeFields))
+	for i, field := range edgeFields {
+		names[i] = field.name
+	}
+	return names
+}
+
+// EdgeChain is an element describing a path of edges through a sequence of
+// vertices, written as a single statement such as a -> b -> c
+type EdgeChain struct {
+	Vertices []VertexDescription
+	Directed bool
+
+	Style string
+}
+
+// Write writes the edge chain to a writer
+func (c *EdgeChain) Write(w io.Writer) error {
+	return c.writeWithOptions(w, WriteOptions{})
+}
+
+func (c *EdgeChain) writeWithOptions(w io.Writer, opts WriteOptions) error {
+	if len(c.Vertices) < 2 {
+		return fmt.Errorf("edge chain needs at least two vertices, has %d", len(c.Vertices))
+	}
+	arrow := " -- "
+	if c.Directed {
+		arrow = " -> "
+	}
+	ids := make([]string, len(c.Vertices))
+	for i, v := range c.Vertices {
+		if err := opts.checkIDs(v.ID); err != nil {
+			return err
+		}
+		ids[i] = QuoteID(v.ID)
+	}
+	var attrs []attribute
+	if c.Style != "" {
+		attrs = append(attrs, attribute{"style", c.Style})
+	}
+	chainStr := opts.statement(strings.Join(ids, arrow), attrs, true)
+	_, err := io.WriteString(w, chainStr)
+	return err
+}
+
+// Edges returns the individual edges making up the chain
+func (c *EdgeChain) Edges() []EdgeDescription {
+	var edges []EdgeDescription
+	for i := 1; i < len(c.Vertices); i++ {
+		edges = append(edges, EdgeDescription{
+			From:     c.Vertices[i-1],
+			To:       c.Vertices[i],
+			Directed: c.Directed,
+			Style:    c.Style,
+		})
+	}
+	return edges
+}
+
+// endpointString formats an edge endpoint with its optional port and compass
+// point
+func endpointString(id, port, compass string) string {
+	s := QuoteID(id)
+	if port != "" {
+		s += ":" + QuoteID(port)
+	}
+	if compass != "" {
+		s += ":" + compass
+	}
+	return s
+}
+
+// RankGroup is an element constraining a set of vertices to a rank, written
+// as an anonymous subgraph such as { rank=same; a; b; c }
+type RankGroup struct {
+	Rank string
+	IDs  []string
+}
+
+// Write writes the rank group to a writer
+func (r *RankGroup) Write(w io.Writer) error {
+	return r.writeWithOptions(w, WriteOptions{})
+}
+
+func (r *RankGroup) writeWithOptions(w io.Writer, opts WriteOptions) error {
+	if err := opts.checkIDs(r.IDs...); err != nil {
+		return err
+	}
+	groupStr := fmt.Sprintf("{ rank=%s;", QuoteID(r.Rank))
+	for _, id := range r.IDs {
+		groupStr += fmt.Sprintf(" %s;", QuoteID(id))
+	}
+	groupStr += " }"
+	_, err := io.WriteString(w, groupStr)
 	return err
 }
 
@@ -116,11 +867,271 @@ type Graph struct {
 	Name       string
 	Body       []Element
 	IsSubGraph bool
+	// IsStrict marks the graph as strict, forbidding multi-edges.  It is
+	// ignored for subgraphs.
+	IsStrict bool
+	// IsUndirected makes the graph an undirected "graph" rather than a
+	// "digraph".  Only undirected edges may be added to it.  It is ignored
+	// for subgraphs.
+	IsUndirected bool
 
 	// string attributes
-	Rank string
+	Rank    string
+	RankDir string
+	Label   string
+	Style   string
+	Color   string
+	BgColor string
+
+	// Compound allows edges to be clipped at cluster boundaries with the
+	// LHead and LTail edge fields.  It only applies to the root graph.
+	Compound bool
+
+	// FillColor and GradientAngle fill a cluster with the "filled" style,
+	// like the matching vertex fields.  GradientAngle also applies to a
+	// Gradient BgColor.
+	FillColor     string
+	GradientAngle int
+
+	// PenWidth is the width of the cluster border and FontSize the size of
+	// the graph label in points
+	PenWidth float64
+	FontSize float64
+
+	// Layout selects the layout engine graphviz uses for the graph.  It only
+	// applies to the root graph.
+	Layout string
+
+	// NodeSep and RankSep are the least space in inches between vertices of
+	// the same rank and between ranks.  RankSepEqually spaces all ranks as
+	// far apart as the two furthest ones.
+	NodeSep        float64
+	RankSep        float64
+	RankSepEqually bool
+
+	// Margin is the space around the drawing, in inches for the root graph
+	// and in points for clusters.  Pad is added around the drawing of the
+	// root graph, in inches.  They are left to graphviz when nil.
+	Margin *Margin
+	Pad    *Margin
+
+	// Concentrate merges edges with a common endpoint into shared lines,
+	// and Splines selects how edges are routed.  Both only apply to the
+	// root graph.
+	Concentrate bool
+	Splines     Splines
+
+	// LabelLoc and LabelJust place the Label of the graph or cluster, which
+	// is written in FontName and FontColor
+	LabelLoc  string
+	LabelJust string
+	FontName  string
+	FontColor string
+
+	// NewRank ranks the whole graph at once, so that rank constraints
+	// apply across cluster boundaries.  ClusterRank selects how clusters
+	// are ranked otherwise.  Both only apply to the root graph.
+	NewRank     bool
+	ClusterRank string
+
+	// Size is the largest size of the drawing, and Ratio how the drawing
+	// is scaled to it.  Rotate set to 90 draws it in landscape, and DPI is
+	// the resolution of bitmap output.  They only apply to the root graph.
+	Size   *Size
+	Ratio  string
+	Rotate int
+	DPI    float64
+
+	// Ordering applies the Ordering of a vertex to every vertex in the
+	// graph
+	Ordering string
+
+	// Class and SVGID are written as the class and id of the element of
+	// the graph or cluster in SVG output
+	Class string
+	SVGID string
+
+	// Layers names the layers of the drawing, which vertices, edges and
+	// clusters are assigned to with their Layer.  LayerSelect picks the
+	// layers that are rendered, all of them when empty.  Both only apply
+	// to the root graph.
+	Layers      []string
+	LayerSelect string
+	Layer       string
+
+	// Attrs holds other graphviz attributes and zero values the fields
+	// above cannot write, such as charset
+	Attrs map[string]string
+
+	// NodeDefaults and EdgeDefaults hold attributes applied to every vertex
+	// and edge of the graph and its subgraphs.  Their IDs and endpoints are
+	// ignored.
+	NodeDefaults *VertexDescription
+	EdgeDefaults *EdgeDescription
+
+	// Dedup controls how AddVertex handles vertices whose ID has already
+	// been added to the graph
+	Dedup DedupMode
+	// dedupErr records the first conflict found when Dedup is DedupError
+	dedupErr error
+	// Parallel controls how AddEdge handles edges between vertices which
+	// are already connected by an edge in the graph body
+	Parallel ParallelMode
+	// DAG makes Validate report self-loops, which are not allowed in a
+	// directed acyclic graph.  It is not written to the output.
+	DAG bool
+	// LabelLimit makes AddVertex truncate the labels of the vertices longer
+	// than that many characters, see TruncateLabel.  LabelLimitTooltip moves
+	// the full labels to the tooltips.  They are not written to the output.
+	LabelLimit        int
+	LabelLimitTooltip bool
+	// IDScheme selects how NewVertex generates vertex IDs
+	IDScheme IDScheme
+	// nextID is the number of the next ID generated by IDSequential
+	nextID int
+	// sanitized maps the IDs returned by SanitizeID to their source
+	sanitized map[string]string
+	// parent is the graph the graph was added to by AddCluster
+	parent *Graph
+	// idx indexes the vertices and edges of the body
+	idx *bodyIndex
+}
+
+// Margin is a horizontal and vertical margin, written as a single value
+// when both are equal
+type Margin struct {
+	X, Y float64
+}
+
+// String returns the margin as written in an attribute
+func (m Margin) String() string {
+	if m.X == m.Y {
+		return formatFloat(m.X)
+	}
+	return formatFloat(m.X) + "," + formatFloat(m.Y)
+}
+
+// parseMargin parses a margin attribute
+func parseMargin(value string) (*Margin, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+	nums, err := parseFloats(parts, 2)
+	if err != nil {
+		return nil, err
+	}
+	return &Margin{X: nums[0], Y: nums[1]}, nil
+}
+
+// Splines is the routing style of the edges of a graph
+type Splines string
+
+// Valid values for Graph.Splines.  Edges are drawn as splines by default.
+const (
+	SplinesSpline   Splines = "spline"
+	SplinesOrtho    Splines = "ortho"
+	SplinesPolyline Splines = "polyline"
+	SplinesCurved   Splines = "curved"
+	SplinesLine     Splines = "line"
+	SplinesNone     Splines = "none"
+)
+
+// Valid values for Graph.LabelLoc.  Graph labels default to the bottom and
+// cluster labels to the top.
+const (
+	LabelLocTop    = "t"
+	LabelLocBottom = "b"
+)
+
+// Valid values for Graph.LabelJust.  Labels are centered by default.
+const (
+	LabelJustLeft   = "l"
+	LabelJustRight  = "r"
+	LabelJustCenter = "c"
+)
+
+// Valid values for Graph.ClusterRank.  Clusters are ranked locally by
+// default.
+const (
+	ClusterRankLocal  = "local"
+	ClusterRankGlobal = "global"
+	ClusterRankNone   = "none"
+)
+
+// LayerAll is the Layer of elements drawn in every layer
+const LayerAll = "all"
+
+// parseLayers splits a layers attribute on the default separators of
+// graphviz
+func parseLayers(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ':' || r == ' ' || r == '\t'
+	})
+}
+
+// Valid values for the Ordering of a vertex or graph.  OrderingOut keeps
+// the out edges of vertices in the order they are written, and OrderingIn
+// the in edges.
+const (
+	OrderingOut = "out"
+	OrderingIn  = "in"
+)
+
+// Size is the size of a drawing in inches.  Drawings larger than the size
+// are scaled down to fit it, and when Fill is set smaller drawings are
+// scaled up until one dimension matches.
+type Size struct {
+	Width, Height float64
+	Fill          bool
 }
 
+// String returns the size as written in the size attribute
+func (s Size) String() string {
+	str := formatFloat(s.Width) + "," + formatFloat(s.Height)
+	if s.Fill {
+		str += "!"
+	}
+	return str
+}
+
+// parseSize parses a size attribute, which may give a single dimension for
+// both
+func parseSize(value string) (*Size, error) {
+	fill := strings.HasSuffix(value, "!")
+	m, err := parseMargin(strings.TrimSuffix(value, "!"))
+	if err != nil {
+		return nil, err
+	}
+	return &Size{Width: m.X, Height: m.Y, Fill: fill}, nil
+}
+
+// Valid values for Graph.Ratio besides a number, which sets the ratio of
+// height to width of the drawing
+const (
+	RatioFill     = "fill"
+	RatioCompress = "compress"
+	RatioExpand   = "expand"
+	RatioAuto     = "auto"
+)
+
+// Valid values for Graph.Layout
+const (
+	LayoutDot   = "dot"
+	LayoutNeato = "neato"
+	LayoutFdp   = "fdp"
+	LayoutCirco = "circo"
+	LayoutTwopi = "twopi"
+)
+
+// Valid values for Graph.RankDir
+const (
+	RankDirTB = "TB"
+	RankDirLR = "LR"
+	RankDirBT = "BT"
+	RankDirRL = "RL"
+)
+
 // NewGraph returns a new dot-file graph object given the provided name
 func NewGraph(name string) Graph {
 	return Graph{
@@ -128,14 +1139,121 @@ func NewGraph(name string) Graph {
 	}
 }
 
+// NewCluster returns a new subgraph which graphviz draws as a cluster, its
+// vertices grouped within a bounding rectangle.  The name is prefixed with
+// "cluster_" as graphviz requires.
+func NewCluster(name string) Graph {
+	return Graph{
+		Name:       "cluster_" + name,
+		IsSubGraph: true,
+	}
+}
+
+// SetBackground sets the background of the graph or cluster to a gradient
+// from one color to another drawn at the given angle in degrees, or to a
+// plain color when both are the same
+func (graph *Graph) SetBackground(from, to Color, angle int) {
+	if from == to {
+		graph.BgColor = string(from)
+		graph.GradientAngle = 0
+		return
+	}
+	graph.BgColor = string(Gradient(from, to))
+	graph.GradientAngle = angle
+}
+
+// IsCluster reports whether the graph is a subgraph drawn as a cluster
+func (graph *Graph) IsCluster() bool {
+	return graph.IsSubGraph && strings.HasPrefix(graph.Name, "cluster")
+}
+
+// AddAttribute sets an arbitrary graphviz attribute on the graph.  Setting
+// the same key twice overwrites the previous value.
+func (graph *Graph) AddAttribute(key, value string) {
+	if graph.Attrs == nil {
+		graph.Attrs = make(map[string]string)
+	}
+	graph.Attrs[key] = value
+}
+
+// SetNodeDefaults schedules a node statement setting the default attributes
+// of all vertices in the graph
+func (graph *Graph) SetNodeDefaults(v VertexDescription) {
+	graph.NodeDefaults = &v
+}
+
+// SetEdgeDefaults schedules an edge statement setting the default attributes
+// of all edges in the graph
+func (graph *Graph) SetEdgeDefaults(e EdgeDescription) {
+	graph.EdgeDefaults = &e
+}
+
+// NewUndirectedGraph returns a new undirected dot-file graph object given the
+// provided name
+func NewUndirectedGraph(name string) Graph {
+	return Graph{
+		Name:         name,
+		IsUndirected: true,
+	}
+}
+
+// CommentStyle selects how AddCommentWithStyle writes a comment
+type CommentStyle int
+
+// Comment styles
+const (
+	// CommentBlock writes /* */ comments, one line per line of text after
+	// the first
+	CommentBlock CommentStyle = iota
+	// CommentLine writes a // comment for each line of text
+	CommentLine
+)
+
 // AddComment interprets the given argument as the text of a comment and
 // schedules the comment to be written in the output dotfile
 func (graph *Graph) AddComment(text string) {
-	commentStr := fmt.Sprintf("/* %s */", text)
-	line := &Literal{
-		Line: commentStr,
+	graph.AddCommentWithStyle(text, CommentBlock)
+}
+
+// AddCommentWithStyle schedules a comment in the given style to be written
+// in the output dotfile.  Text spanning several lines is written as one
+// literal per line, and a "*/" which would end a block comment early is
+// broken up.
+func (graph *Graph) AddCommentWithStyle(text string, style CommentStyle) {
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	var comment []string
+	switch {
+	case style == CommentLine:
+		for _, line := range lines {
+			comment = append(comment, strings.TrimRight("// "+line, " "))
+		}
+	case len(lines) == 1:
+		comment = []string{fmt.Sprintf("/* %s */", escapeComment(text))}
+	default:
+		comment = append(comment, "/*")
+		for _, line := range lines {
+			comment = append(comment, strings.TrimRight(" * "+escapeComment(line), " "))
+		}
+		comment = append(comment, " */")
 	}
-	graph.Body = append(graph.Body, line)
+	for _, line := range comment {
+		graph.Body = append(graph.Body, &Literal{Line: line})
+	}
+}
+
+// escapeComment breaks up the "*/" sequences of the text of a block comment
+func escapeComment(text string) string {
+	return strings.Replace(text, "*/", "* /", -1)
+}
+
+// trailingComment returns the line comment written after a statement, with
+// line breaks in the text replaced by spaces
+func trailingComment(text string) string {
+	if text == "" {
+		return ""
+	}
+	text = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(text)
+	return " // " + text
 }
 
 // AddNewLine schedules a newline to be written in the output dotfile
@@ -147,21 +1265,141 @@ func (graph *Graph) AddNewLine() {
 }
 
 // AddVertex schedules the vertexdescription to be written in the output
-// dotfile
+// dotfile.  If deduplication is enabled and a vertex with the same ID was
+// already added, v is merged into it instead.
 func (graph *Graph) AddVertex(v *VertexDescription) {
+	if graph.LabelLimit > 0 {
+		v.TruncateLabel(graph.LabelLimit, graph.LabelLimitTooltip)
+	}
+	if graph.Dedup != DedupNone {
+		if existing := graph.FindVertex(v.ID); existing != nil {
+			err := mergeVertex(existing, v, graph.Dedup == DedupMerge)
+			if err != nil && graph.dedupErr == nil {
+				graph.dedupErr = err
+			}
+			return
+		}
+	}
 	graph.Body = append(graph.Body, v)
 }
 
 // AddEdge constructs an edgedescription connecting the two vertices given
 // as parameters and schedules this element to be written in the output dotfile
+// according to the Parallel mode of the graph
 func (graph *Graph) AddEdge(v1 *VertexDescription, v2 *VertexDescription, directed bool, style string) {
-	edge := &EdgeDescription{
+	graph.addEdge(&EdgeDescription{
 		From:     *v1,
 		To:       *v2,
 		Directed: directed,
 		Style:    style,
+	})
+}
+
+// AddInvisibleEdge schedules an edge connecting the two vertices which is
+// not drawn but still constrains the layout, such as keeping one vertex
+// above the other.  The edge is directed unless the graph is undirected.
+func (graph *Graph) AddInvisibleEdge(v1 *VertexDescription, v2 *VertexDescription) {
+	graph.AddEdge(v1, v2, !graph.IsUndirected, StyleInvisible)
+}
+
+// SelfLoopOptions sets the appearance of an edge added by AddSelfLoop
+type SelfLoopOptions struct {
+	Label string
+	Style string
+	// Port names the record field or HTML table cell the loop attaches to
+	Port string
+	// FromCompass and ToCompass are the compass points the loop leaves and
+	// enters the vertex at.  They default to "ne" and "se", drawing the
+	// loop on the right of the vertex.
+	FromCompass string
+	ToCompass   string
+}
+
+// AddSelfLoop schedules an edge from the vertex to itself to be written in
+// the output dotfile according to the Parallel mode of the graph and returns
+// it, or the earlier loop it was collapsed into.  The edge is directed
+// unless the graph is undirected.
+func (graph *Graph) AddSelfLoop(v *VertexDescription, opts SelfLoopOptions) *EdgeDescription {
+	if opts.FromCompass == "" {
+		opts.FromCompass = "ne"
+	}
+	if opts.ToCompass == "" {
+		opts.ToCompass = "se"
+	}
+	return graph.addEdge(&EdgeDescription{
+		From:        *v,
+		To:          *v,
+		Directed:    !graph.IsUndirected,
+		Label:       opts.Label,
+		Style:       opts.Style,
+		FromPort:    opts.Port,
+		FromCompass: opts.FromCompass,
+		ToPort:      opts.Port,
+		ToCompass:   opts.ToCompass,
+	})
+}
+
+// AddClusterEdge schedules an edge drawn between the boundaries of two
+// clusters to be written in the output dotfile.  The edge connects the first
+// vertex of each cluster and is clipped with LTail and LHead, and Compound
+// is set on the graph, which should be the root graph.
+func (graph *Graph) AddClusterEdge(from, to *Graph, directed bool, style string) error {
+	v1, err := clusterAnchor(from)
+	if err != nil {
+		return err
+	}
+	v2, err := clusterAnchor(to)
+	if err != nil {
+		return err
+	}
+	graph.Compound = true
+	graph.addEdge(&EdgeDescription{
+		From:     VertexDescription{ID: v1},
+		To:       VertexDescription{ID: v2},
+		Directed: directed,
+		Style:    style,
+		LTail:    from.Name,
+		LHead:    to.Name,
+	})
+	return nil
+}
+
+// clusterAnchor returns the ID of the first vertex of a cluster
+func clusterAnchor(cluster *Graph) (string, error) {
+	if !cluster.IsCluster() {
+		return "", fmt.Errorf("subgraph %s is not a cluster", cluster.Name)
+	}
+	_, order := collectVertices(cluster)
+	if len(order) == 0 {
+		return "", fmt.Errorf("cluster %s has no vertices", cluster.Name)
+	}
+	return order[0], nil
+}
+
+// AddSameRank schedules a rank group placing the given vertices on the same
+// rank to be written in the output dotfile
+func (graph *Graph) AddSameRank(vertices ...*VertexDescription) {
+	group := &RankGroup{
+		Rank: "same",
+	}
+	for _, v := range vertices {
+		group.IDs = append(group.IDs, v.ID)
+	}
+	graph.Body = append(graph.Body, group)
+}
+
+// AddEdgeChain constructs an edge chain connecting the given vertices in
+// order and schedules it to be written in the output dotfile as a single
+// statement
+func (graph *Graph) AddEdgeChain(directed bool, style string, vs ...*VertexDescription) {
+	chain := &EdgeChain{
+		Directed: directed,
+		Style:    style,
+	}
+	for _, v := range vs {
+		chain.Vertices = append(chain.Vertices, *v)
 	}
-	graph.Body = append(graph.Body, edge)
+	graph.Body = append(graph.Body, chain)
 }
 
 // AddSubGraph schedules a newline to be written in the output dotfile.
@@ -172,33 +1410,432 @@ func (graph *Graph) AddSubGraph(sGraph *Graph) {
 // WriteDot writes the elements scheduled on this Graph to the provided
 // writer to construct a valid dot-file
 func (graph *Graph) Write(w io.Writer) error {
+	return graph.WriteWithOptions(w, WriteOptions{})
+}
+
+// WriteContext writes the graph like Write, checking ctx for cancellation
+// before writing each element of the graph and its subgraphs.  Once ctx is
+// done the write stops and ctx.Err() is returned, leaving a partial
+// dot-file in w.
+func (graph *Graph) WriteContext(ctx context.Context, w io.Writer) error {
+	return graph.WriteWithOptions(w, WriteOptions{ctx: ctx})
+}
+
+// WriteWithOptions writes the elements scheduled on this Graph to the
+// provided writer, formatted according to opts
+func (graph *Graph) WriteWithOptions(w io.Writer, opts WriteOptions) error {
+	if graph.dedupErr != nil {
+		return graph.dedupErr
+	}
+	what := "graph " + graph.Name
+	if graph.IsSubGraph {
+		what = "subgraph " + graph.Name
+	}
+	cw, ok := w.(*countingWriter)
+	if !ok {
+		if opts.BufferSize > 0 {
+			bw := bufio.NewWriterSize(w, opts.BufferSize)
+			cw = &countingWriter{w: bw}
+			err := graph.WriteWithOptions(cw, opts)
+			if flushErr := bw.Flush(); err == nil && flushErr != nil {
+				err = writeError(what, cw.n-int64(bw.Buffered()), flushErr)
+			}
+			return err
+		}
+		cw = &countingWriter{w: w}
+	}
+	name := graph.Name
+	if name != "" {
+		if err := opts.checkIDs(name); err != nil {
+			return err
+		}
+		name = QuoteID(name)
+	}
 	var title string
 	if graph.IsSubGraph {
-		title = fmt.Sprintf("subgraph %s {\n", graph.Name)
+		title = fmt.Sprintf("subgraph %s {\n", name)
+	} else if graph.IsUndirected {
+		if err := graph.checkUndirected(); err != nil {
+			return err
+		}
+		title = fmt.Sprintf("graph %s {\n", name)
 	} else {
-		title = fmt.Sprintf("digraph %s {\n", graph.Name)
+		title = fmt.Sprintf("digraph %s {\n", name)
 	}
-	_, err := io.WriteString(w, title)
-	if err != nil {
-		return err
+	if !graph.IsSubGraph && graph.IsStrict {
+		title = "strict " + title
+	}
+	offset := cw.n
+	if _, err := io.WriteString(cw, title); err != nil {
+		return writeError(what, offset, err)
+	}
+
+	indent := opts.indent(opts.depth + 1)
+	for _, attr := range opts.order(graph.attributes()) {
+		offset = cw.n
+		if _, err := io.WriteString(cw, indent+attrString(attr.name, attr.value)+"\n"); err != nil {
+			return writeError(what+" attribute "+attr.name, offset, err)
+		}
+	}
+
+	if graph.NodeDefaults != nil {
+		stmt := opts.statement("node", graph.NodeDefaults.attributes(), false)
+		offset = cw.n
+		if _, err := io.WriteString(cw, indent+stmt+"\n"); err != nil {
+			return writeError(what+" node defaults", offset, err)
+		}
 	}
 
-	if graph.Rank != "" {
-		_, err = io.WriteString(w, fmt.Sprintf("%s=\"%s\"\n", "rank", graph.Rank))
+	if graph.EdgeDefaults != nil {
+		if attrs := graph.EdgeDefaults.attributes(); len(attrs) > 0 {
+			stmt := opts.statement("edge", attrs, true)
+			offset = cw.n
+			if _, err := io.WriteString(cw, indent+stmt+"\n"); err != nil {
+				return writeError(what+" edge defaults", offset, err)
+			}
+		}
+	}
+
+	body := graph.Body
+	if opts.SortBody {
+		body = sortedBody(body)
+	}
+	bodyOpts := opts
+	if opts.AlignAttrs {
+		bodyOpts.idWidth = vertexIDWidth(body)
+	}
+	var rendered []*renderedElement
+	if opts.ParallelSubgraphs > 1 {
+		rendered = renderSubgraphs(body, indent, bodyOpts)
+	}
+	for i, line := range body {
+		if opts.ctx != nil {
+			if err := opts.ctx.Err(); err != nil {
+				return err
+			}
+		}
+		var err error
+		if rendered != nil && rendered[i] != nil {
+			err = writeRendered(cw, line, rendered[i])
+		} else {
+			err = writeElement(cw, line, indent, bodyOpts)
+		}
 		if err != nil {
 			return err
 		}
 	}
 
-	for _, line := range graph.Body {
-		err = line.Write(w)
-		_, err2 := io.WriteString(w, "\n")
-		if err != nil || err2 != nil {
-			return err
+	offset = cw.n
+	if _, err := io.WriteString(cw, opts.indent(opts.depth)+"}"); err != nil {
+		return writeError(what, offset, err)
+	}
+	return nil
+}
+
+// writeElement writes an element of a graph body on its own line
+func writeElement(cw *countingWriter, elem Element, indent string, opts WriteOptions) error {
+	offset := cw.n
+	var err error
+	if lit, ok := elem.(*Literal); !ok || lit.Line != "" {
+		_, err = io.WriteString(cw, indent)
+	}
+	if err == nil {
+		if ow, ok := elem.(optionWriter); ok {
+			err = ow.writeWithOptions(cw, opts)
+		} else {
+			err = elem.Write(cw)
 		}
+	}
+	if err == nil {
+		_, err = io.WriteString(cw, "\n")
+	}
+	switch {
+	case err == nil:
+		return nil
+	case opts.ctx != nil && err == opts.ctx.Err():
+		// cancellation of a nested subgraph
+		return err
+	}
+	return writeError(describeElement(elem), offset, err)
+}
+
+// WriteTo implements io.WriterTo by writing the graph like Write and
+// returning the number of bytes written
+func (graph *Graph) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := graph.WriteWithOptions(cw, WriteOptions{})
+	return cw.n, err
+}
 
+// String returns the dot-file representation of the graph.  If the graph
+// cannot be written the returned string describes the error instead.
+func (graph *Graph) String() string {
+	text, err := graph.MarshalText()
+	if err != nil {
+		return fmt.Sprintf("%%!dot(error=%s)", err)
 	}
+	return string(text)
+}
 
-	_, err = io.WriteString(w, "}")
-	return err
+// MarshalText implements encoding.TextMarshaler by returning the dot-file
+// representation of the graph
+func (graph *Graph) MarshalText() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := graph.Write(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeWithOptions writes the graph as a subgraph nested in the body of
+// another graph
+func (graph *Graph) writeWithOptions(w io.Writer, opts WriteOptions) error {
+	opts.depth++
+	opts.idWidth = 0
+	return graph.WriteWithOptions(w, opts)
+}
+
+// attributes returns the attributes set on the graph, fields first in the
+// order of graphFields followed by Attrs
+func (graph *Graph) attributes() []attribute {
+	var attrs []attribute
+	for _, field := range graphFields {
+		if _, ok := graph.Attrs[field.name]; ok {
+			continue
+		}
+		if value := field.get(graph); value != "" {
+			attrs = append(attrs, attribute{field.name, value})
+		}
+	}
+	return appendAttrs(attrs, graph.Attrs)
+}
+
+// graphField binds a graphviz attribute to a Graph field.  get returns the
+// empty string for a field left at its zero value.
+type graphField struct {
+	name string
+	get  func(graph *Graph) string
+	set  func(graph *Graph, value string) error
+}
+
+// graphStringField returns a graphField for a string field
+func graphStringField(name string, field func(graph *Graph) *string) graphField {
+	return graphField{
+		name: name,
+		get: func(graph *Graph) string {
+			return *field(graph)
+		},
+		set: func(graph *Graph, value string) error {
+			*field(graph) = value
+			return nil
+		},
+	}
+}
+
+// graphBoolField returns a graphField for a bool field, written only when
+// true
+func graphBoolField(name string, field func(graph *Graph) *bool) graphField {
+	return graphField{
+		name: name,
+		get: func(graph *Graph) string {
+			if !*field(graph) {
+				return ""
+			}
+			return "true"
+		},
+		set: func(graph *Graph, value string) error {
+			b, err := parseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for attribute %s", value, name)
+			}
+			*field(graph) = b
+			return nil
+		},
+	}
+}
+
+// graphIntField returns a graphField for an int field
+func graphIntField(name string, field func(graph *Graph) *int) graphField {
+	return graphField{
+		name: name,
+		get: func(graph *Graph) string {
+			if *field(graph) == 0 {
+				return ""
+			}
+			return strconv.Itoa(*field(graph))
+		},
+		set: func(graph *Graph, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for attribute %s", value, name)
+			}
+			*field(graph) = n
+			return nil
+		},
+	}
+}
+
+// graphFloatField returns a graphField for a float64 field
+func graphFloatField(name string, field func(graph *Graph) *float64) graphField {
+	return graphField{
+		name: name,
+		get: func(graph *Graph) string {
+			if *field(graph) == 0 {
+				return ""
+			}
+			return formatFloat(*field(graph))
+		},
+		set: func(graph *Graph, value string) error {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for attribute %s", value, name)
+			}
+			*field(graph) = f
+			return nil
+		},
+	}
+}
+
+// graphMarginField returns a graphField for a *Margin field, where nil
+// leaves the attribute unset
+func graphMarginField(name string, field func(graph *Graph) **Margin) graphField {
+	return graphField{
+		name: name,
+		get: func(graph *Graph) string {
+			if *field(graph) == nil {
+				return ""
+			}
+			return (*field(graph)).String()
+		},
+		set: func(graph *Graph, value string) error {
+			m, err := parseMargin(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for attribute %s", value, name)
+			}
+			*field(graph) = m
+			return nil
+		},
+	}
+}
+
+// graphFields lists the Graph fields in the order their attributes are
+// written
+var graphFields = []graphField{
+	graphStringField("rank", func(graph *Graph) *string { return &graph.Rank }),
+	graphStringField("rankdir", func(graph *Graph) *string { return &graph.RankDir }),
+	graphStringField("label", func(graph *Graph) *string { return &graph.Label }),
+	graphStringField("style", func(graph *Graph) *string { return &graph.Style }),
+	graphStringField("color", func(graph *Graph) *string { return &graph.Color }),
+	graphStringField("bgcolor", func(graph *Graph) *string { return &graph.BgColor }),
+	graphBoolField("compound", func(graph *Graph) *bool { return &graph.Compound }),
+	graphStringField("fillcolor", func(graph *Graph) *string { return &graph.FillColor }),
+	graphIntField("gradientangle", func(graph *Graph) *int { return &graph.GradientAngle }),
+	graphFloatField("penwidth", func(graph *Graph) *float64 { return &graph.PenWidth }),
+	graphFloatField("fontsize", func(graph *Graph) *float64 { return &graph.FontSize }),
+	graphStringField("layout", func(graph *Graph) *string { return &graph.Layout }),
+	graphFloatField("nodesep", func(graph *Graph) *float64 { return &graph.NodeSep }),
+	{
+		name: "ranksep",
+		get: func(graph *Graph) string {
+			var parts []string
+			if graph.RankSep != 0 {
+				parts = append(parts, formatFloat(graph.RankSep))
+			}
+			if graph.RankSepEqually {
+				parts = append(parts, "equally")
+			}
+			return strings.Join(parts, " ")
+		},
+		set: func(graph *Graph, value string) error {
+			graph.RankSep, graph.RankSepEqually = 0, false
+			for _, part := range strings.Fields(value) {
+				if part == "equally" {
+					graph.RankSepEqually = true
+					continue
+				}
+				f, err := strconv.ParseFloat(part, 64)
+				if err != nil {
+					return fmt.Errorf("invalid value %q for attribute ranksep", value)
+				}
+				graph.RankSep = f
+			}
+			return nil
+		},
+	},
+	graphMarginField("margin", func(graph *Graph) **Margin { return &graph.Margin }),
+	graphMarginField("pad", func(graph *Graph) **Margin { return &graph.Pad }),
+	graphBoolField("concentrate", func(graph *Graph) *bool { return &graph.Concentrate }),
+	graphStringField("splines", func(graph *Graph) *string { return (*string)(&graph.Splines) }),
+	graphStringField("labelloc", func(graph *Graph) *string { return &graph.LabelLoc }),
+	graphStringField("labeljust", func(graph *Graph) *string { return &graph.LabelJust }),
+	graphStringField("fontname", func(graph *Graph) *string { return &graph.FontName }),
+	graphStringField("fontcolor", func(graph *Graph) *string { return &graph.FontColor }),
+	graphBoolField("newrank", func(graph *Graph) *bool { return &graph.NewRank }),
+	graphStringField("clusterrank", func(graph *Graph) *string { return &graph.ClusterRank }),
+	{
+		name: "size",
+		get: func(graph *Graph) string {
+			if graph.Size == nil {
+				return ""
+			}
+			return graph.Size.String()
+		},
+		set: func(graph *Graph, value string) error {
+			size, err := parseSize(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for attribute size", value)
+			}
+			graph.Size = size
+			return nil
+		},
+	},
+	graphStringField("ratio", func(graph *Graph) *string { return &graph.Ratio }),
+	graphIntField("rotate", func(graph *Graph) *int { return &graph.Rotate }),
+	graphFloatField("dpi", func(graph *Graph) *float64 { return &graph.DPI }),
+	graphStringField("ordering", func(graph *Graph) *string { return &graph.Ordering }),
+	graphStringField("class", func(graph *Graph) *string { return &graph.Class }),
+	graphStringField("id", func(graph *Graph) *string { return &graph.SVGID }),
+	{
+		name: "layers",
+		get: func(graph *Graph) string {
+			return strings.Join(graph.Layers, ":")
+		},
+		set: func(graph *Graph, value string) error {
+			graph.Layers = parseLayers(value)
+			return nil
+		},
+	},
+	graphStringField("layerselect", func(graph *Graph) *string { return &graph.LayerSelect }),
+	graphStringField("layer", func(graph *Graph) *string { return &graph.Layer }),
+}
+
+// graphFieldIndex maps attribute names to their entry in graphFields
+var graphFieldIndex = make(map[string]*graphField)
+
+func init() {
+	for i := range graphFields {
+		graphFieldIndex[graphFields[i].name] = &graphFields[i]
+	}
+}
+
+// checkUndirected returns an error if the graph or any of its subgraphs
+// contain a directed edge
+func (graph *Graph) checkUndirected() error {
+	for _, elem := range graph.Body {
+		switch e := elem.(type) {
+		case *EdgeDescription:
+			if e.Directed {
+				return fmt.Errorf("directed edge %s -> %s in undirected graph %s", e.From.ID, e.To.ID, graph.Name)
+			}
+		case *EdgeChain:
+			if e.Directed && len(e.Vertices) > 0 {
+				return fmt.Errorf("directed edge chain from %s in undirected graph %s", e.Vertices[0].ID, graph.Name)
+			}
+		case *Graph:
+			if err := e.checkUndirected(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
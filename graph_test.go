@@ -3,19 +3,19 @@ package dot
 import (
 	"bytes"
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 )
 
 var emptyGraph = `digraph testGraph {
-
-
- }`
+}`
 
 func TestMakeWriteEmptyGraph(t *testing.T) {
 	buf := new(bytes.Buffer)
 	g := NewGraph("testGraph")
-	err := g.WriteDot(buf)
+	err := g.Write(buf)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -25,23 +25,17 @@ func TestMakeWriteEmptyGraph(t *testing.T) {
 	}
 }
 
-var edgeGraph = `digraph testGraph {
-
-vTo -> vFrom
-
- }`
-
 func ExampleGraph_AddEdge() {
 	buf := new(bytes.Buffer)
 	g := NewGraph("testGraph")
-	vTo := &VertexDescription {
+	vTo := &VertexDescription{
 		ID: "vTo",
 	}
-	vFrom := &VertexDescription {
+	vFrom := &VertexDescription{
 		ID: "vFrom",
 	}
-	g.AddEdge(vTo, vFrom, true)
-	g.WriteDot(buf)
+	g.AddEdge(vTo, vFrom, true, "")
+	g.Write(buf)
 
 	s := buf.String()
 	lines := strings.Split(s, "\n")
@@ -49,90 +43,107 @@ func ExampleGraph_AddEdge() {
 	if len(lines) < 3 {
 		return
 	}
-	fmt.Println(lines[2])
+	fmt.Println(lines[1])
 	// Output:
-	// 5
-	// vTo -> vFrom 
+	// 3
+	// vTo -> vFrom
 }
 
 var vertexGraph = `digraph testGraph {
-
-v [label="vertex"]
-
- }`
+v [label="vertex" ]
+}`
 
 func TestAddVertex(t *testing.T) {
 	buf := new(bytes.Buffer)
 	g := NewGraph("testGraph")
-	v := &VertexDescription {
-		ID: "v",
+	v := &VertexDescription{
+		ID:    "v",
 		Label: "vertex",
 	}
 	g.AddVertex(v)
-	err := g.WriteDot(buf)
-	if err != nil{
+	err := g.Write(buf)
+	if err != nil {
 		t.Fatal(err)
 	}
 	s := buf.String()
 	if s != vertexGraph {
-		t.Errorf("unexpected output: \n%s\n",s)
+		t.Errorf("unexpected output: \n%s\n", s)
 		t.Errorf("expected outpuf: \n%s", vertexGraph)
 	}
 }
 
 var commentGraph = `digraph testGraph {
-
 /* This is a comment */
-
- }`
+}`
 
 func TestAddComment(t *testing.T) {
 	buf := new(bytes.Buffer)
 	g := NewGraph("testGraph")
 	g.AddComment("This is a comment")
-	err := g.WriteDot(buf)
+	err := g.Write(buf)
 	if err != nil {
 		t.Fatal(err)
 	}
 	s := buf.String()
-	if s!= commentGraph {
-		t.Errorf("unexpeted output: \n%s\n",s)
+	if s != commentGraph {
+		t.Errorf("unexpeted output: \n%s\n", s)
 	}
 }
 
-var newlineGraph = `digraph testGraph {
+var safeCommentGraph = `digraph testGraph {
+/* foo * / bar */
+/*
+ * first line
+ *
+ * last * / line
+ */
+// line comment
+// with /* */ inside
+}`
 
+func TestAddCommentSafe(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.AddComment("foo */ bar")
+	g.AddComment("first line\r\n\nlast */ line")
+	g.AddCommentWithStyle("line comment\nwith /* */ inside", CommentLine)
+	if s := writeString(t, &g); s != safeCommentGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+	if _, err := Parse(strings.NewReader(safeCommentGraph)); err != nil {
+		t.Error(err)
+	}
+}
 
+var newlineGraph = `digraph testGraph {
 
- }`
+}`
 
 func TestAddNewLine(t *testing.T) {
 	buf := new(bytes.Buffer)
 	g := NewGraph("testGraph")
 	g.AddNewLine()
-	err := g.WriteDot(buf)
+	err := g.Write(buf)
 	if err != nil {
 		t.Fatal(err)
 	}
 	s := buf.String()
-	if s!= newlineGraph {
-		t.Errorf("unexpeted output: \n%s\n",s)
+	if s != newlineGraph {
+		t.Errorf("unexpeted output: \n%s\n", s)
 		t.Errorf("expected ouput: \n%s\n", newlineGraph)
 	}
 }
 
 var basicGraph = `digraph cluster {
-
 /* The nodes of the connectivity graph */
 /* The cluster-service peers */
-C0 [label="EhD" color="blue2"]
-C1 [label="DQJ" color="blue2"]
-C2 [label="mJu" color="blue2"]
+C0 [label="EhD" color="blue2" ]
+C1 [label="DQJ" color="blue2" ]
+C2 [label="mJu" color="blue2" ]
 
 /* The ipfs peers */
-I0 [label="Ssq" color="goldenrod"]
-I1 [label="ZDV" color="goldenrod"]
-I2 [label="suL" color="goldenrod"]
+I0 [label="Ssq" color="goldenrod" ]
+I1 [label="ZDV" color="goldenrod" ]
+I2 [label="suL" color="goldenrod" ]
 
 /* Edges representing active connections in the cluster */
 /* The connections among cluster-service peers */
@@ -155,9 +166,7 @@ I1 -> I0
 I1 -> I2
 I2 -> I0
 I2 -> I1
-
- }`
-
+}`
 
 func TestPrintBasicGraph(t *testing.T) {
 	buf := new(bytes.Buffer)
@@ -165,20 +174,20 @@ func TestPrintBasicGraph(t *testing.T) {
 	g.AddComment("The nodes of the connectivity graph")
 	g.AddComment("The cluster-service peers")
 	c0 := &VertexDescription{
-		ID: "C0",
+		ID:    "C0",
 		Label: "EhD",
 		Color: "blue2",
 	}
 	c1 := &VertexDescription{
-		ID: "C1",
+		ID:    "C1",
 		Label: "DQJ",
 		Color: "blue2",
 	}
 	c2 := &VertexDescription{
-		ID: "C2",
+		ID:    "C2",
 		Label: "mJu",
 		Color: "blue2",
-	}	
+	}
 	g.AddVertex(c0)
 	g.AddVertex(c1)
 	g.AddVertex(c2)
@@ -186,17 +195,17 @@ func TestPrintBasicGraph(t *testing.T) {
 
 	g.AddComment("The ipfs peers")
 	i0 := &VertexDescription{
-		ID: "I0",
+		ID:    "I0",
 		Label: "Ssq",
 		Color: "goldenrod",
 	}
 	i1 := &VertexDescription{
-		ID: "I1",
+		ID:    "I1",
 		Label: "ZDV",
 		Color: "goldenrod",
 	}
 	i2 := &VertexDescription{
-		ID: "I2",
+		ID:    "I2",
 		Label: "suL",
 		Color: "goldenrod",
 	}
@@ -207,29 +216,29 @@ func TestPrintBasicGraph(t *testing.T) {
 
 	g.AddComment("Edges representing active connections in the cluster")
 	g.AddComment("The connections among cluster-service peers")
-	g.AddEdge(c0, c1, true)
-	g.AddEdge(c0, c2, true)	
-	g.AddEdge(c1, c0, true)	
-	g.AddEdge(c1, c2, true)	
-	g.AddEdge(c2, c0, true)
-	g.AddEdge(c2, c1, true)
+	g.AddEdge(c0, c1, true, "")
+	g.AddEdge(c0, c2, true, "")
+	g.AddEdge(c1, c0, true, "")
+	g.AddEdge(c1, c2, true, "")
+	g.AddEdge(c2, c0, true, "")
+	g.AddEdge(c2, c1, true, "")
 	g.AddNewLine()
 
 	g.AddComment("The connections between cluster peers and their ipfs daemons")
-	g.AddEdge(c0, i1, true)
-	g.AddEdge(c1, i0, true)
-	g.AddEdge(c2, i2, true)
+	g.AddEdge(c0, i1, true, "")
+	g.AddEdge(c1, i0, true, "")
+	g.AddEdge(c2, i2, true, "")
 	g.AddNewLine()
 
 	g.AddComment("The swarm peer connections among ipfs daemons in the cluster")
-	g.AddEdge(i0, i1, true)
-	g.AddEdge(i0, i2, true)
-	g.AddEdge(i1, i0, true)		
-	g.AddEdge(i1, i2, true)
-	g.AddEdge(i2, i0, true)
-	g.AddEdge(i2, i1, true)
+	g.AddEdge(i0, i1, true, "")
+	g.AddEdge(i0, i2, true, "")
+	g.AddEdge(i1, i0, true, "")
+	g.AddEdge(i1, i2, true, "")
+	g.AddEdge(i2, i0, true, "")
+	g.AddEdge(i2, i1, true, "")
 
-	err := g.WriteDot(buf)
+	err := g.Write(buf)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -239,3 +248,1182 @@ func TestPrintBasicGraph(t *testing.T) {
 		t.Errorf("The expected output \n%s\n", basicGraph)
 	}
 }
+
+var attrsGraph = `digraph testGraph {
+v [label="vertex" fixedsize="true" tooltip="a vertex" ]
+}`
+
+func TestAddAttribute(t *testing.T) {
+	buf := new(bytes.Buffer)
+	g := NewGraph("testGraph")
+	v := &VertexDescription{
+		ID:    "v",
+		Label: "vertex",
+	}
+	v.AddAttribute("tooltip", "a vertex")
+	v.AddAttribute("fixedsize", "true")
+	g.AddVertex(v)
+	err := g.Write(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	if s != attrsGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", attrsGraph)
+	}
+}
+
+var rankDirGraph = `digraph testGraph {
+rankdir="LR"
+a -> b
+}`
+
+func TestRankDir(t *testing.T) {
+	buf := new(bytes.Buffer)
+	g := NewGraph("testGraph")
+	g.RankDir = RankDirLR
+	g.AddEdge(&VertexDescription{ID: "a"}, &VertexDescription{ID: "b"}, true, "")
+	err := g.Write(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	if s != rankDirGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", rankDirGraph)
+	}
+}
+
+func TestStrictGraph(t *testing.T) {
+	buf := new(bytes.Buffer)
+	g := NewGraph("testGraph")
+	g.IsStrict = true
+	sub := NewGraph("sub")
+	sub.IsSubGraph = true
+	sub.IsStrict = true
+	g.AddSubGraph(&sub)
+	err := g.Write(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "strict digraph testGraph {\nsubgraph sub {\n}\n}"
+	s := buf.String()
+	if s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", expected)
+	}
+}
+
+func TestUndirectedGraph(t *testing.T) {
+	buf := new(bytes.Buffer)
+	g := NewUndirectedGraph("testGraph")
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	g.AddEdge(a, b, false, "")
+	err := g.Write(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "graph testGraph {\na -- b\n}"
+	s := buf.String()
+	if s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", expected)
+	}
+
+	sub := NewGraph("sub")
+	sub.IsSubGraph = true
+	sub.AddEdge(b, a, true, "")
+	g.AddSubGraph(&sub)
+	err = g.Write(new(bytes.Buffer))
+	if err == nil {
+		t.Error("expected error writing directed edge in undirected graph")
+	}
+}
+
+var quotedGraph = `digraph "test graph" {
+"peer-1" [label="first" ]
+"peer-1" -> "node"
+}`
+
+func TestQuotedIDs(t *testing.T) {
+	buf := new(bytes.Buffer)
+	g := NewGraph("test graph")
+	v1 := &VertexDescription{ID: "peer-1", Label: "first"}
+	v2 := &VertexDescription{ID: "node"}
+	g.AddVertex(v1)
+	g.AddEdge(v1, v2, true, "")
+	err := g.Write(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	if s != quotedGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", quotedGraph)
+	}
+}
+
+func TestEdgePorts(t *testing.T) {
+	tests := []struct {
+		edge     EdgeDescription
+		expected string
+	}{
+		{EdgeDescription{FromPort: "out", ToPort: "in"}, "a:out -> b:in"},
+		{EdgeDescription{FromPort: "out", FromCompass: CompassSE, ToCompass: CompassN}, "a:out:se -> b:n"},
+		{EdgeDescription{ToPort: "port 1", ToCompass: CompassW}, `a -> b:"port 1":w`},
+	}
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		e := test.edge
+		e.From = NewVertexDescription("a")
+		e.To = NewVertexDescription("b")
+		e.Directed = true
+		err := e.Write(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("unexpected output %s, expected %s", buf.String(), test.expected)
+		}
+	}
+}
+
+var clusterGraph = `digraph testGraph {
+subgraph cluster_peers {
+label="Peers"
+style="filled"
+color="blue"
+bgcolor="lightgrey"
+penwidth="2"
+a []
+}
+}`
+
+func TestCluster(t *testing.T) {
+	buf := new(bytes.Buffer)
+	g := NewGraph("testGraph")
+	c := NewCluster("peers")
+	c.Label = "Peers"
+	c.Style = "filled"
+	c.Color = "blue"
+	c.BgColor = "lightgrey"
+	c.AddAttribute("penwidth", "2")
+	c.AddVertex(&VertexDescription{ID: "a"})
+	g.AddSubGraph(&c)
+	if !c.IsCluster() || g.IsCluster() {
+		t.Error("unexpected IsCluster result")
+	}
+	err := g.Write(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	if s != clusterGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", clusterGraph)
+	}
+}
+
+var defaultsGraph = `digraph testGraph {
+node [fontname="Helvetica" shape="box" ]
+edge [ style="dashed" color="gray" ]
+a [label="A" ]
+}`
+
+func TestDefaults(t *testing.T) {
+	buf := new(bytes.Buffer)
+	g := NewGraph("testGraph")
+	g.SetNodeDefaults(VertexDescription{Shape: "box", FontName: "Helvetica"})
+	e := EdgeDescription{Style: "dashed"}
+	e.AddAttribute("color", "gray")
+	g.SetEdgeDefaults(e)
+	g.AddVertex(&VertexDescription{ID: "a", Label: "A"})
+	err := g.Write(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	if s != defaultsGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", defaultsGraph)
+	}
+}
+
+func TestAddSameRank(t *testing.T) {
+	buf := new(bytes.Buffer)
+	g := NewGraph("testGraph")
+	g.AddSameRank(&VertexDescription{ID: "a"}, &VertexDescription{ID: "b-1"}, &VertexDescription{ID: "c"})
+	err := g.Write(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "digraph testGraph {\n{ rank=same; a; \"b-1\"; c; }\n}"
+	s := buf.String()
+	if s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", expected)
+	}
+}
+
+func TestAddEdgeChain(t *testing.T) {
+	buf := new(bytes.Buffer)
+	g := NewGraph("testGraph")
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	c := &VertexDescription{ID: "c"}
+	g.AddEdgeChain(true, "bold", a, b, c)
+	err := g.Write(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "digraph testGraph {\na -> b -> c [ style=\"bold\" ]\n}"
+	s := buf.String()
+	if s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", expected)
+	}
+
+	edges := g.Body[0].(*EdgeChain).Edges()
+	if len(edges) != 2 || edges[1].From.ID != "b" || edges[1].To.ID != "c" {
+		t.Errorf("unexpected edges %+v", edges)
+	}
+
+	g = NewGraph("testGraph")
+	g.AddEdgeChain(true, "", a)
+	if err := g.Write(new(bytes.Buffer)); err == nil {
+		t.Error("expected error writing chain of one vertex")
+	}
+}
+
+func TestGraphString(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.AddVertex(&VertexDescription{ID: "v", Label: "vertex"})
+	if s := g.String(); s != vertexGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+	if s := fmt.Sprintf("%v", &g); s != vertexGraph {
+		t.Errorf("unexpected formatted output: \n%s\n", s)
+	}
+	text, err := g.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != vertexGraph {
+		t.Errorf("unexpected text: \n%s\n", text)
+	}
+
+	g = NewUndirectedGraph("testGraph")
+	g.AddEdge(&VertexDescription{ID: "a"}, &VertexDescription{ID: "b"}, true, "")
+	if _, err := g.MarshalText(); err == nil {
+		t.Error("expected error marshalling invalid graph")
+	}
+	if s := g.String(); !strings.HasPrefix(s, "%!dot(error=") {
+		t.Errorf("unexpected output for invalid graph: %s", s)
+	}
+}
+
+func TestVertexAttributeOrder(t *testing.T) {
+	expected := []string{"label", "group", "color", "style", "colorscheme", "fontcolor", "fontname", "shape", "peripheries", "URL", "target", "tooltip", "fillcolor", "gradientangle", "penwidth", "fontsize", "width", "height", "fixedsize", "image", "imagescale", "imagepos", "xlabel", "pos", "ordering", "class", "id", "layer"}
+	if order := VertexAttributeOrder(); !reflect.DeepEqual(order, expected) {
+		t.Errorf("unexpected order %v", order)
+	}
+
+	// every field other than ID and Attrs must have an attribute
+	typ := reflect.TypeOf(VertexDescription{})
+	fields := 0
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Name
+		if name == "ID" || name == "Attrs" || name == "Comment" {
+			continue
+		}
+		// SVGID is written as id, since ID is the ID of the vertex
+		name = strings.TrimPrefix(name, "SVG")
+		fields++
+		found := false
+		for _, field := range vertexFields {
+			found = found || strings.EqualFold(field.name, name)
+		}
+		if !found {
+			t.Errorf("field %s has no attribute", name)
+		}
+	}
+	if fields != len(vertexFields) {
+		t.Errorf("%d fields but %d attributes", fields, len(vertexFields))
+	}
+}
+
+func BenchmarkVertexWrite(b *testing.B) {
+	v := &VertexDescription{
+		ID:          "vertex",
+		Label:       "Vertex",
+		Color:       "blue",
+		Shape:       "box",
+		Peripheries: 2,
+	}
+	v.AddAttribute("tooltip", "a vertex")
+	buf := new(bytes.Buffer)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := v.Write(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestVertexLinks(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.AddVertex(&VertexDescription{
+		ID:      "a",
+		Label:   "A",
+		URL:     "https://example.com/a",
+		Target:  "_blank",
+		Tooltip: "Dashboard of a",
+	})
+	expected := `digraph testGraph {
+a [label="A" URL="https://example.com/a" target="_blank" tooltip="Dashboard of a" ]
+}`
+	buf := new(bytes.Buffer)
+	if err := g.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := parsed.FindVertex("a"); v.URL != "https://example.com/a" || v.Target != "_blank" || len(v.Attrs) != 0 {
+		t.Errorf("unexpected parsed vertex %+v", v)
+	}
+}
+
+func TestEdgeLabel(t *testing.T) {
+	g := NewGraph("testGraph")
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	g.AddEdge(a, b, true, "dashed")
+	e := g.Body[0].(*EdgeDescription)
+	e.Label = `12ms "p99"\nover \"wan\"`
+
+	expected := `digraph testGraph {
+a -> b [ label="12ms \"p99\"\nover \"wan\"" style="dashed" ]
+}`
+	buf := new(bytes.Buffer)
+	if err := g.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pe := parsed.Body[0].(*EdgeDescription)
+	if pe.Label != `12ms \"p99\"\nover \"wan\"` || len(pe.Attrs) != 0 {
+		t.Errorf("unexpected parsed edge %+v", pe)
+	}
+}
+
+func TestEscapeQuotes(t *testing.T) {
+	tests := map[string]string{
+		`plain`:      `plain`,
+		`say "hi"`:   `say \"hi\"`,
+		`say \"hi\"`: `say \"hi\"`,
+		`a\\"b`:      `a\\\"b`,
+		`left\l`:     `left\l`,
+		`trailing\`:  `trailing\\`,
+	}
+	for value, expected := range tests {
+		if s := escapeQuotes(value); s != expected {
+			t.Errorf("escapeQuotes(%s) = %s, expected %s", value, s, expected)
+		}
+	}
+}
+
+func TestEdgeEndLabels(t *testing.T) {
+	g := NewGraph("testGraph")
+	order := &VertexDescription{ID: "Order"}
+	item := &VertexDescription{ID: "Item"}
+	g.AddEdge(order, item, true, "")
+	e := g.Body[0].(*EdgeDescription)
+	e.HeadLabel = "0..*"
+	e.TailLabel = "1"
+	e.LabelDistance = 1.5
+	e.LabelAngle = -30
+
+	expected := `digraph testGraph {
+Order -> Item [ headlabel="0..*" taillabel="1" labeldistance="1.5" labelangle="-30" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pe := parsed.Body[0].(*EdgeDescription)
+	if pe.HeadLabel != "0..*" || pe.TailLabel != "1" || pe.LabelDistance != 1.5 ||
+		pe.LabelAngle != -30 || len(pe.Attrs) != 0 {
+		t.Errorf("unexpected parsed edge %+v", pe)
+	}
+
+	parsed, err = Parse(strings.NewReader(`digraph { a -> b [labelangle="left"] }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pe := parsed.Body[0].(*EdgeDescription); pe.LabelAngle != 0 || pe.Attrs["labelangle"] != "left" {
+		t.Errorf("non-numeric labelangle not kept in Attrs: %+v", pe)
+	}
+}
+
+func TestEdgeLayoutFields(t *testing.T) {
+	g := NewGraph("testGraph")
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	c := &VertexDescription{ID: "c"}
+	g.AddEdge(a, b, true, "")
+	g.AddEdge(a, c, true, "")
+	ab := g.Body[0].(*EdgeDescription)
+	ab.Weight = 10
+	ab.MinLen = 2
+	ab.Constraint = Bool(true)
+	g.Body[1].(*EdgeDescription).Constraint = Bool(false)
+
+	expected := `digraph testGraph {
+a -> b [ weight="10" constraint="true" minlen="2" ]
+a -> c [ constraint="false" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(`digraph { a -> b [weight=2.5 minlen=3] a -> c [constraint=no] a -> d }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	edges := []*EdgeDescription{}
+	for _, elem := range parsed.Body {
+		if e, ok := elem.(*EdgeDescription); ok {
+			edges = append(edges, e)
+		}
+	}
+	if len(edges) != 3 {
+		t.Fatalf("expected 3 edges, found %d", len(edges))
+	}
+	if edges[0].Weight != 2.5 || edges[0].MinLen != 3 || edges[0].Constraint != nil {
+		t.Errorf("unexpected parsed edge %+v", edges[0])
+	}
+	if edges[1].Constraint == nil || *edges[1].Constraint {
+		t.Errorf("expected constraint=false, found %+v", edges[1])
+	}
+	if edges[2].Constraint != nil {
+		t.Errorf("expected no constraint, found %+v", edges[2])
+	}
+}
+
+func TestAddClusterEdge(t *testing.T) {
+	g := NewGraph("testGraph")
+	front := NewCluster("front")
+	front.AddVertex(&VertexDescription{ID: "web"})
+	front.AddVertex(&VertexDescription{ID: "api"})
+	back := NewCluster("back")
+	back.AddVertex(&VertexDescription{ID: "db"})
+	g.AddSubGraph(&front)
+	g.AddSubGraph(&back)
+	if err := g.AddClusterEdge(&front, &back, true, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `digraph testGraph {
+compound="true"
+subgraph cluster_front {
+web []
+api []
+}
+subgraph cluster_back {
+db []
+}
+web -> db [ lhead="cluster_back" ltail="cluster_front" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Compound {
+		t.Error("compound not parsed")
+	}
+
+	empty := NewCluster("empty")
+	if err := g.AddClusterEdge(&front, &empty, true, ""); err == nil {
+		t.Error("expected an error for a cluster without vertices")
+	}
+	plain := Graph{Name: "plain", IsSubGraph: true}
+	plain.AddVertex(&VertexDescription{ID: "x"})
+	if err := g.AddClusterEdge(&plain, &back, true, ""); err == nil {
+		t.Error("expected an error for a subgraph that is not a cluster")
+	}
+}
+
+func TestColorFills(t *testing.T) {
+	g := NewGraph("testGraph")
+	c := NewCluster("load")
+	c.Style = "filled"
+	c.FillColor = string(Gradient("white", "lightblue"))
+	c.GradientAngle = 90
+	a := &VertexDescription{ID: "a", Style: "radial", FillColor: string(Gradient("yellow", "orange"))}
+	b := &VertexDescription{ID: "b"}
+	c.AddVertex(a)
+	c.AddVertex(b)
+	g.AddSubGraph(&c)
+	g.AddEdge(a, b, true, "")
+	e := g.Body[1].(*EdgeDescription)
+	e.Color = string(ColorList(ColorStop{"red", 0.7}, ColorStop{Color: "green"}))
+
+	expected := `digraph testGraph {
+subgraph cluster_load {
+style="filled"
+fillcolor="white:lightblue"
+gradientangle="90"
+a [style="radial" fillcolor="yellow:orange" ]
+b []
+}
+a -> b [ color="red;0.7:green" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+	if err := g.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := parsed.Body[0].(*Graph)
+	pe := parsed.Body[1].(*EdgeDescription)
+	if sub.FillColor != "white:lightblue" || sub.GradientAngle != 90 || pe.Color != "red;0.7:green" {
+		t.Errorf("unexpected parsed graph %+v", parsed)
+	}
+}
+
+func TestFloatAttributes(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.FontSize = 18
+	a := &VertexDescription{ID: "a", PenWidth: 0.1 + 0.2, FontSize: 10.5, Width: 1.25, Height: 0.5}
+	b := &VertexDescription{ID: "b"}
+	g.AddVertex(a)
+	g.AddEdge(a, b, true, "")
+	e := g.Body[1].(*EdgeDescription)
+	e.PenWidth = 2
+
+	expected := `digraph testGraph {
+fontsize="18"
+a [penwidth="0.3" fontsize="10.5" width="1.25" height="0.5" ]
+a -> b [ penwidth="2" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := parsed.Body[0].(*VertexDescription)
+	if parsed.FontSize != 18 || v.FontSize != 10.5 || v.Width != 1.25 || len(v.Attrs) != 0 {
+		t.Errorf("unexpected parsed graph %+v", parsed)
+	}
+	parsed, err = Parse(strings.NewReader(`digraph { a [width=wide] }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := parsed.Body[0].(*VertexDescription); v.Width != 0 || v.Attrs["width"] != "wide" {
+		t.Errorf("non-numeric width not kept in Attrs: %+v", v)
+	}
+}
+
+func TestFixedSize(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.NodeDefaults = &VertexDescription{Shape: ShapeCircle}
+	g.NodeDefaults.SetSize(0.5, 0.5)
+	g.AddVertex(&VertexDescription{ID: "peer", Label: "a long peer name", FixedSize: FixedSizeShape})
+
+	expected := `digraph testGraph {
+node [shape="circle" width="0.5" height="0.5" fixedsize="true" ]
+peer [label="a long peer name" fixedsize="shape" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := parsed.NodeDefaults; d.FixedSize != FixedSizeTrue || d.Width != 0.5 || d.Height != 0.5 {
+		t.Errorf("unexpected parsed node defaults %+v", d)
+	}
+}
+
+func TestXLabel(t *testing.T) {
+	g := NewGraph("testGraph")
+	a := &VertexDescription{ID: "a", XLabel: "cpu 93%"}
+	b := &VertexDescription{ID: "b"}
+	g.AddVertex(a)
+	g.AddEdge(a, b, true, "")
+	g.Body[1].(*EdgeDescription).XLabel = "12 MB/s"
+
+	expected := `digraph testGraph {
+a [xlabel="cpu 93%" ]
+a -> b [ xlabel="12 MB/s" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := parsed.Body[0].(*VertexDescription)
+	e := parsed.Body[1].(*EdgeDescription)
+	if v.XLabel != "cpu 93%" || e.XLabel != "12 MB/s" || len(v.Attrs)+len(e.Attrs) != 0 {
+		t.Errorf("unexpected parsed graph %+v", parsed)
+	}
+}
+
+func TestGraphSpacing(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.NodeSep = 0.3
+	g.RankSep = 1.2
+	g.RankSepEqually = true
+	g.Margin = &Margin{X: 0, Y: 0}
+	g.Pad = &Margin{X: 0.5, Y: 0.25}
+
+	expected := `digraph testGraph {
+nodesep="0.3"
+ranksep="1.2 equally"
+margin="0"
+pad="0.5,0.25"
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.NodeSep != 0.3 || parsed.RankSep != 1.2 || !parsed.RankSepEqually ||
+		parsed.Margin == nil || *parsed.Margin != (Margin{}) ||
+		parsed.Pad == nil || *parsed.Pad != (Margin{0.5, 0.25}) {
+		t.Errorf("unexpected parsed graph %+v", parsed)
+	}
+
+	parsed, err = Parse(strings.NewReader(`digraph { ranksep=equally }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.RankSep != 0 || !parsed.RankSepEqually {
+		t.Errorf("unexpected parsed graph %+v", parsed)
+	}
+	parsed, err = Parse(strings.NewReader(`digraph { pad="wide" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Pad != nil || parsed.Attrs["pad"] != "wide" {
+		t.Errorf("invalid pad not kept in Attrs: %+v", parsed)
+	}
+}
+
+func TestEdgeRouting(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.Concentrate = true
+	g.Splines = SplinesOrtho
+
+	expected := `digraph testGraph {
+concentrate="true"
+splines="ortho"
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Concentrate || parsed.Splines != SplinesOrtho {
+		t.Errorf("unexpected parsed graph %+v", parsed)
+	}
+}
+
+func TestVertexOptions(t *testing.T) {
+	g := NewGraph("testGraph")
+	a := NewVertexDescription("a",
+		WithLabel("A"),
+		WithShape(ShapeBox),
+		WithStyle("rounded"),
+		WithFillColor(RGB(0xee, 0xee, 0xee)),
+		WithColor("navy"),
+		WithAttribute("sides", "5"),
+	)
+	b := NewVertexDescription("b", WithFillColor("pink"), WithGroup("g"), WithTooltip("b"))
+	g.AddVertex(&a)
+	g.AddVertex(&b)
+
+	expected := `digraph testGraph {
+a [label="A" color="navy" style="rounded,filled" shape="box" fillcolor="#eeeeee" sides="5" ]
+b [group="g" style="filled" tooltip="b" fillcolor="pink" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+	if v := NewVertexDescription("c"); !reflect.DeepEqual(v, VertexDescription{ID: "c"}) {
+		t.Errorf("unexpected vertex %+v", v)
+	}
+}
+
+var zeroValueGraph = `digraph testGraph {
+subgraph cluster_borderless {
+peripheries="0"
+a [shape="box" label="" ]
+}
+a -> a [ labelangle="0" ]
+}`
+
+func TestZeroValueAttributes(t *testing.T) {
+	g := NewGraph("testGraph")
+	c := NewCluster("borderless")
+	c.AddAttribute("peripheries", "0")
+	a := &VertexDescription{ID: "a", Label: "ignored", Shape: ShapeBox}
+	a.AddAttribute("label", "")
+	c.AddVertex(a)
+	g.AddSubGraph(&c)
+	g.AddEdge(a, a, true, "")
+	g.Body[1].(*EdgeDescription).AddAttribute("labelangle", "0")
+	if s := writeString(t, &g); s != zeroValueGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(zeroValueGraph))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := writeString(t, parsed); s != zeroValueGraph {
+		t.Errorf("zero values lost: \n%s\n", s)
+	}
+
+	// a later non-zero value replaces the zero value
+	parsed, err = Parse(strings.NewReader(`digraph { a [peripheries=0 peripheries=2] }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := parsed.Body[0].(*VertexDescription); v.Peripheries != 2 || len(v.Attrs) != 0 {
+		t.Errorf("unexpected parsed vertex %+v", v)
+	}
+}
+
+var titledGraph = `digraph testGraph {
+label="Cluster topology"
+fontsize="20"
+labelloc="t"
+labeljust="l"
+fontname="Helvetica"
+fontcolor="gray20"
+subgraph cluster_eu {
+label="eu-west"
+fontsize="12"
+labeljust="r"
+}
+}`
+
+func TestGraphLabel(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.Label = "Cluster topology"
+	g.LabelLoc = LabelLocTop
+	g.LabelJust = LabelJustLeft
+	g.FontName = "Helvetica"
+	g.FontColor = "gray20"
+	g.FontSize = 20
+	c := NewCluster("eu")
+	c.Label = "eu-west"
+	c.LabelJust = LabelJustRight
+	c.FontSize = 12
+	g.AddSubGraph(&c)
+	if s := writeString(t, &g); s != titledGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(titledGraph))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := writeString(t, parsed); s != titledGraph || len(parsed.Attrs) != 0 {
+		t.Errorf("unexpected parsed graph: \n%s\n", s)
+	}
+}
+
+var tintedGraph = `digraph testGraph {
+bgcolor="white"
+subgraph cluster_prod {
+bgcolor="#ffe0e0:white"
+gradientangle="270"
+}
+subgraph cluster_staging {
+bgcolor="lightyellow"
+}
+}`
+
+func TestSetBackground(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.SetBackground("white", "white", 90)
+	prod := NewCluster("prod")
+	prod.SetBackground(RGB(0xff, 0xe0, 0xe0), "white", 270)
+	staging := NewCluster("staging")
+	staging.SetBackground("lightyellow", "lightyellow", 0)
+	g.AddSubGraph(&prod)
+	g.AddSubGraph(&staging)
+	if s := writeString(t, &g); s != tintedGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+	if err := g.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	staging.BgColor = "lightyelow:white"
+	if err := g.Validate(); err == nil || err.Error() != `graph cluster_staging: color list "lightyelow:white": unknown color name "lightyelow"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+var newRankGraph = `digraph testGraph {
+newrank="true"
+clusterrank="global"
+subgraph cluster_a {
+a []
+}
+subgraph cluster_b {
+b []
+}
+{ rank=same; a; b; }
+}`
+
+func TestNewRank(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.NewRank = true
+	g.ClusterRank = ClusterRankGlobal
+	ca := NewCluster("a")
+	a := &VertexDescription{ID: "a"}
+	ca.AddVertex(a)
+	cb := NewCluster("b")
+	b := &VertexDescription{ID: "b"}
+	cb.AddVertex(b)
+	g.AddSubGraph(&ca)
+	g.AddSubGraph(&cb)
+	g.AddSameRank(a, b)
+	if s := writeString(t, &g); s != newRankGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(newRankGraph))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.NewRank || parsed.ClusterRank != ClusterRankGlobal {
+		t.Errorf("unexpected parsed graph %+v", parsed)
+	}
+}
+
+func TestOutputSize(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.Size = &Size{Width: 11.7, Height: 8.3, Fill: true}
+	g.Ratio = RatioFill
+	g.Rotate = 90
+	g.DPI = 300
+
+	expected := `digraph testGraph {
+size="11.7,8.3!"
+ratio="fill"
+rotate="90"
+dpi="300"
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := writeString(t, parsed); s != expected || len(parsed.Attrs) != 0 {
+		t.Errorf("unexpected parsed graph: \n%s\n", s)
+	}
+
+	parsed, err = Parse(strings.NewReader(`digraph { size=7 ratio=0.5 }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *parsed.Size != (Size{Width: 7, Height: 7}) || parsed.Ratio != "0.5" {
+		t.Errorf("unexpected parsed graph %+v", parsed)
+	}
+}
+
+func TestOrdering(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.Ordering = OrderingOut
+	root := &VertexDescription{ID: "root", Ordering: OrderingIn}
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	g.AddVertex(root)
+	g.AddEdge(a, root, true, "")
+	g.AddEdge(b, root, true, "")
+	for _, elem := range g.Body {
+		if e, ok := elem.(*EdgeDescription); ok {
+			e.SameHead = "children"
+			e.SameTail = "parent"
+		}
+	}
+
+	expected := `digraph testGraph {
+ordering="out"
+root [ordering="in" ]
+a -> root [ samehead="children" sametail="parent" ]
+b -> root [ samehead="children" sametail="parent" ]
+}`
+	s := writeString(t, &g)
+	if s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps := writeString(t, parsed); ps != expected {
+		t.Errorf("unexpected parsed graph: \n%s\n", ps)
+	}
+}
+
+func TestInvisible(t *testing.T) {
+	g := NewGraph("testGraph")
+	a := NewVertexDescription("a")
+	spacer := NewInvisibleVertex("spacer")
+	g.AddVertex(&a)
+	g.AddVertex(&spacer)
+	g.AddInvisibleEdge(&a, &spacer)
+
+	expected := `digraph testGraph {
+a []
+spacer [style="invis" ]
+a -> spacer [ style="invis" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	u := NewUndirectedGraph("testGraph")
+	u.AddInvisibleEdge(&a, &spacer)
+	expected = `graph testGraph {
+a -- spacer [ style="invis" ]
+}`
+	if s := writeString(t, &u); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestSelfLoop(t *testing.T) {
+	g := NewGraph("testGraph")
+	a := &VertexDescription{ID: "a"}
+	g.AddVertex(a)
+	g.AddSelfLoop(a, SelfLoopOptions{Label: "retry"})
+	g.AddSelfLoop(a, SelfLoopOptions{Port: "out", FromCompass: "s", ToCompass: "w", Style: "dashed"})
+
+	expected := `digraph testGraph {
+a []
+a:ne -> a:se [ label="retry" ]
+a:out:s -> a:out:w [ style="dashed" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	u := NewUndirectedGraph("testGraph")
+	if e := u.AddSelfLoop(a, SelfLoopOptions{}); e.Directed {
+		t.Error("expected an undirected self-loop")
+	}
+}
+
+func TestSVGClassAndID(t *testing.T) {
+	g := NewGraph("testGraph")
+	cluster := NewCluster("peers")
+	cluster.Class = "peers"
+	cluster.SVGID = "cluster-peers"
+	a := &VertexDescription{ID: "a", Class: "peer online", SVGID: "peer-a"}
+	cluster.AddVertex(a)
+	g.AddSubGraph(&cluster)
+	g.AddEdge(a, a, true, "")
+	e := g.Body[1].(*EdgeDescription)
+	e.Class = "pin"
+	e.SVGID = "pin-1"
+
+	expected := `digraph testGraph {
+subgraph cluster_peers {
+class="peers"
+id="cluster-peers"
+a [class="peer online" id="peer-a" ]
+}
+a -> a [ class="pin" id="pin-1" ]
+}`
+	s := writeString(t, &g)
+	if s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps := writeString(t, parsed); ps != expected {
+		t.Errorf("unexpected parsed graph: \n%s\n", ps)
+	}
+}
+
+func TestLayers(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.Layers = []string{"control", "data"}
+	g.LayerSelect = "data"
+	cluster := NewCluster("peers")
+	cluster.Layer = LayerAll
+	a := &VertexDescription{ID: "a", Layer: "control"}
+	b := &VertexDescription{ID: "b", Layer: "data"}
+	cluster.AddVertex(a)
+	cluster.AddVertex(b)
+	g.AddSubGraph(&cluster)
+	g.AddEdge(a, b, true, "")
+	g.Body[1].(*EdgeDescription).Layer = "control:data"
+
+	expected := `digraph testGraph {
+layers="control:data"
+layerselect="data"
+subgraph cluster_peers {
+layer="all"
+a [layer="control" ]
+b [layer="data" ]
+}
+a -> b [ layer="control:data" ]
+}`
+	s := writeString(t, &g)
+	if s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps := writeString(t, parsed); ps != expected {
+		t.Errorf("unexpected parsed graph: \n%s\n", ps)
+	}
+
+	parsed, err = Parse(strings.NewReader(`digraph { layers="a b	c" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.Layers, []string{"a", "b", "c"}) {
+		t.Errorf("unexpected layers %q", parsed.Layers)
+	}
+}
+
+func TestTrailingComment(t *testing.T) {
+	g := NewGraph("testGraph")
+	a := &VertexDescription{ID: "a", Label: "peer", Comment: "bootstrap peer"}
+	b := &VertexDescription{ID: "b"}
+	g.AddVertex(a)
+	g.AddVertex(b)
+	g.AddEdge(a, b, true, "")
+	g.AddEdge(b, a, true, "dashed")
+	g.Body[2].(*EdgeDescription).Comment = "replica link"
+	g.Body[3].(*EdgeDescription).Comment = "back\nlink"
+
+	expected := `digraph testGraph {
+a [label="peer" ] // bootstrap peer
+b []
+a -> b // replica link
+b -> a [ style="dashed" ] // back link
+}`
+	s := writeString(t, &g)
+	if s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps := writeString(t, parsed); ps != expected {
+		t.Errorf("unexpected parsed graph: \n%s\n", ps)
+	}
+
+	parsed, err = Parse(strings.NewReader("digraph {\na\nrankdir=LR // layout\ngraph [splines=ortho] // edges\nb\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := parsed.FindVertex("a"); v.Comment != "" {
+		t.Errorf("comment of a graph attribute statement attached to %+v", v)
+	}
+	if ps := writeString(t, parsed); !strings.Contains(ps, "// layout") || !strings.Contains(ps, "// edges") {
+		t.Errorf("comments lost: \n%s\n", ps)
+	}
+}
+
+func BenchmarkEdgeWrite(b *testing.B) {
+	e := &EdgeDescription{
+		From:     VertexDescription{ID: "a"},
+		To:       VertexDescription{ID: "b"},
+		Directed: true,
+		Label:    "edge",
+		Style:    "dashed",
+		Weight:   2,
+	}
+	buf := new(bytes.Buffer)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := e.Write(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGraphWrite(b *testing.B) {
+	g := NewGraphWithCapacity("bench", 10000, 10000)
+	prev := &VertexDescription{ID: "v0"}
+	for i := 0; i < 10000; i++ {
+		v := &VertexDescription{ID: "v" + strconv.Itoa(i), Label: "vertex " + strconv.Itoa(i), Shape: "box"}
+		g.AddVertex(v)
+		g.AddEdge(prev, v, true, "dashed")
+		prev = v
+	}
+	buf := new(bytes.Buffer)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := g.Write(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package dot
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// WriteGraphML writes the graph in GraphML format, readable by tools such as
+// yEd and Gephi.  Vertex, edge and graph attributes are written as GraphML
+// data elements with a string key per attribute name.  Subgraphs are written
+// as nodes containing nested graphs, with generated ids n<i> and g<i> as
+// subgraph names need not be unique.  Vertices which only appear as edge
+// endpoints are declared in the top level graph, and Literal elements are
+// skipped.
+func (graph *Graph) WriteGraphML(w io.Writer) error {
+	enc := &graphmlEncoder{
+		nodes: make(map[string]*graphmlNode),
+		keys:  make(map[graphmlKey]bool),
+		used:  make(map[string]bool),
+	}
+	_, ids := collectVertices(graph)
+	for _, id := range ids {
+		enc.used[id] = true
+	}
+	id := graph.Name
+	if id == "" {
+		id = "G"
+	}
+	enc.used[id] = true
+	root := enc.graph(graph, id)
+	for _, id := range enc.undeclared {
+		if enc.nodes[id] == nil {
+			node := &graphmlNode{ID: id}
+			enc.nodes[id] = node
+			root.Nodes = append(root.Nodes, node)
+		}
+	}
+
+	doc := graphmlDoc{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Graph: root,
+	}
+	for key := range enc.keys {
+		doc.Keys = append(doc.Keys, key)
+	}
+	sort.Slice(doc.Keys, func(i, j int) bool {
+		return doc.Keys[i].ID < doc.Keys[j].ID
+	})
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	xenc := xml.NewEncoder(w)
+	xenc.Indent("", "  ")
+	if err := xenc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type graphmlDoc struct {
+	XMLName xml.Name      `xml:"graphml"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Keys    []graphmlKey  `xml:"key"`
+	Graph   *graphmlGraph `xml:"graph"`
+}
+
+type graphmlKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphmlGraph struct {
+	ID          string         `xml:"id,attr"`
+	EdgeDefault string         `xml:"edgedefault,attr"`
+	Data        []graphmlData  `xml:"data"`
+	Nodes       []*graphmlNode `xml:"node"`
+	Edges       []*graphmlEdge `xml:"edge"`
+}
+
+type graphmlNode struct {
+	ID    string        `xml:"id,attr"`
+	Data  []graphmlData `xml:"data"`
+	Graph *graphmlGraph `xml:"graph"`
+}
+
+type graphmlEdge struct {
+	ID       string        `xml:"id,attr"`
+	Source   string        `xml:"source,attr"`
+	Target   string        `xml:"target,attr"`
+	Directed bool          `xml:"directed,attr"`
+	Data     []graphmlData `xml:"data"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphmlEncoder converts a Graph into the GraphML document structure
+type graphmlEncoder struct {
+	directed   bool
+	nodes      map[string]*graphmlNode
+	undeclared []string
+	keys       map[graphmlKey]bool
+	edgeCount  int
+	// used holds the vertex IDs and the root graph id, which the ids
+	// generated for subgraphs are kept apart from
+	used          map[string]bool
+	subgraphCount int
+}
+
+// data returns the data elements for attrs, registering their keys for the
+// given domain
+func (enc *graphmlEncoder) data(domain string, attrs []attribute) []graphmlData {
+	var data []graphmlData
+	for _, attr := range attrs {
+		key := graphmlKey{
+			ID:   domain + "_" + attr.name,
+			For:  domain,
+			Name: attr.name,
+			Type: "string",
+		}
+		enc.keys[key] = true
+		data = append(data, graphmlData{Key: key.ID, Value: attr.value})
+	}
+	return data
+}
+
+// subgraphIDs returns unused ids for the node of a subgraph and its nested
+// graph
+func (enc *graphmlEncoder) subgraphIDs() (string, string) {
+	for {
+		i := strconv.Itoa(enc.subgraphCount)
+		enc.subgraphCount++
+		if !enc.used["n"+i] && !enc.used["g"+i] {
+			return "n" + i, "g" + i
+		}
+	}
+}
+
+func (enc *graphmlEncoder) graph(graph *Graph, id string) *graphmlGraph {
+	g := &graphmlGraph{
+		ID:   id,
+		Data: enc.data("graph", graph.attributes()),
+	}
+	if !graph.IsSubGraph {
+		enc.directed = !graph.IsUndirected
+	}
+	g.EdgeDefault = "undirected"
+	if enc.directed {
+		g.EdgeDefault = "directed"
+	}
+
+	for _, elem := range graph.Body {
+		switch e := elem.(type) {
+		case *VertexDescription:
+			enc.vertex(g, e)
+		case *EdgeDescription:
+			enc.edge(g, e)
+		case *EdgeChain:
+			for _, edge := range e.Edges() {
+				enc.edge(g, &edge)
+			}
+		case *Graph:
+			nodeID, graphID := enc.subgraphIDs()
+			g.Nodes = append(g.Nodes, &graphmlNode{
+				ID:    nodeID,
+				Graph: enc.graph(e, graphID),
+			})
+		}
+	}
+	return g
+}
+
+func (enc *graphmlEncoder) vertex(g *graphmlGraph, v *VertexDescription) {
+	data := enc.data("node", v.attributes())
+	if node, ok := enc.nodes[v.ID]; ok {
+		// merge repeated vertex statements into the first one
+		for _, d := range data {
+			replaced := false
+			for i := range node.Data {
+				if node.Data[i].Key == d.Key {
+					node.Data[i] = d
+					replaced = true
+				}
+			}
+			if !replaced {
+				node.Data = append(node.Data, d)
+			}
+		}
+		return
+	}
+	node := &graphmlNode{
+		ID:   v.ID,
+		Data: data,
+	}
+	enc.nodes[v.ID] = node
+	g.Nodes = append(g.Nodes, node)
+}
+
+func (enc *graphmlEncoder) edge(g *graphmlGraph, e *EdgeDescription) {
+	g.Edges = append(g.Edges, &graphmlEdge{
+		ID:       "e" + strconv.Itoa(enc.edgeCount),
+		Source:   e.From.ID,
+		Target:   e.To.ID,
+		Directed: e.Directed,
+		Data:     enc.data("edge", e.attributes()),
+	})
+	enc.edgeCount++
+	enc.undeclared = append(enc.undeclared, e.From.ID, e.To.ID)
+}
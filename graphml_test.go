@@ -0,0 +1,92 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+var graphmlOutput = `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="edge_style" for="edge" attr.name="style" attr.type="string"></key>
+  <key id="graph_label" for="graph" attr.name="label" attr.type="string"></key>
+  <key id="graph_rankdir" for="graph" attr.name="rankdir" attr.type="string"></key>
+  <key id="node_color" for="node" attr.name="color" attr.type="string"></key>
+  <key id="node_label" for="node" attr.name="label" attr.type="string"></key>
+  <graph id="testGraph" edgedefault="directed">
+    <data key="graph_rankdir">LR</data>
+    <node id="a">
+      <data key="node_label">A &amp; B</data>
+      <data key="node_color">red</data>
+    </node>
+    <node id="n0">
+      <graph id="g0" edgedefault="directed">
+        <data key="graph_label">Sub</data>
+        <node id="b"></node>
+        <edge id="e1" source="b" target="c" directed="true"></edge>
+      </graph>
+    </node>
+    <node id="c"></node>
+    <edge id="e0" source="a" target="b" directed="true">
+      <data key="edge_style">dashed</data>
+    </edge>
+  </graph>
+</graphml>
+`
+
+func TestWriteGraphML(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.RankDir = RankDirLR
+	a := &VertexDescription{ID: "a", Label: "A & B"}
+	b := &VertexDescription{ID: "b"}
+	c := &VertexDescription{ID: "c"}
+	g.AddVertex(a)
+	g.AddEdge(a, b, true, "dashed")
+	sub := NewCluster("sub")
+	sub.Label = "Sub"
+	sub.AddVertex(b)
+	sub.AddEdge(b, c, true, "")
+	g.AddSubGraph(&sub)
+	g.AddVertex(&VertexDescription{ID: "a", Color: "red"})
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteGraphML(buf); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != graphmlOutput {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", graphmlOutput)
+	}
+}
+
+func TestWriteGraphMLAnonymousSubgraphs(t *testing.T) {
+	g := NewGraph("")
+	for _, id := range []string{"n0", "b"} {
+		sub := Graph{IsSubGraph: true}
+		sub.AddVertex(&VertexDescription{ID: id})
+		g.AddSubGraph(&sub)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <graph id="G" edgedefault="directed">
+    <node id="n1">
+      <graph id="g1" edgedefault="directed">
+        <node id="n0"></node>
+      </graph>
+    </node>
+    <node id="n2">
+      <graph id="g2" edgedefault="directed">
+        <node id="b"></node>
+      </graph>
+    </node>
+  </graph>
+</graphml>
+`
+	buf := new(bytes.Buffer)
+	if err := g.WriteGraphML(buf); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
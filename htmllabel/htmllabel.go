@@ -0,0 +1,252 @@
+// Package htmllabel provides builders for graphviz HTML-like labels
+package htmllabel
+
+import (
+	"strings"
+)
+
+// Element is a piece of HTML-like label markup
+type Element interface {
+	writeHTML(b *strings.Builder)
+}
+
+// Label renders the given elements as an HTML-like label, enclosed in angle
+// brackets so that it can be used directly as a dot attribute value, e.g.
+// VertexDescription.Label
+func Label(elems ...Element) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	writeAll(&b, elems)
+	b.WriteByte('>')
+	return b.String()
+}
+
+func writeAll(b *strings.Builder, elems []Element) {
+	for _, elem := range elems {
+		elem.writeHTML(b)
+	}
+}
+
+// attribute is a single markup attribute, omitted when its value is empty
+type attribute struct {
+	name, value string
+}
+
+func writeTag(b *strings.Builder, tag string, attrs []attribute, selfClosing bool) {
+	b.WriteByte('<')
+	b.WriteString(tag)
+	for _, attr := range attrs {
+		if attr.value == "" {
+			continue
+		}
+		b.WriteByte(' ')
+		b.WriteString(attr.name)
+		b.WriteString(`="`)
+		b.WriteString(Escape(attr.value))
+		b.WriteByte('"')
+	}
+	if selfClosing {
+		b.WriteByte('/')
+	}
+	b.WriteByte('>')
+}
+
+var escaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+)
+
+// Escape replaces the characters which are special in HTML-like labels with
+// their entities, so that arbitrary text can be placed in a label built
+// by hand.  Text and the attributes of elements are escaped when rendered.
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+var unescaper = strings.NewReplacer(
+	"&amp;", "&",
+	"&lt;", "<",
+	"&gt;", ">",
+	"&quot;", `"`,
+	"&apos;", "'",
+	"&#39;", "'",
+)
+
+// Unescape replaces the entities of the characters which are special in
+// HTML-like labels with the characters
+func Unescape(s string) string {
+	return unescaper.Replace(s)
+}
+
+// Text is plain label text.  It is escaped when rendered.
+type Text string
+
+func (t Text) writeHTML(b *strings.Builder) {
+	b.WriteString(Escape(string(t)))
+}
+
+// Break is a line break, optionally aligning the preceding line
+type Break struct {
+	Align string
+}
+
+func (br Break) writeHTML(b *strings.Builder) {
+	writeTag(b, "BR", []attribute{{"ALIGN", br.Align}}, true)
+}
+
+// styled wraps content in a text styling tag such as <B>
+type styled struct {
+	tag     string
+	content []Element
+}
+
+func (s *styled) writeHTML(b *strings.Builder) {
+	writeTag(b, s.tag, nil, false)
+	writeAll(b, s.content)
+	b.WriteString("</" + s.tag + ">")
+}
+
+// Bold renders its content in bold
+func Bold(elems ...Element) Element {
+	return &styled{tag: "B", content: elems}
+}
+
+// Italic renders its content in italics
+func Italic(elems ...Element) Element {
+	return &styled{tag: "I", content: elems}
+}
+
+// Underline renders its content underlined
+func Underline(elems ...Element) Element {
+	return &styled{tag: "U", content: elems}
+}
+
+// Font changes the font of its content
+type Font struct {
+	Face      string
+	Color     string
+	PointSize string
+
+	Content []Element
+}
+
+func (f *Font) writeHTML(b *strings.Builder) {
+	writeTag(b, "FONT", []attribute{
+		{"FACE", f.Face},
+		{"COLOR", f.Color},
+		{"POINT-SIZE", f.PointSize},
+	}, false)
+	writeAll(b, f.Content)
+	b.WriteString("</FONT>")
+}
+
+// Table is a <TABLE> element.  Attributes are strings so that zero values
+// such as BORDER="0" can be expressed; empty attributes are omitted.
+type Table struct {
+	Border      string
+	CellBorder  string
+	CellSpacing string
+	CellPadding string
+	BgColor     string
+	Color       string
+	Align       string
+	Port        string
+
+	Rows []*Row
+}
+
+// NewTable returns a new table with the given rows
+func NewTable(rows ...*Row) *Table {
+	return &Table{
+		Rows: rows,
+	}
+}
+
+// AddRow appends a row made of the given cells to the table and returns it
+func (t *Table) AddRow(cells ...*Cell) *Row {
+	row := &Row{Cells: cells}
+	t.Rows = append(t.Rows, row)
+	return row
+}
+
+func (t *Table) writeHTML(b *strings.Builder) {
+	writeTag(b, "TABLE", []attribute{
+		{"BORDER", t.Border},
+		{"CELLBORDER", t.CellBorder},
+		{"CELLSPACING", t.CellSpacing},
+		{"CELLPADDING", t.CellPadding},
+		{"BGCOLOR", t.BgColor},
+		{"COLOR", t.Color},
+		{"ALIGN", t.Align},
+		{"PORT", t.Port},
+	}, false)
+	for _, row := range t.Rows {
+		row.writeHTML(b)
+	}
+	b.WriteString("</TABLE>")
+}
+
+// Row is a <TR> element of a table
+type Row struct {
+	Cells []*Cell
+}
+
+// AddCell appends a cell with the given content to the row and returns it
+func (r *Row) AddCell(elems ...Element) *Cell {
+	cell := NewCell(elems...)
+	r.Cells = append(r.Cells, cell)
+	return cell
+}
+
+func (r *Row) writeHTML(b *strings.Builder) {
+	b.WriteString("<TR>")
+	for _, cell := range r.Cells {
+		cell.writeHTML(b)
+	}
+	b.WriteString("</TR>")
+}
+
+// Cell is a <TD> element of a table row.  Its content may include nested
+// tables.
+type Cell struct {
+	Border      string
+	CellPadding string
+	BgColor     string
+	Color       string
+	Align       string
+	VAlign      string
+	ColSpan     string
+	RowSpan     string
+	Width       string
+	Height      string
+	Port        string
+
+	Content []Element
+}
+
+// NewCell returns a new cell with the given content
+func NewCell(elems ...Element) *Cell {
+	return &Cell{
+		Content: elems,
+	}
+}
+
+func (c *Cell) writeHTML(b *strings.Builder) {
+	writeTag(b, "TD", []attribute{
+		{"BORDER", c.Border},
+		{"CELLPADDING", c.CellPadding},
+		{"BGCOLOR", c.BgColor},
+		{"COLOR", c.Color},
+		{"ALIGN", c.Align},
+		{"VALIGN", c.VAlign},
+		{"COLSPAN", c.ColSpan},
+		{"ROWSPAN", c.RowSpan},
+		{"WIDTH", c.Width},
+		{"HEIGHT", c.Height},
+		{"PORT", c.Port},
+	}, false)
+	writeAll(b, c.Content)
+	b.WriteString("</TD>")
+}
@@ -0,0 +1,57 @@
+package htmllabel
+
+import (
+	"testing"
+)
+
+func TestTextEscaping(t *testing.T) {
+	s := Label(Text(`a < b & "c" > d`))
+	expected := `<a &lt; b &amp; &quot;c&quot; &gt; d>`
+	if s != expected {
+		t.Errorf("unexpected label %s, expected %s", s, expected)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	s := Escape(`<b>R&D</b> "x"`)
+	expected := `&lt;b&gt;R&amp;D&lt;/b&gt; &quot;x&quot;`
+	if s != expected {
+		t.Errorf("unexpected escaped text %s, expected %s", s, expected)
+	}
+	if u := Unescape(s); u != `<b>R&D</b> "x"` {
+		t.Errorf("unexpected unescaped text %s", u)
+	}
+	if u := Unescape("&amp;lt; &#39;"); u != "&lt; '" {
+		t.Errorf("unexpected unescaped text %s", u)
+	}
+}
+
+func TestTable(t *testing.T) {
+	table := NewTable()
+	table.Border = "0"
+	table.CellBorder = "1"
+	header := table.AddRow()
+	cell := header.AddCell(Bold(Text("peer")))
+	cell.ColSpan = "2"
+	cell.BgColor = "lightgrey"
+	row := table.AddRow()
+	row.AddCell(Text("id")).Port = "id"
+	row.AddCell(&Font{Color: "red", Content: []Element{Text("Qm<x>")}})
+
+	s := Label(table)
+	expected := `<<TABLE BORDER="0" CELLBORDER="1">` +
+		`<TR><TD BGCOLOR="lightgrey" COLSPAN="2"><B>peer</B></TD></TR>` +
+		`<TR><TD PORT="id">id</TD><TD><FONT COLOR="red">Qm&lt;x&gt;</FONT></TD></TR>` +
+		`</TABLE>>`
+	if s != expected {
+		t.Errorf("unexpected label:\n%s\nexpected:\n%s", s, expected)
+	}
+}
+
+func TestBreak(t *testing.T) {
+	s := Label(Text("one"), Break{Align: "LEFT"}, Italic(Text("two")), Break{}, Underline(Text("three")))
+	expected := `<one<BR ALIGN="LEFT"/><I>two</I><BR/><U>three</U>>`
+	if s != expected {
+		t.Errorf("unexpected label %s, expected %s", s, expected)
+	}
+}
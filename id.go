@@ -0,0 +1,221 @@
+package dot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// keywords are the reserved words of the dot language.  They are case
+// independent and cannot be used as bare IDs.
+var keywords = map[string]bool{
+	"node":     true,
+	"edge":     true,
+	"graph":    true,
+	"digraph":  true,
+	"subgraph": true,
+	"strict":   true,
+}
+
+// Compass points for EdgeDescription.FromCompass and ToCompass
+const (
+	CompassN      = "n"
+	CompassNE     = "ne"
+	CompassE      = "e"
+	CompassSE     = "se"
+	CompassS      = "s"
+	CompassSW     = "sw"
+	CompassW      = "w"
+	CompassNW     = "nw"
+	CompassCenter = "c"
+	CompassAny    = "_"
+)
+
+func isCompassPoint(s string) bool {
+	switch s {
+	case CompassN, CompassNE, CompassE, CompassSE, CompassS, CompassSW, CompassW, CompassNW, CompassCenter, CompassAny:
+		return true
+	}
+	return false
+}
+
+// QuoteID returns id in a form that can be written to a dot-file as a node,
+// edge endpoint or graph ID.  Alphanumeric identifiers and numerals are
+// returned unchanged.  HTML strings (enclosed in '<' and '>') and IDs which
+// are already double-quoted are passed through as well.  Everything else,
+// including dot keywords, is double-quoted with embedded quotes and
+// backslashes escaped.
+func QuoteID(id string) string {
+	if isPlainID(id) || isNumeral(id) || isQuoted(id) || isHTML(id) {
+		return id
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(id); i++ {
+		if id[i] == '"' || id[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(id[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// checkStrictID returns an error if id is a keyword, or starts with a digit
+// and is not a numeral.  QuoteID quotes such IDs, which is rejected by
+// WriteOptions.StrictIDs.
+func checkStrictID(id string) error {
+	if keywords[strings.ToLower(id)] {
+		return fmt.Errorf("ID %q is a dot keyword", id)
+	}
+	if id != "" && id[0] >= '0' && id[0] <= '9' && !isNumeral(id) {
+		return fmt.Errorf("ID %q starts with a digit", id)
+	}
+	return nil
+}
+
+// isPlainID reports whether id is a dot identifier: a string of alphabetic
+// characters, underscores or digits, not beginning with a digit, which is
+// not a keyword.
+func isPlainID(id string) bool {
+	if id == "" || keywords[strings.ToLower(id)] {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= 0x80:
+		case c >= '0' && c <= '9':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isNumeral reports whether id is a dot numeral: [-]?(.[0-9]+|[0-9]+(.[0-9]*)?)
+func isNumeral(id string) bool {
+	if strings.HasPrefix(id, "-") {
+		id = id[1:]
+	}
+	if id == "" || id == "." {
+		return false
+	}
+	seenDot := false
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case c == '.' && !seenDot:
+			seenDot = true
+		case c >= '0' && c <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isQuoted reports whether id is a complete double-quoted string.
+func isQuoted(id string) bool {
+	if len(id) < 2 || id[0] != '"' || id[len(id)-1] != '"' {
+		return false
+	}
+	// the closing quote must not be escaped
+	escaped := false
+	for i := 1; i < len(id)-1; i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case id[i] == '\\':
+			escaped = true
+		case id[i] == '"':
+			return false
+		}
+	}
+	return !escaped
+}
+
+// isHTML reports whether id is an HTML string.
+func isHTML(id string) bool {
+	return len(id) >= 2 && id[0] == '<' && id[len(id)-1] == '>'
+}
+
+// sanitizedHashLength is the number of hex digits of the hash appended by
+// SanitizeID to the IDs it changes
+const sanitizedHashLength = 8
+
+// SanitizeID converts an arbitrary string, such as a multiaddress, CID, URL
+// or path, into a dot identifier which can be written without quoting.
+// Runs of characters other than ASCII letters, digits and underscores are
+// replaced by an underscore, and an underscore is prepended to IDs starting
+// with a digit.  When the string had to be changed, or is a keyword, a hash
+// of it is appended so that different strings give different IDs.  The
+// result only depends on s.
+func SanitizeID(s string) string {
+	if isPlainASCIIID(s) {
+		return s
+	}
+	var b strings.Builder
+	replaced := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			if i == 0 && c >= '0' && c <= '9' {
+				b.WriteByte('_')
+			}
+			b.WriteByte(c)
+			replaced = false
+		case !replaced:
+			b.WriteByte('_')
+			replaced = true
+		}
+	}
+	sum := sha256.Sum256([]byte(s))
+	if !replaced {
+		b.WriteByte('_')
+	}
+	b.WriteString(hex.EncodeToString(sum[:])[:sanitizedHashLength])
+	return b.String()
+}
+
+// isPlainASCIIID reports whether id is a dot identifier made of ASCII
+// characters only
+func isPlainASCIIID(id string) bool {
+	for i := 0; i < len(id); i++ {
+		if id[i] >= 0x80 {
+			return false
+		}
+	}
+	return isPlainID(id)
+}
+
+// SanitizeID returns the identifier of s computed by the package-level
+// SanitizeID, and records s so that SourceID can map the identifier back,
+// for instance to label the vertex with s.  The mapping is kept by the root
+// graph, so that it is shared with the clusters added by AddCluster.
+func (graph *Graph) SanitizeID(s string) string {
+	root := graph
+	for root.parent != nil {
+		root = root.parent
+	}
+	id := SanitizeID(s)
+	if root.sanitized == nil {
+		root.sanitized = make(map[string]string)
+	}
+	root.sanitized[id] = s
+	return id
+}
+
+// SourceID returns the string that SanitizeID converted into id, if any
+func (graph *Graph) SourceID(id string) (string, bool) {
+	root := graph
+	for root.parent != nil {
+		root = root.parent
+	}
+	s, ok := root.sanitized[id]
+	return s, ok
+}
@@ -0,0 +1,111 @@
+package dot
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestQuoteID(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected string
+	}{
+		{"C0", "C0"},
+		{"_peer_1", "_peer_1"},
+		{"42", "42"},
+		{"-3.14", "-3.14"},
+		{".5", ".5"},
+		{"", `""`},
+		{"a b", `"a b"`},
+		{"peer-1", `"peer-1"`},
+		{"ipfs.io", `"ipfs.io"`},
+		{"node", `"node"`},
+		{"Graph", `"Graph"`},
+		{"1abc", `"1abc"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\dir`, `"C:\\dir"`},
+		{`"already quoted"`, `"already quoted"`},
+		{`"bad" end"`, `"\"bad\" end\""`},
+		{"<<b>html</b>>", "<<b>html</b>>"},
+	}
+	for _, test := range tests {
+		if got := QuoteID(test.id); got != test.expected {
+			t.Errorf("QuoteID(%q) = %s, expected %s", test.id, got, test.expected)
+		}
+	}
+}
+
+var keywordIDGraph = `digraph testGraph {
+"graph" []
+"123abc" []
+2.5 []
+"graph" -> "123abc"
+{ rank=same; "graph"; 2.5; }
+}`
+
+func TestStrictIDs(t *testing.T) {
+	g := NewGraph("testGraph")
+	kw := &VertexDescription{ID: "graph"}
+	digits := &VertexDescription{ID: "123abc"}
+	num := &VertexDescription{ID: "2.5"}
+	g.AddVertex(kw)
+	g.AddVertex(digits)
+	g.AddVertex(num)
+	g.AddEdge(kw, digits, true, "")
+	g.AddSameRank(kw, num)
+	if s := writeString(t, &g); s != keywordIDGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+	if _, err := Parse(bytes.NewBufferString(keywordIDGraph)); err != nil {
+		t.Error(err)
+	}
+
+	err := g.WriteWithOptions(new(bytes.Buffer), WriteOptions{StrictIDs: true})
+	var werr *WriteError
+	if !errors.As(err, &werr) || werr.Element != "vertex graph" || werr.Err.Error() != `ID "graph" is a dot keyword` {
+		t.Errorf("unexpected error: %v", err)
+	}
+	g.Body = g.Body[1:]
+	err = g.WriteWithOptions(new(bytes.Buffer), WriteOptions{StrictIDs: true})
+	if !errors.As(err, &werr) || werr.Err.Error() != `ID "123abc" starts with a digit` {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	ok := NewGraph("testGraph")
+	ok.AddEdge(num, &VertexDescription{ID: "peer-1"}, true, "")
+	if err := ok.WriteWithOptions(new(bytes.Buffer), WriteOptions{StrictIDs: true}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSanitizeID(t *testing.T) {
+	tests := map[string]string{
+		"QmYw":                      "QmYw",
+		"/ip4/10.0.0.1/tcp/9096":    "_ip4_10_0_0_1_tcp_9096_2f9747ec",
+		"12D3KooW":                  "_12D3KooW_52296d93",
+		"node":                      "node_545ea538",
+		"https://example.com/a?b=c": "https_example_com_a_b_c_e0512f29",
+		"":                          "_e3b0c442",
+		"héllo":                     "h_llo_3c48591d",
+	}
+	for s, expected := range tests {
+		id := SanitizeID(s)
+		if id != expected {
+			t.Errorf("unexpected ID %s for %q, expected %s", id, s, expected)
+		}
+		if QuoteID(id) != id {
+			t.Errorf("ID %s of %q needs quoting", id, s)
+		}
+	}
+
+	g := NewGraph("g")
+	cluster := g.AddCluster("peers")
+	id := cluster.SanitizeID("/ip4/10.0.0.1/tcp/9096")
+	if s, ok := g.SourceID(id); !ok || s != "/ip4/10.0.0.1/tcp/9096" {
+		t.Errorf("unexpected source %q of %s", s, id)
+	}
+	if _, ok := g.SourceID("QmYw"); ok {
+		t.Error("unexpected source of an ID which was not sanitized")
+	}
+}
@@ -0,0 +1,58 @@
+package dot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ImageScale controls how the image of a vertex is scaled to fill it
+type ImageScale string
+
+// Valid values for VertexDescription.ImageScale.  Images keep their natural
+// size by default.
+const (
+	ImageScaleTrue   ImageScale = "true"
+	ImageScaleFalse  ImageScale = "false"
+	ImageScaleWidth  ImageScale = "width"
+	ImageScaleHeight ImageScale = "height"
+	ImageScaleBoth   ImageScale = "both"
+)
+
+// ImagePos places the image of a vertex when it is smaller than the vertex
+type ImagePos string
+
+// Valid values for VertexDescription.ImagePos, from top left to bottom
+// right.  Images are centered by default.
+const (
+	ImagePosTopLeft      ImagePos = "tl"
+	ImagePosTopCenter    ImagePos = "tc"
+	ImagePosTopRight     ImagePos = "tr"
+	ImagePosMiddleLeft   ImagePos = "ml"
+	ImagePosMiddleCenter ImagePos = "mc"
+	ImagePosMiddleRight  ImagePos = "mr"
+	ImagePosBottomLeft   ImagePos = "bl"
+	ImagePosBottomCenter ImagePos = "bc"
+	ImagePosBottomRight  ImagePos = "br"
+)
+
+// validateImage checks the image attributes of a vertex.  The image path
+// or URL is written as a quoted string, which cannot hold double quotes or
+// line breaks graphviz would read back unchanged.
+func (v *VertexDescription) validateImage() error {
+	if strings.ContainsAny(v.Image, "\"\n\r") {
+		return fmt.Errorf("image %q: quotes and line breaks are not allowed", v.Image)
+	}
+	switch v.ImageScale {
+	case "", ImageScaleTrue, ImageScaleFalse, ImageScaleWidth, ImageScaleHeight, ImageScaleBoth:
+	default:
+		return fmt.Errorf("unknown imagescale %q", string(v.ImageScale))
+	}
+	switch v.ImagePos {
+	case "", ImagePosTopLeft, ImagePosTopCenter, ImagePosTopRight,
+		ImagePosMiddleLeft, ImagePosMiddleCenter, ImagePosMiddleRight,
+		ImagePosBottomLeft, ImagePosBottomCenter, ImagePosBottomRight:
+	default:
+		return fmt.Errorf("unknown imagepos %q", string(v.ImagePos))
+	}
+	return nil
+}
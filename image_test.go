@@ -0,0 +1,52 @@
+package dot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImage(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.AddVertex(&VertexDescription{
+		ID:         "db",
+		Shape:      ShapeNone,
+		Image:      `C:\icons\db.png`,
+		ImageScale: ImageScaleTrue,
+		ImagePos:   ImagePosBottomCenter,
+	})
+
+	expected := `digraph testGraph {
+db [shape="none" image="C:\icons\db.png" imagescale="true" imagepos="bc" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+	if err := g.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := parsed.Body[0].(*VertexDescription)
+	if v.Image != `C:\icons\db.png` || v.ImageScale != ImageScaleTrue || v.ImagePos != ImagePosBottomCenter {
+		t.Errorf("unexpected parsed vertex %+v", v)
+	}
+}
+
+func TestImageValidate(t *testing.T) {
+	tests := []struct {
+		v   VertexDescription
+		err string
+	}{
+		{VertexDescription{Image: `icons/"db".png`}, `image "icons/\"db\".png": quotes and line breaks are not allowed`},
+		{VertexDescription{Image: "db.png", ImageScale: "fit"}, `unknown imagescale "fit"`},
+		{VertexDescription{Image: "db.png", ImagePos: "center"}, `unknown imagepos "center"`},
+	}
+	for _, test := range tests {
+		if err := test.v.validateImage(); err == nil || err.Error() != test.err {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package dot
+
+// bodyIndex indexes the vertices and edges of the body of a graph, without
+// those of its subgraphs, which have their own index.  It is built on the
+// first lookup and extended with the elements appended to the body since.
+// Methods changing the body in other ways than appending drop the index
+// with resetIndex.
+type bodyIndex struct {
+	// graph is the graph the index was built for, so that a copy of the
+	// graph does not use the index of the original.  An empty index made by
+	// NewGraphWithCapacity is claimed by the first graph using it.
+	graph *Graph
+	// n is the number of elements of the body indexed
+	n int
+	// vertices holds the first vertex statement for each ID, with its
+	// position in the body
+	vertices map[string]indexedVertex
+	// edges counts the edges between each pair of vertices, including the
+	// hops of edge chains
+	edges map[vertexPair]int
+	// degree counts the edge ends at each vertex
+	degree map[string]int
+	// subgraphs holds the subgraphs of the body with their position
+	subgraphs []indexedSubgraph
+}
+
+type indexedVertex struct {
+	v   *VertexDescription
+	pos int
+}
+
+type indexedSubgraph struct {
+	g   *Graph
+	pos int
+}
+
+// vertexPair identifies the endpoints of an edge, ignoring ports.  The
+// endpoints of undirected edges are ordered.
+type vertexPair struct {
+	from, to string
+	directed bool
+}
+
+func newVertexPair(from, to string, directed bool) vertexPair {
+	if !directed && to < from {
+		from, to = to, from
+	}
+	return vertexPair{from, to, directed}
+}
+
+// index returns the index of the body of the graph, updated with any
+// elements appended since it was last used
+func (graph *Graph) index() *bodyIndex {
+	idx := graph.idx
+	if idx != nil && idx.graph == nil && idx.n == 0 {
+		idx.graph = graph
+	}
+	if idx == nil || idx.graph != graph || idx.n > len(graph.Body) {
+		idx = newBodyIndex(graph, 0, 0)
+		graph.idx = idx
+	}
+	for ; idx.n < len(graph.Body); idx.n++ {
+		switch e := graph.Body[idx.n].(type) {
+		case *VertexDescription:
+			if _, ok := idx.vertices[e.ID]; !ok {
+				idx.vertices[e.ID] = indexedVertex{v: e, pos: idx.n}
+			}
+		case *EdgeDescription:
+			idx.addEdge(e.From.ID, e.To.ID, e.Directed)
+		case *EdgeChain:
+			for i := 1; i < len(e.Vertices); i++ {
+				idx.addEdge(e.Vertices[i-1].ID, e.Vertices[i].ID, e.Directed)
+			}
+		case *Graph:
+			idx.subgraphs = append(idx.subgraphs, indexedSubgraph{g: e, pos: idx.n})
+		}
+	}
+	return idx
+}
+
+// newBodyIndex returns an empty index for graph with room for the given
+// number of vertices and edges
+func newBodyIndex(graph *Graph, vertices, edges int) *bodyIndex {
+	return &bodyIndex{
+		graph:    graph,
+		vertices: make(map[string]indexedVertex, vertices),
+		edges:    make(map[vertexPair]int, edges),
+		degree:   make(map[string]int, vertices),
+	}
+}
+
+func (idx *bodyIndex) addEdge(from, to string, directed bool) {
+	idx.edges[newVertexPair(from, to, directed)]++
+	idx.degree[from]++
+	idx.degree[to]++
+}
+
+// resetIndex drops the index of the graph after its body was changed in
+// place
+func (graph *Graph) resetIndex() {
+	graph.idx = nil
+}
+
+// HasVertex reports whether a vertex with the given ID is declared in the
+// graph or any of its subgraphs, or is the endpoint of one of their edges.
+// The body is indexed on the first query, so the elements of the body must
+// not be changed in place other than through the methods of Graph.
+func (graph *Graph) HasVertex(id string) bool {
+	idx := graph.index()
+	if _, ok := idx.vertices[id]; ok || idx.degree[id] > 0 {
+		return true
+	}
+	for _, sub := range idx.subgraphs {
+		if sub.g.HasVertex(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasEdge reports whether the graph or any of its subgraphs has an edge
+// from the vertex with ID from to the vertex with ID to.  Undirected edges
+// match regardless of the order of their endpoints.
+func (graph *Graph) HasEdge(from, to string) bool {
+	idx := graph.index()
+	if idx.edges[newVertexPair(from, to, true)] > 0 || idx.edges[newVertexPair(from, to, false)] > 0 {
+		return true
+	}
+	for _, sub := range idx.subgraphs {
+		if sub.g.HasEdge(from, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// Degree returns the number of edge ends at the vertex with the given ID in
+// the graph and its subgraphs.  A self-loop counts twice.
+func (graph *Graph) Degree(id string) int {
+	idx := graph.index()
+	degree := idx.degree[id]
+	for _, sub := range idx.subgraphs {
+		degree += sub.g.Degree(id)
+	}
+	return degree
+}
+
+// NewGraphWithCapacity returns a new graph with room for the given number
+// of vertices and edges, avoiding reallocations while a large graph is
+// built
+func NewGraphWithCapacity(name string, vertices, edges int) Graph {
+	graph := NewGraph(name)
+	graph.Body = make([]Element, 0, vertices+edges)
+	graph.idx = newBodyIndex(nil, vertices, edges)
+	return graph
+}
+
+// Reserve makes room for the given number of vertices and edges to be added
+// to the graph, in addition to its current elements
+func (graph *Graph) Reserve(vertices, edges int) {
+	if n := len(graph.Body) + vertices + edges; n > cap(graph.Body) {
+		body := make([]Element, len(graph.Body), n)
+		copy(body, graph.Body)
+		graph.Body = body
+	}
+	idx := graph.index()
+	graph.idx = newBodyIndex(graph, len(idx.vertices)+vertices, len(idx.edges)+edges)
+}
@@ -0,0 +1,149 @@
+package dot
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestIndexQueries(t *testing.T) {
+	g := buildEditGraph()
+	g.AddVertex(&VertexDescription{ID: "a", Label: "second"})
+
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if !g.HasVertex(id) {
+			t.Errorf("expected vertex %s", id)
+		}
+	}
+	if g.HasVertex("e") {
+		t.Error("unexpected vertex e")
+	}
+	if v := g.FindVertex("a"); v == nil || v.Label != "A" {
+		t.Errorf("unexpected vertex %+v", v)
+	}
+
+	for _, pair := range [][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}, {"a", "c"}, {"c", "d"}} {
+		if !g.HasEdge(pair[0], pair[1]) {
+			t.Errorf("expected edge %s -> %s", pair[0], pair[1])
+		}
+	}
+	if g.HasEdge("b", "a") || g.HasEdge("d", "c") {
+		t.Error("unexpected reversed directed edge")
+	}
+
+	// a: a -> b, c -- a, a -> b in the chain
+	if d := g.Degree("a"); d != 3 {
+		t.Errorf("unexpected degree %d of a", d)
+	}
+	// b: b -> c in the cluster, a -> b, and two hops of the chain
+	if d := g.Degree("b"); d != 4 {
+		t.Errorf("unexpected degree %d of b", d)
+	}
+}
+
+func TestIndexUpdates(t *testing.T) {
+	g := buildEditGraph()
+	if g.HasEdge("d", "e") {
+		t.Error("unexpected edge d -> e")
+	}
+
+	// appended elements are indexed, including those appended directly
+	g.AddEdge(&VertexDescription{ID: "d"}, &VertexDescription{ID: "e"}, true, "")
+	g.Body = append(g.Body, &VertexDescription{ID: "f"})
+	if !g.HasEdge("d", "e") || !g.HasVertex("f") {
+		t.Error("expected appended elements to be indexed")
+	}
+
+	// a copy of the graph has its own index
+	copied := g
+	copied.Body = append([]Element(nil), g.Body...)
+	copied.AddVertex(&VertexDescription{ID: "g"})
+	if g.HasVertex("g") || !copied.HasVertex("g") {
+		t.Error("expected the copy to be indexed separately")
+	}
+
+	if !g.RemoveVertex("e") || g.HasEdge("d", "e") || g.Degree("d") != 1 {
+		t.Error("expected removed edges to be dropped from the index")
+	}
+	if err := g.InsertAt(0, &VertexDescription{ID: "a", Label: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	if v := g.FindVertex("a"); v.Label != "first" {
+		t.Errorf("unexpected vertex %+v", v)
+	}
+	if _, err := g.RemoveAt(0); err != nil {
+		t.Fatal(err)
+	}
+	if v := g.FindVertex("a"); v.Label != "A" {
+		t.Errorf("unexpected vertex %+v", v)
+	}
+}
+
+func BenchmarkDedupAddVertex(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		g := NewGraph("bench")
+		g.Dedup = DedupMerge
+		for j := 0; j < 10000; j++ {
+			g.AddVertex(&VertexDescription{ID: strconv.Itoa(j % 5000)})
+		}
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	g := NewGraphWithCapacity("testGraph", 100, 200)
+	if cap(g.Body) != 300 {
+		t.Errorf("unexpected capacity %d", cap(g.Body))
+	}
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	g.AddVertex(a)
+	g.AddEdge(a, b, true, "")
+	if !g.HasVertex("a") || !g.HasEdge("a", "b") {
+		t.Error("expected the graph to be indexed")
+	}
+
+	g.Reserve(1000, 0)
+	if cap(g.Body) < 1002 || len(g.Body) != 2 {
+		t.Errorf("unexpected body length %d and capacity %d", len(g.Body), cap(g.Body))
+	}
+	if !g.HasVertex("a") || !g.HasEdge("a", "b") {
+		t.Error("expected the graph to be indexed after Reserve")
+	}
+	expected := `digraph testGraph {
+a []
+a -> b
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func BenchmarkBuildLargeGraph(b *testing.B) {
+	ids := make([]string, 100000)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+	}
+	build := func(g *Graph) {
+		g.Dedup = DedupMerge
+		prev := &VertexDescription{ID: ids[0]}
+		for _, id := range ids {
+			v := &VertexDescription{ID: id}
+			g.AddVertex(v)
+			g.AddEdge(prev, v, true, "")
+			prev = v
+		}
+	}
+	b.Run("NewGraph", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g := NewGraph("bench")
+			build(&g)
+		}
+	})
+	b.Run("NewGraphWithCapacity", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g := NewGraphWithCapacity("bench", len(ids), len(ids))
+			build(&g)
+		}
+	})
+}
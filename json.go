@@ -0,0 +1,278 @@
+package dot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// WriteJSON writes the graph in the JSON format produced by graphviz with
+// "dot -Tjson0": the graph, its subgraphs, nodes and edges with their
+// attributes, but no layout information.  Subgraphs come first in the
+// "objects" array, followed by the nodes, and are referred to by their
+// index in that array.  Literal elements have no JSON representation and are
+// skipped.
+func (graph *Graph) WriteJSON(w io.Writer) error {
+	b := &jsonBuilder{
+		nodeIndex: make(map[string]int),
+	}
+	b.addGraph(graph, nil, nil, nil)
+
+	root := b.subgraphs[0]
+	obj := jsonObject{
+		{"name", root.name},
+		{"directed", !graph.IsUndirected},
+		{"strict", graph.IsStrict},
+		{"_subgraph_cnt", len(b.subgraphs) - 1},
+	}
+	obj = appendJSONAttrs(obj, root.attrs)
+
+	var objects []jsonObject
+	for i, sub := range b.subgraphs[1:] {
+		subObj := jsonObject{
+			{"_gvid", i},
+			{"name", sub.name},
+		}
+		subObj = appendJSONAttrs(subObj, sub.attrs)
+		if len(sub.subgraphs) > 0 {
+			subObj = append(subObj, jsonField{"subgraphs", sub.subgraphs})
+		}
+		if len(sub.nodes) > 0 {
+			nodes := make([]int, len(sub.nodes))
+			for j, n := range sub.nodes {
+				nodes[j] = n + len(b.subgraphs) - 1
+			}
+			subObj = append(subObj, jsonField{"nodes", nodes})
+		}
+		if len(sub.edges) > 0 {
+			subObj = append(subObj, jsonField{"edges", sub.edges})
+		}
+		objects = append(objects, subObj)
+	}
+	for i, n := range b.nodes {
+		nodeObj := jsonObject{
+			{"_gvid", i + len(b.subgraphs) - 1},
+			{"name", n.name},
+		}
+		objects = append(objects, appendJSONAttrs(nodeObj, n.attrs))
+	}
+	if len(objects) > 0 {
+		obj = append(obj, jsonField{"objects", objects})
+	}
+
+	var edges []jsonObject
+	for i, e := range b.edges {
+		edgeObj := jsonObject{
+			{"_gvid", i},
+			{"tail", e.tail + len(b.subgraphs) - 1},
+			{"head", e.head + len(b.subgraphs) - 1},
+		}
+		edges = append(edges, appendJSONAttrs(edgeObj, e.attrs))
+	}
+	if len(edges) > 0 {
+		obj = append(obj, jsonField{"edges", edges})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(obj)
+}
+
+// jsonField is a member of a jsonObject
+type jsonField struct {
+	key   string
+	value interface{}
+}
+
+// jsonObject is a JSON object which keeps its members in order
+type jsonObject []jsonField
+
+// MarshalJSON implements json.Marshaler
+func (obj jsonObject) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, field := range obj {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func appendJSONAttrs(obj jsonObject, attrs []attribute) jsonObject {
+	for _, attr := range attrs {
+		obj = append(obj, jsonField{attr.name, attr.value})
+	}
+	return obj
+}
+
+type jsonSubgraph struct {
+	name      string
+	attrs     []attribute
+	subgraphs []int
+	nodes     []int
+	edges     []int
+}
+
+type jsonNode struct {
+	name  string
+	attrs []attribute
+}
+
+type jsonEdge struct {
+	tail, head int
+	attrs      []attribute
+}
+
+// jsonBuilder collects the subgraphs, nodes and edges of a graph in the
+// order graphviz numbers them
+type jsonBuilder struct {
+	// subgraphs in preorder, starting with the root graph
+	subgraphs []*jsonSubgraph
+	nodes     []*jsonNode
+	nodeIndex map[string]int
+	edges     []*jsonEdge
+}
+
+// addGraph adds graph and its contents.  scope holds the indices of the
+// enclosing subgraphs, and nodeDefaults and edgeDefaults the default
+// attributes in effect.
+func (b *jsonBuilder) addGraph(graph *Graph, scope []int, nodeDefaults, edgeDefaults []attribute) {
+	index := len(b.subgraphs)
+	sub := &jsonSubgraph{
+		name:  graph.Name,
+		attrs: graph.attributes(),
+	}
+	b.subgraphs = append(b.subgraphs, sub)
+	if len(scope) > 0 {
+		parent := b.subgraphs[scope[len(scope)-1]]
+		// the root graph is not numbered with the subgraphs
+		parent.subgraphs = append(parent.subgraphs, index-1)
+	}
+	scope = append(scope, index)
+
+	if graph.NodeDefaults != nil {
+		nodeDefaults = mergeAttributes(nodeDefaults, graph.NodeDefaults.attributes())
+	}
+	if graph.EdgeDefaults != nil {
+		edgeDefaults = mergeAttributes(edgeDefaults, graph.EdgeDefaults.attributes())
+	}
+
+	for _, elem := range graph.Body {
+		switch e := elem.(type) {
+		case *VertexDescription:
+			n := b.node(e.ID, scope, nodeDefaults)
+			b.nodes[n].attrs = mergeAttributes(b.nodes[n].attrs, e.attributes())
+		case *EdgeDescription:
+			b.edge(e, scope, nodeDefaults, edgeDefaults)
+		case *EdgeChain:
+			for _, edge := range e.Edges() {
+				b.edge(&edge, scope, nodeDefaults, edgeDefaults)
+			}
+		case *RankGroup:
+			rank := &Graph{
+				Name: fmt.Sprintf("%%%d", len(b.subgraphs)),
+				Rank: e.Rank,
+			}
+			for _, id := range e.IDs {
+				rank.Body = append(rank.Body, &VertexDescription{ID: id})
+			}
+			b.addGraph(rank, scope, nodeDefaults, edgeDefaults)
+		case *Graph:
+			b.addGraph(e, scope, nodeDefaults, edgeDefaults)
+		}
+	}
+}
+
+// node returns the index of the node with the given ID, creating it if
+// necessary, and adds it to the subgraphs in scope
+func (b *jsonBuilder) node(id string, scope []int, defaults []attribute) int {
+	n, ok := b.nodeIndex[id]
+	if !ok {
+		n = len(b.nodes)
+		b.nodeIndex[id] = n
+		b.nodes = append(b.nodes, &jsonNode{
+			name:  id,
+			attrs: mergeAttributes(nil, defaults),
+		})
+	}
+	for _, s := range scope[1:] {
+		sub := b.subgraphs[s]
+		if !containsInt(sub.nodes, n) {
+			sub.nodes = append(sub.nodes, n)
+		}
+	}
+	return n
+}
+
+func (b *jsonBuilder) edge(e *EdgeDescription, scope []int, nodeDefaults, edgeDefaults []attribute) {
+	index := len(b.edges)
+	attrs := mergeAttributes(mergeAttributes(nil, edgeDefaults), e.attributes())
+	if port := portString(e.FromPort, e.FromCompass); port != "" {
+		attrs = append(attrs, attribute{"tailport", port})
+	}
+	if port := portString(e.ToPort, e.ToCompass); port != "" {
+		attrs = append(attrs, attribute{"headport", port})
+	}
+	b.edges = append(b.edges, &jsonEdge{
+		tail:  b.node(e.From.ID, scope, nodeDefaults),
+		head:  b.node(e.To.ID, scope, nodeDefaults),
+		attrs: attrs,
+	})
+	for _, s := range scope[1:] {
+		sub := b.subgraphs[s]
+		sub.edges = append(sub.edges, index)
+	}
+}
+
+// portString formats a port and compass point as in the tailport and
+// headport attributes
+func portString(port, compass string) string {
+	if port != "" && compass != "" {
+		return port + ":" + compass
+	}
+	return port + compass
+}
+
+// mergeAttributes returns attrs updated with the values of the attributes
+// in update.  Attributes not yet in attrs are appended.
+func mergeAttributes(attrs, update []attribute) []attribute {
+	merged := append([]attribute(nil), attrs...)
+	for _, u := range update {
+		found := false
+		for i := range merged {
+			if merged[i].name == u.name {
+				merged[i].value = u.value
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, u)
+		}
+	}
+	return merged
+}
+
+func containsInt(list []int, x int) bool {
+	for _, y := range list {
+		if y == x {
+			return true
+		}
+	}
+	return false
+}
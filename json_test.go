@@ -0,0 +1,77 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+var jsonGraph = `{
+  "name": "testGraph",
+  "directed": true,
+  "strict": false,
+  "_subgraph_cnt": 1,
+  "rankdir": "LR",
+  "objects": [
+    {
+      "_gvid": 0,
+      "name": "cluster_sub",
+      "label": "Sub",
+      "nodes": [
+        2
+      ],
+      "edges": [
+        1
+      ]
+    },
+    {
+      "_gvid": 1,
+      "name": "a",
+      "shape": "box",
+      "label": "A"
+    },
+    {
+      "_gvid": 2,
+      "name": "b",
+      "shape": "box"
+    }
+  ],
+  "edges": [
+    {
+      "_gvid": 0,
+      "tail": 1,
+      "head": 2,
+      "style": "dashed",
+      "tailport": "out:e"
+    },
+    {
+      "_gvid": 1,
+      "tail": 2,
+      "head": 2
+    }
+  ]
+}
+`
+
+func TestWriteJSON(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.RankDir = RankDirLR
+	g.SetNodeDefaults(VertexDescription{Shape: "box"})
+	a := &VertexDescription{ID: "a", Label: "A"}
+	b := &VertexDescription{ID: "b"}
+	g.AddVertex(a)
+	g.AddComment("ignored")
+	g.Body = append(g.Body, &EdgeDescription{From: *a, To: *b, Directed: true, Style: "dashed", FromPort: "out", FromCompass: CompassE})
+	sub := NewCluster("sub")
+	sub.Label = "Sub"
+	sub.AddEdge(b, b, true, "")
+	g.AddSubGraph(&sub)
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteJSON(buf); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != jsonGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", jsonGraph)
+	}
+}
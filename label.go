@@ -0,0 +1,158 @@
+package dot
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zenground0/go-dot/htmllabel"
+)
+
+// Escape sequences substituted by graphviz in labels.  They are passed
+// through by EscapeLabel.
+const (
+	// LabelNodeName is replaced by the ID of the vertex
+	LabelNodeName = `\N`
+	// LabelGraphName is replaced by the name of the graph
+	LabelGraphName = `\G`
+	// LabelEdgeName is replaced by the name of the edge, such as "a->b"
+	LabelEdgeName = `\E`
+	// LabelTailName and LabelHeadName are replaced by the IDs of the
+	// endpoints of the edge
+	LabelTailName = `\T`
+	LabelHeadName = `\H`
+	// LabelObjectLabel is replaced by the label of the object, for use in
+	// its tooltip
+	LabelObjectLabel = `\L`
+)
+
+// Justify selects how a line of a label is aligned
+type Justify byte
+
+// Line justifications, written as the escape sequence ending the line
+const (
+	JustifyCenter Justify = 'n'
+	JustifyLeft   Justify = 'l'
+	JustifyRight  Justify = 'r'
+)
+
+// EscapeLabel returns text as a label showing it verbatim.  Line breaks
+// become centered \n breaks and backslashes are escaped, except for those
+// starting the substitution sequences \N, \G, \E, \T, \H and \L.
+func EscapeLabel(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\r' && i+1 < len(text) && text[i+1] == '\n':
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("NGETHL", text[i+1]) >= 0:
+			b.WriteString(text[i : i+2])
+			i++
+		case c == '\\':
+			b.WriteString(`\\`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// LabelLines returns a label made of the given lines, each escaped with
+// EscapeLabel and aligned as selected
+func LabelLines(justify Justify, lines ...string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(EscapeLabel(line))
+		b.WriteByte('\\')
+		b.WriteByte(byte(justify))
+	}
+	return b.String()
+}
+
+// Labelf formats a label like fmt.Sprintf, escaping the formatted arguments
+// so that they are shown verbatim: backslashes are escaped and line breaks
+// written as \n.  The format itself is used as is, so it may contain
+// escape sequences such as \l.
+func Labelf(format string, args ...interface{}) string {
+	return fmt.Sprintf(format, escapeArgs(labelValueEscaper.Replace, args)...)
+}
+
+// HTMLLabelf formats the markup of an HTML-like label like fmt.Sprintf,
+// escaping the formatted arguments as HTML text, and returns the label
+// enclosed in '<' and '>'
+func HTMLLabelf(format string, args ...interface{}) string {
+	return "<" + fmt.Sprintf(format, escapeArgs(htmllabel.Escape, args)...) + ">"
+}
+
+// escapeArgs wraps the arguments of a format so that they are escaped once
+// formatted
+func escapeArgs(escape func(string) string, args []interface{}) []interface{} {
+	escaped := make([]interface{}, len(args))
+	for i, arg := range args {
+		escaped[i] = escapedArg{arg, escape}
+	}
+	return escaped
+}
+
+// escapedArg is a format argument escaped after being formatted with the
+// verb, flags, width and precision in use
+type escapedArg struct {
+	value  interface{}
+	escape func(string) string
+}
+
+func (a escapedArg) Format(f fmt.State, verb rune) {
+	format := "%"
+	for _, flag := range "+-# 0" {
+		if f.Flag(int(flag)) {
+			format += string(flag)
+		}
+	}
+	if width, ok := f.Width(); ok {
+		format += strconv.Itoa(width)
+	}
+	if prec, ok := f.Precision(); ok {
+		format += "." + strconv.Itoa(prec)
+	}
+	io.WriteString(f, a.escape(fmt.Sprintf(format+string(verb), a.value)))
+}
+
+// Ellipsis ends the labels shortened by TruncateLabel
+const Ellipsis = "…"
+
+// TruncateLabel shortens the label of the vertex to n characters, the last
+// being an Ellipsis, when it is longer, so that long identifiers do not make
+// the vertex too wide.  With tooltip set the full label becomes the tooltip
+// of the vertex, unless it already has one.  HTML-like labels are left as
+// they are.
+func (v *VertexDescription) TruncateLabel(n int, tooltip bool) {
+	label, ok := truncateLabel(v.Label, n)
+	if !ok {
+		return
+	}
+	if tooltip && v.Tooltip == "" {
+		v.Tooltip = v.Label
+	}
+	v.Label = label
+}
+
+// truncateLabel returns the label shortened to n characters, and whether it
+// had to be
+func truncateLabel(label string, n int) (string, bool) {
+	if n <= 0 || isHTML(label) || utf8.RuneCountInString(label) <= n {
+		return label, false
+	}
+	runes := []rune(label)[:n-1]
+	// do not cut an escape sequence in half
+	backslashes := 0
+	for i := len(runes) - 1; i >= 0 && runes[i] == '\\'; i-- {
+		backslashes++
+	}
+	if backslashes%2 == 1 {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + Ellipsis, true
+}
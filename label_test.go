@@ -0,0 +1,108 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEscapeLabel(t *testing.T) {
+	tests := map[string]string{
+		"plain":                "plain",
+		"two\nlines":           `two\nlines`,
+		"crlf\r\nline":         `crlf\nline`,
+		`C:\dir\n`:             `C:\\dir\\n`,
+		`\N on \G`:             `\N on \G`,
+		`\E \T \H \L`:          `\E \T \H \L`,
+		`say "hi"`:             `say "hi"`,
+		"id: " + LabelNodeName: `id: \N`,
+		`trailing backslash \`: `trailing backslash \\`,
+	}
+	for text, expected := range tests {
+		if got := EscapeLabel(text); got != expected {
+			t.Errorf("EscapeLabel(%q) = %s, expected %s", text, got, expected)
+		}
+	}
+}
+
+func TestLabelLines(t *testing.T) {
+	if got := LabelLines(JustifyLeft, "name: \\N", "cpu: 93%"); got != `name: \N\lcpu: 93%\l` {
+		t.Errorf("unexpected label %s", got)
+	}
+	if got := LabelLines(JustifyRight, "a\\b"); got != `a\\b\r` {
+		t.Errorf("unexpected label %s", got)
+	}
+	if got := LabelLines(JustifyCenter, "a", "b"); got != `a\nb\n` {
+		t.Errorf("unexpected label %s", got)
+	}
+}
+
+func TestRawNewlineLabel(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.AddVertex(&VertexDescription{ID: "a", Label: "first\nsecond \"quoted\""})
+	expected := `digraph testGraph {
+a [label="first\nsecond \"quoted\"" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestLabelf(t *testing.T) {
+	label := Labelf(`%s\l%d pins, %5.1f%%\l%q`, `peer "a" \ b`, 3, 12.34, "x\ny")
+	expected := `peer "a" \\ b\l3 pins,  12.3%\l"x\\ny"`
+	if label != expected {
+		t.Errorf("unexpected label %s, expected %s", label, expected)
+	}
+
+	v := &VertexDescription{ID: "a", Label: Labelf("%s", `say "hi"\`)}
+	buf := new(bytes.Buffer)
+	if err := v.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != `a [label="say \"hi\"\\" ]` {
+		t.Errorf("unexpected output %s", s)
+	}
+
+	label = HTMLLabelf("<B>%s</B><BR/>%v", "R&D <team>", []string{"a"})
+	expected = `<<B>R&amp;D &lt;team&gt;</B><BR/>[a]>`
+	if label != expected {
+		t.Errorf("unexpected label %s, expected %s", label, expected)
+	}
+}
+
+func TestTruncateLabel(t *testing.T) {
+	tests := []struct {
+		label, expected string
+	}{
+		{"QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG", "QmYwAPJ…"},
+		{"QmYwAPJz", "QmYwAPJz"},
+		{"short", "short"},
+		{`QmYwAP\nJzv5`, `QmYwAP…`},
+		{"日本語のラベルです", "日本語のラベル…"},
+		{"<<B>QmYwAPJzv5CZsnA625s3</B>>", "<<B>QmYwAPJzv5CZsnA625s3</B>>"},
+	}
+	for _, test := range tests {
+		v := NewVertexDescription("a", WithLabel(test.label), WithTruncatedLabel(8, false))
+		if v.Label != test.expected {
+			t.Errorf("unexpected label %s for %s, expected %s", v.Label, test.label, test.expected)
+		}
+		if v.Tooltip != "" {
+			t.Errorf("unexpected tooltip %s", v.Tooltip)
+		}
+	}
+
+	g := NewGraph("g")
+	g.LabelLimit = 5
+	g.LabelLimitTooltip = true
+	a := NewVertexDescription("a", WithLabel("12D3KooWabc"))
+	b := NewVertexDescription("b", WithLabel("12D3KooWdef"), WithTooltip("peer b"))
+	g.AddVertex(&a)
+	g.AddVertex(&b)
+	expected := `digraph g {
+a [label="12D3…" tooltip="12D3KooWabc" ]
+b [label="12D3…" tooltip="peer b" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
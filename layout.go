@@ -0,0 +1,340 @@
+package dot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// pointsPerInch converts the inch units of graphviz sizes to points
+const pointsPerInch = 72
+
+// Point is a position in points, with the y axis pointing up
+type Point struct {
+	X, Y float64
+}
+
+// Position is the pos attribute of a vertex.  Pinned vertices keep their
+// position when laid out by neato or fdp, and the other vertices use it as
+// a starting point.  The position is in inches, or in points for layouts
+// rendered with "neato -n".
+type Position struct {
+	X, Y   float64
+	Pinned bool
+}
+
+// String returns the position as written in the pos attribute
+func (p Position) String() string {
+	s := formatFloat(p.X) + "," + formatFloat(p.Y)
+	if p.Pinned {
+		s += "!"
+	}
+	return s
+}
+
+// parsePosition parses a pos attribute of a vertex
+func parsePosition(s string) (*Position, error) {
+	pinned := strings.HasSuffix(s, "!")
+	p, err := parsePoint(strings.TrimSuffix(s, "!"))
+	if err != nil {
+		return nil, err
+	}
+	return &Position{X: p.X, Y: p.Y, Pinned: pinned}, nil
+}
+
+// Rect is an axis aligned rectangle given by its lower left and upper right
+// corners
+type Rect struct {
+	Min, Max Point
+}
+
+// NodeLayout is the position and size graphviz computed for a vertex.  The
+// size is in inches, as in the width and height attributes.
+type NodeLayout struct {
+	Pos           Point
+	Width, Height float64
+}
+
+// BoundingBox returns the rectangle covered by the vertex in points
+func (n NodeLayout) BoundingBox() Rect {
+	w := n.Width * pointsPerInch / 2
+	h := n.Height * pointsPerInch / 2
+	return Rect{
+		Min: Point{n.Pos.X - w, n.Pos.Y - h},
+		Max: Point{n.Pos.X + w, n.Pos.Y + h},
+	}
+}
+
+// EdgeLayout is the spline graphviz computed for an edge, as a list of
+// B-spline control points
+type EdgeLayout struct {
+	From, To string
+	Points   []Point
+}
+
+// Layout holds the positions graphviz computed for the vertices and edges
+// of a graph, as read back from its plain or xdot output
+type Layout struct {
+	BoundingBox Rect
+	Nodes       map[string]NodeLayout
+	Edges       []EdgeLayout
+}
+
+// Apply annotates graph with the layout: the bb attribute is set on the
+// graph, and the Pos, Width and Height fields of every vertex in the
+// graph or its subgraphs which has a position in the layout.  Rendering the
+// annotated graph with "neato -n" reproduces the layout.
+func (l *Layout) Apply(graph *Graph) {
+	graph.AddAttribute("bb", formatFloats(l.BoundingBox.Min.X, l.BoundingBox.Min.Y,
+		l.BoundingBox.Max.X, l.BoundingBox.Max.Y))
+	l.applyBody(graph)
+}
+
+func (l *Layout) applyBody(graph *Graph) {
+	for _, elem := range graph.Body {
+		switch e := elem.(type) {
+		case *VertexDescription:
+			n, ok := l.Nodes[e.ID]
+			if !ok {
+				continue
+			}
+			e.Pos = &Position{X: n.Pos.X, Y: n.Pos.Y}
+			e.Width = n.Width
+			e.Height = n.Height
+		case *Graph:
+			l.applyBody(e)
+		}
+	}
+}
+
+// ParsePlain reads the layout from the output of graphviz with -Tplain or
+// -Tplain-ext.  Coordinates are converted from inches to points.
+func ParsePlain(r io.Reader) (*Layout, error) {
+	layout := &Layout{Nodes: make(map[string]NodeLayout)}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields, err := plainFields(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "graph":
+			nums, err := parseFloats(fields[1:], 3)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			layout.BoundingBox.Max = Point{nums[1] * pointsPerInch, nums[2] * pointsPerInch}
+		case "node":
+			if len(fields) < 6 {
+				return nil, fmt.Errorf("line %d: short node statement", line)
+			}
+			nums, err := parseFloats(fields[2:], 4)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			layout.Nodes[fields[1]] = NodeLayout{
+				Pos:    Point{nums[0] * pointsPerInch, nums[1] * pointsPerInch},
+				Width:  nums[2],
+				Height: nums[3],
+			}
+		case "edge":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("line %d: short edge statement", line)
+			}
+			n, err := strconv.Atoi(fields[3])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid point count %q", line, fields[3])
+			}
+			nums, err := parseFloats(fields[4:], 2*n)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			edge := EdgeLayout{From: fields[1], To: fields[2]}
+			for i := 0; i < n; i++ {
+				edge.Points = append(edge.Points, Point{nums[2*i] * pointsPerInch, nums[2*i+1] * pointsPerInch})
+			}
+			layout.Edges = append(layout.Edges, edge)
+		case "stop":
+			return layout, nil
+		default:
+			return nil, fmt.Errorf("line %d: unknown statement %q", line, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
+
+// plainFields splits a line of plain output into fields.  Quoted fields are
+// unquoted.
+func plainFields(s string) ([]string, error) {
+	var fields []string
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == ' ' || s[i] == '\t' || s[i] == '\r':
+			i++
+		case s[i] == '"':
+			var b strings.Builder
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			i++
+			fields = append(fields, b.String())
+		default:
+			start := i
+			for i < len(s) && s[i] != ' ' && s[i] != '\t' && s[i] != '\r' {
+				i++
+			}
+			fields = append(fields, s[start:i])
+		}
+	}
+	return fields, nil
+}
+
+// parseFloats parses the first n fields as numbers
+func parseFloats(fields []string, n int) ([]float64, error) {
+	if len(fields) < n {
+		return nil, fmt.Errorf("expected %d numbers, found %d", n, len(fields))
+	}
+	nums := make([]float64, n)
+	for i := range nums {
+		f, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", fields[i])
+		}
+		nums[i] = f
+	}
+	return nums, nil
+}
+
+// ParseXdot reads the layout from the output of graphviz with -Txdot or
+// -Tdot, using the bb, pos, width and height attributes it adds to the
+// graph.
+func ParseXdot(r io.Reader) (*Layout, error) {
+	graph, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	layout := &Layout{Nodes: make(map[string]NodeLayout)}
+	if bb, ok := findAttribute(graph.attributes(), "bb"); ok {
+		nums, err := parseFloats(strings.Split(bb, ","), 4)
+		if err != nil {
+			return nil, fmt.Errorf("bb: %v", err)
+		}
+		layout.BoundingBox = Rect{Point{nums[0], nums[1]}, Point{nums[2], nums[3]}}
+	}
+	if err := layout.readXdot(graph); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
+
+func (l *Layout) readXdot(graph *Graph) error {
+	for _, elem := range graph.Body {
+		switch e := elem.(type) {
+		case *VertexDescription:
+			attrs := e.attributes()
+			pos, ok := findAttribute(attrs, "pos")
+			if !ok {
+				continue
+			}
+			p, err := parsePoint(strings.TrimSuffix(pos, "!"))
+			if err != nil {
+				return fmt.Errorf("vertex %s: %v", e.ID, err)
+			}
+			n := NodeLayout{Pos: p}
+			if w, ok := findAttribute(attrs, "width"); ok {
+				n.Width, err = strconv.ParseFloat(w, 64)
+			}
+			if h, ok := findAttribute(attrs, "height"); ok && err == nil {
+				n.Height, err = strconv.ParseFloat(h, 64)
+			}
+			if err != nil {
+				return fmt.Errorf("vertex %s: %v", e.ID, err)
+			}
+			l.Nodes[e.ID] = n
+		case *EdgeDescription:
+			if err := l.readXdotEdge(e); err != nil {
+				return err
+			}
+		case *EdgeChain:
+			for _, edge := range e.Edges() {
+				if err := l.readXdotEdge(&edge); err != nil {
+					return err
+				}
+			}
+		case *Graph:
+			if err := l.readXdot(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l *Layout) readXdotEdge(e *EdgeDescription) error {
+	pos, ok := findAttribute(e.attributes(), "pos")
+	if !ok {
+		return nil
+	}
+	edge := EdgeLayout{From: e.From.ID, To: e.To.ID}
+	for _, field := range strings.Fields(pos) {
+		// arrowhead end and start points
+		if strings.HasPrefix(field, "e,") || strings.HasPrefix(field, "s,") {
+			continue
+		}
+		p, err := parsePoint(field)
+		if err != nil {
+			return fmt.Errorf("edge %s -> %s: %v", e.From.ID, e.To.ID, err)
+		}
+		edge.Points = append(edge.Points, p)
+	}
+	l.Edges = append(l.Edges, edge)
+	return nil
+}
+
+// parsePoint parses an "x,y" point
+func parsePoint(s string) (Point, error) {
+	nums, err := parseFloats(strings.Split(s, ","), 2)
+	if err != nil {
+		return Point{}, err
+	}
+	return Point{nums[0], nums[1]}, nil
+}
+
+// findAttribute returns the value of the last attribute with the given name
+func findAttribute(attrs []attribute, name string) (string, bool) {
+	var value string
+	found := false
+	for _, attr := range attrs {
+		if attr.name == name {
+			value = attr.value
+			found = true
+		}
+	}
+	return value, found
+}
+
+// formatFloats formats numbers as a comma separated list
+func formatFloats(nums ...float64) string {
+	s := make([]string, len(nums))
+	for i, f := range nums {
+		s[i] = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return strings.Join(s, ",")
+}
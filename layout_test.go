@@ -0,0 +1,140 @@
+package dot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var plainLayout = `graph 1 0.75 2.5
+node a 0.375 2.25 0.75 0.5 a solid ellipse black lightgrey
+node "b c" 0.375 0.25 0.75 0.5 "B \"C\"" solid ellipse black lightgrey
+edge a "b c" 2 0.375 2 0.375 0.5 solid black
+stop
+`
+
+var xdotLayout = `digraph test {
+	graph [bb="0,0,54,180"];
+	node [label="\N"];
+	a	[height=0.5,
+		pos="27,162",
+		width=0.75];
+	subgraph cluster_sub {
+		"b c"	[height=0.5,
+			pos="27,18!",
+			width=0.75];
+	}
+	a -> "b c"	[pos="e,27,36.104 27,144 27,36"];
+}
+`
+
+var expectedLayout = &Layout{
+	BoundingBox: Rect{Max: Point{54, 180}},
+	Nodes: map[string]NodeLayout{
+		"a":   {Pos: Point{27, 162}, Width: 0.75, Height: 0.5},
+		"b c": {Pos: Point{27, 18}, Width: 0.75, Height: 0.5},
+	},
+	Edges: []EdgeLayout{
+		{From: "a", To: "b c", Points: []Point{{27, 144}, {27, 36}}},
+	},
+}
+
+var appliedLayout = `digraph test {
+bb="0,0,54,180"
+a [width="0.75" height="0.5" pos="27,162" ]
+b [label="B" ]
+a -> b
+}`
+
+func TestParsePlain(t *testing.T) {
+	layout, err := ParsePlain(strings.NewReader(plainLayout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(layout, expectedLayout) {
+		t.Errorf("unexpected layout: %+v", layout)
+	}
+}
+
+func TestParsePlainError(t *testing.T) {
+	_, err := ParsePlain(strings.NewReader("graph 1 2 3\nnode a 1 x 1 1\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseXdot(t *testing.T) {
+	layout, err := ParseXdot(strings.NewReader(xdotLayout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(layout, expectedLayout) {
+		t.Errorf("unexpected layout: %+v", layout)
+	}
+}
+
+func TestNodeLayoutBoundingBox(t *testing.T) {
+	n := expectedLayout.Nodes["a"]
+	expected := Rect{Point{0, 144}, Point{54, 180}}
+	if bb := n.BoundingBox(); bb != expected {
+		t.Errorf("unexpected bounding box: %+v", bb)
+	}
+}
+
+func TestLayoutApply(t *testing.T) {
+	g := NewGraph("test")
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b", Label: "B"}
+	g.AddVertex(a)
+	g.AddVertex(b)
+	g.AddEdge(a, b, true, "")
+
+	layout := &Layout{
+		BoundingBox: expectedLayout.BoundingBox,
+		Nodes: map[string]NodeLayout{
+			"a": expectedLayout.Nodes["a"],
+		},
+	}
+	layout.Apply(&g)
+	if s := g.String(); s != appliedLayout {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestPinnedPositions(t *testing.T) {
+	g := NewGraph("test")
+	g.Layout = LayoutNeato
+	paris := &VertexDescription{ID: "paris"}
+	paris.PinAt(2.35, 48.86)
+	berlin := &VertexDescription{ID: "berlin", Pos: &Position{X: 13.4, Y: 52.52}}
+	g.AddVertex(paris)
+	g.AddVertex(berlin)
+
+	expected := `digraph test {
+layout="neato"
+paris [pos="2.35,48.86!" ]
+berlin [pos="13.4,52.52" ]
+}`
+	if s := g.String(); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	parsed, err := Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Layout != LayoutNeato {
+		t.Errorf("unexpected layout %q", parsed.Layout)
+	}
+	v := parsed.Body[0].(*VertexDescription)
+	if v.Pos == nil || *v.Pos != (Position{X: 2.35, Y: 48.86, Pinned: true}) {
+		t.Errorf("unexpected position %+v", v.Pos)
+	}
+	parsed, err = Parse(strings.NewReader(`graph { a [pos="1;2"] }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := parsed.Body[0].(*VertexDescription); v.Pos != nil || v.Attrs["pos"] != "1;2" {
+		t.Errorf("invalid pos not kept in Attrs: %+v", v)
+	}
+}
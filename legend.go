@@ -0,0 +1,69 @@
+package dot
+
+import "strconv"
+
+// LegendEntry maps the look of a vertex, or of an edge when Edge is set, to
+// its meaning
+type LegendEntry struct {
+	Label string
+
+	Edge      bool
+	Color     string
+	FillColor string
+	Style     string
+	Shape     Shape
+}
+
+// AddLegend adds a cluster labelled "Legend" to the graph, showing a sample
+// of each entry next to its label.  Samples are stacked in the order of the
+// entries.  The vertices of the legend have IDs starting with "legend".  The
+// cluster is returned so that it can be styled further.
+func (graph *Graph) AddLegend(entries []LegendEntry) *Graph {
+	legend := NewCluster("legend")
+	legend.Label = "Legend"
+	var prev *VertexDescription
+	for i, entry := range entries {
+		id := "legend" + strconv.Itoa(i)
+		sample := &VertexDescription{
+			ID:        id,
+			Label:     " ",
+			Color:     entry.Color,
+			FillColor: entry.FillColor,
+			Style:     entry.Style,
+			Shape:     entry.Shape,
+			Width:     0.5,
+			Height:    0.3,
+		}
+		last := sample
+		if entry.Edge {
+			sample = &VertexDescription{ID: id, Shape: ShapePoint, Style: "invis"}
+			last = &VertexDescription{ID: id + "_head", Shape: ShapePoint, Style: "invis"}
+		}
+		text := &VertexDescription{ID: id + "_label", Label: entry.Label, Shape: ShapePlainText}
+		legend.AddVertex(sample)
+		if entry.Edge {
+			legend.AddVertex(last)
+			legend.Body = append(legend.Body, &EdgeDescription{
+				From:     *sample,
+				To:       *last,
+				Directed: !graph.IsUndirected,
+				Color:    entry.Color,
+				Style:    entry.Style,
+				MinLen:   2,
+			})
+		}
+		legend.AddVertex(text)
+		legend.AddEdge(last, text, !graph.IsUndirected, "invis")
+		if entry.Edge {
+			legend.AddSameRank(sample, last, text)
+		} else {
+			legend.AddSameRank(sample, text)
+		}
+		if prev != nil {
+			legend.AddEdge(prev, sample, !graph.IsUndirected, "invis")
+		}
+		prev = sample
+	}
+	graph.AddSubGraph(&legend)
+	return &legend
+}
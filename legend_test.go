@@ -0,0 +1,39 @@
+package dot
+
+import (
+	"testing"
+)
+
+var legendGraph = `digraph testGraph {
+subgraph cluster_legend {
+label="Legend"
+legend0 [label=" " color="red" style="filled" shape="box" fillcolor="pink" width="0.5" height="0.3" ]
+legend0_label [label="service" shape="plaintext" ]
+legend0 -> legend0_label [ style="invis" ]
+{ rank=same; legend0; legend0_label; }
+legend1 [style="invis" shape="point" ]
+legend1_head [style="invis" shape="point" ]
+legend1 -> legend1_head [ style="dashed" minlen="2" color="blue" ]
+legend1_label [label="replication" shape="plaintext" ]
+legend1_head -> legend1_label [ style="invis" ]
+{ rank=same; legend1; legend1_head; legend1_label; }
+legend0 -> legend1 [ style="invis" ]
+}
+}`
+
+func TestAddLegend(t *testing.T) {
+	g := NewGraph("testGraph")
+	legend := g.AddLegend([]LegendEntry{
+		{Label: "service", Color: "red", FillColor: "pink", Style: "filled", Shape: ShapeBox},
+		{Label: "replication", Edge: true, Color: "blue", Style: "dashed"},
+	})
+	if !legend.IsCluster() || len(g.Body) != 1 || g.Body[0] != legend {
+		t.Fatalf("unexpected legend %+v", legend)
+	}
+	if s := writeString(t, &g); s != legendGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+	if err := g.Validate(); err != nil {
+		t.Error(err)
+	}
+}
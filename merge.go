@@ -0,0 +1,220 @@
+package dot
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MergeOptions controls how Graph.Merge combines two graphs
+type MergeOptions struct {
+	// Overwrite makes the attribute values of the merged graph replace
+	// different values already set.  Otherwise the existing values are
+	// kept and the first conflict is returned as an error.
+	Overwrite bool
+	// Namespace, when set, is prefixed to the names of the merged
+	// subgraphs, keeping them apart from subgraphs of the same name already
+	// in the graph.  Otherwise subgraphs with the same name are merged.
+	Namespace string
+}
+
+// Merge adds the contents of other to the graph.  Vertices are deduplicated
+// by ID, merging the attributes of a vertex already present anywhere in the
+// graph.  Edges are added unless the graph already has an identical edge.
+// Graph attributes and node and edge defaults are merged like vertex
+// attributes.  Elements are copied, so other is left unchanged and may be
+// reused, except elements of types defined outside this package, which are
+// shared.  The first attribute conflict is returned once the merge is
+// complete.
+func (graph *Graph) Merge(other *Graph, opts MergeOptions) error {
+	m := &merger{root: graph, opts: opts}
+	m.mergeGraph(graph, other)
+	return m.err
+}
+
+// merger holds the state of a Graph.Merge
+type merger struct {
+	root *Graph
+	opts MergeOptions
+	err  error
+	// edges holds the edges and edge chain hops of root by their
+	// endpoints.  It is built when root first has an edge between the
+	// endpoints of a merged edge, and extended with the merged edges.
+	edges map[vertexPair][]*EdgeDescription
+}
+
+func (m *merger) conflict(err error) {
+	if err != nil && m.err == nil {
+		m.err = err
+	}
+}
+
+// mergeValue sets *dst to value, checking for a conflict with its current
+// value
+func (m *merger) mergeValue(owner, name string, dst *string, value string) {
+	if value == "" || *dst == value {
+		return
+	}
+	if *dst != "" && !m.opts.Overwrite {
+		m.conflict(fmt.Errorf("%s: conflicting values %q and %q for attribute %s", owner, *dst, value, name))
+		return
+	}
+	*dst = value
+}
+
+// mergeAttrs merges the src attributes map into *dst
+func (m *merger) mergeAttrs(owner string, dst *map[string]string, src map[string]string) {
+	for _, key := range sortedKeys(src) {
+		value := (*dst)[key]
+		m.mergeValue(owner, key, &value, src[key])
+		if value != "" {
+			if *dst == nil {
+				*dst = make(map[string]string)
+			}
+			(*dst)[key] = value
+		}
+	}
+}
+
+func (m *merger) mergeGraph(dst, src *Graph) {
+	owner := "graph " + dst.Name
+	for _, field := range graphFields {
+		value := field.get(dst)
+		m.mergeValue(owner, field.name, &value, field.get(src))
+		field.set(dst, value)
+	}
+	m.mergeAttrs(owner, &dst.Attrs, src.Attrs)
+
+	if src.NodeDefaults != nil {
+		if dst.NodeDefaults == nil {
+			dst.NodeDefaults = &VertexDescription{}
+		}
+		m.conflict(mergeVertex(dst.NodeDefaults, src.NodeDefaults, m.opts.Overwrite))
+	}
+	if src.EdgeDefaults != nil {
+		if dst.EdgeDefaults == nil {
+			dst.EdgeDefaults = &EdgeDescription{}
+		}
+		for _, field := range edgeFields {
+			value := field.get(dst.EdgeDefaults)
+			m.mergeValue(owner+" edge defaults", field.name, &value, field.get(src.EdgeDefaults))
+			field.set(dst.EdgeDefaults, value)
+		}
+		m.mergeAttrs(owner+" edge defaults", &dst.EdgeDefaults.Attrs, src.EdgeDefaults.Attrs)
+	}
+
+	for _, elem := range src.Body {
+		switch e := elem.(type) {
+		case *VertexDescription:
+			if existing := m.root.FindVertex(e.ID); existing != nil {
+				m.conflict(mergeVertex(existing, e, m.opts.Overwrite))
+				continue
+			}
+			dst.Body = append(dst.Body, copyVertex(e))
+		case *EdgeDescription:
+			if m.containsEdge(e) {
+				continue
+			}
+			edge := copyEdge(e)
+			dst.Body = append(dst.Body, edge)
+			m.addEdge(edge)
+		case *EdgeChain:
+			chain := *e
+			chain.Vertices = make([]VertexDescription, len(e.Vertices))
+			for i := range e.Vertices {
+				chain.Vertices[i] = *copyVertex(&e.Vertices[i])
+			}
+			hops := chain.Edges()
+			i := 0
+			for _, elem := range splitChain(&chain, func(from, to *VertexDescription) bool {
+				i++
+				return !m.containsEdge(&hops[i-1])
+			}) {
+				dst.Body = append(dst.Body, elem)
+				for _, hop := range elem.(*EdgeChain).Edges() {
+					hop := hop
+					m.addEdge(&hop)
+				}
+			}
+		case *RankGroup:
+			dst.Body = append(dst.Body, &RankGroup{
+				Rank: e.Rank,
+				IDs:  append([]string(nil), e.IDs...),
+			})
+		case *Literal:
+			lit := *e
+			dst.Body = append(dst.Body, &lit)
+		case *Graph:
+			name := e.Name
+			if m.opts.Namespace != "" {
+				if strings.HasPrefix(name, "cluster") {
+					name = "cluster_" + m.opts.Namespace + strings.TrimPrefix(strings.TrimPrefix(name, "cluster"), "_")
+				} else {
+					name = m.opts.Namespace + name
+				}
+			}
+			sub := findSubGraph(dst, name)
+			if sub == nil {
+				sub = &Graph{Name: name, IsSubGraph: true}
+				dst.Body = append(dst.Body, sub)
+			}
+			m.mergeGraph(sub, e)
+		default:
+			dst.Body = append(dst.Body, elem)
+		}
+	}
+}
+
+// findSubGraph returns the direct subgraph of graph with the given name, or
+// nil if there is none
+func findSubGraph(graph *Graph, name string) *Graph {
+	for _, elem := range graph.Body {
+		if sub, ok := elem.(*Graph); ok && sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// containsEdge reports whether the root graph or its subgraphs contain an
+// edge, or edge chain hop, identical to e
+func (m *merger) containsEdge(e *EdgeDescription) bool {
+	if !m.root.HasEdge(e.From.ID, e.To.ID) {
+		return false
+	}
+	if m.edges == nil {
+		m.edges = make(map[vertexPair][]*EdgeDescription)
+		forEachEdge(m.root, m.addEdge)
+	}
+	for _, other := range m.edges[newVertexPair(e.From.ID, e.To.ID, e.Directed)] {
+		if sameEdge(other, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// addEdge adds an edge of the root graph to the edges looked up by
+// containsEdge, once they are built
+func (m *merger) addEdge(e *EdgeDescription) {
+	if m.edges != nil {
+		key := newVertexPair(e.From.ID, e.To.ID, e.Directed)
+		m.edges[key] = append(m.edges[key], e)
+	}
+}
+
+// sameEdge reports whether two edges connect the same endpoints in the same
+// direction with the same attributes.  Undirected edges match regardless of
+// the order of their endpoints.
+func sameEdge(a, b *EdgeDescription) bool {
+	if a.Directed != b.Directed || !reflect.DeepEqual(a.attributes(), b.attributes()) {
+		return false
+	}
+	if a.From.ID == b.From.ID && a.FromPort == b.FromPort && a.FromCompass == b.FromCompass &&
+		a.To.ID == b.To.ID && a.ToPort == b.ToPort && a.ToCompass == b.ToCompass {
+		return true
+	}
+	return !a.Directed &&
+		a.From.ID == b.To.ID && a.FromPort == b.ToPort && a.FromCompass == b.ToCompass &&
+		a.To.ID == b.From.ID && a.ToPort == b.FromPort && a.ToCompass == b.FromCompass
+}
@@ -0,0 +1,169 @@
+package dot
+
+import (
+	"strings"
+	"testing"
+)
+
+var mergedGraph = `digraph testGraph {
+rankdir="LR"
+a [label="A" color="red" ]
+subgraph cluster_sub {
+label="Sub"
+b [shape="box" ]
+c []
+b -> c
+}
+a -> b
+d []
+b -> d
+}`
+
+var namespacedGraph = `digraph testGraph {
+subgraph cluster_sub {
+b []
+}
+subgraph cluster_other_sub {
+c []
+}
+}`
+
+func buildMergeGraphs() (Graph, Graph) {
+	g := NewGraph("testGraph")
+	g.RankDir = RankDirLR
+	a := &VertexDescription{ID: "a", Label: "A"}
+	b := &VertexDescription{ID: "b"}
+	g.AddVertex(a)
+	sub := NewCluster("sub")
+	sub.AddVertex(b)
+	g.AddSubGraph(&sub)
+	g.AddEdge(a, b, true, "")
+
+	other := NewGraph("other")
+	other.RankDir = RankDirLR
+	oa := &VertexDescription{ID: "a", Color: "red"}
+	ob := &VertexDescription{ID: "b", Shape: "box"}
+	oc := &VertexDescription{ID: "c"}
+	od := &VertexDescription{ID: "d"}
+	osub := NewCluster("sub")
+	osub.Label = "Sub"
+	osub.AddVertex(ob)
+	osub.AddVertex(oc)
+	osub.AddEdge(ob, oc, true, "")
+	other.AddSubGraph(&osub)
+	other.AddVertex(oa)
+	other.AddVertex(od)
+	other.AddEdgeChain(true, "", oa, ob, od)
+	return g, other
+}
+
+func TestMerge(t *testing.T) {
+	g, other := buildMergeGraphs()
+	if err := g.Merge(&other, MergeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if s := writeString(t, &g); s != mergedGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", mergedGraph)
+	}
+	// other is left unchanged
+	if v := other.FindVertex("a"); v.Label != "" {
+		t.Errorf("merged graph was modified: %+v", v)
+	}
+}
+
+func TestMergeEdges(t *testing.T) {
+	g := NewGraph("testGraph")
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	g.AddEdge(a, b, true, "")
+
+	other := NewGraph("other")
+	other.AddEdge(a, b, true, "")
+	other.AddEdge(a, b, true, "dashed")
+	other.AddEdge(a, b, true, "dashed")
+	other.AddEdge(b, a, true, "")
+	other.AddEdgeChain(true, "", b, a, b)
+	if err := g.Merge(&other, MergeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `digraph testGraph {
+a -> b
+a -> b [ style="dashed" ]
+b -> a
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestMergeCopies(t *testing.T) {
+	other := NewGraph("other")
+	c := &VertexDescription{ID: "c", Attrs: map[string]string{"peripheries": "2"}}
+	d := &VertexDescription{ID: "d", Attrs: map[string]string{"peripheries": "2"}}
+	other.AddVertex(c)
+	other.Body = append(other.Body, &EdgeDescription{From: *c, To: *d, Directed: true, Constraint: Bool(true)})
+	other.AddEdgeChain(true, "", d, c)
+	before := writeString(t, &other)
+
+	g := NewGraph("testGraph")
+	if err := g.Merge(&other, MergeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	g.Body[0].(*VertexDescription).AddAttribute("peripheries", "3")
+	edge := g.Body[1].(*EdgeDescription)
+	edge.From.AddAttribute("peripheries", "3")
+	*edge.Constraint = false
+	g.Body[2].(*EdgeChain).Vertices[0].AddAttribute("peripheries", "3")
+	if s := writeString(t, &other); s != before {
+		t.Errorf("merged graph was modified: \n%s\n", s)
+	}
+	src := other.Body[1].(*EdgeDescription)
+	if src.From.Attrs["peripheries"] != "2" || !*src.Constraint {
+		t.Errorf("merged edge was modified: %+v", src)
+	}
+	if v := other.Body[2].(*EdgeChain).Vertices[0]; v.Attrs["peripheries"] != "2" {
+		t.Errorf("merged edge chain was modified: %+v", v)
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	g, other := buildMergeGraphs()
+	other.FindVertex("a").Label = "Other A"
+	err := g.Merge(&other, MergeOptions{})
+	if err == nil || !strings.Contains(err.Error(), `"A" and "Other A"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if v := g.FindVertex("a"); v.Label != "A" {
+		t.Errorf("conflicting value overwritten: %+v", v)
+	}
+
+	g, other = buildMergeGraphs()
+	other.FindVertex("a").Label = "Other A"
+	if err := g.Merge(&other, MergeOptions{Overwrite: true}); err != nil {
+		t.Fatal(err)
+	}
+	if v := g.FindVertex("a"); v.Label != "Other A" {
+		t.Errorf("value not overwritten: %+v", v)
+	}
+}
+
+func TestMergeNamespace(t *testing.T) {
+	g := NewGraph("testGraph")
+	sub := NewCluster("sub")
+	sub.AddVertex(&VertexDescription{ID: "b"})
+	g.AddSubGraph(&sub)
+
+	other := NewGraph("other")
+	osub := NewCluster("sub")
+	osub.AddVertex(&VertexDescription{ID: "c"})
+	other.AddSubGraph(&osub)
+
+	if err := g.Merge(&other, MergeOptions{Namespace: "other_"}); err != nil {
+		t.Fatal(err)
+	}
+	if s := writeString(t, &g); s != namespacedGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
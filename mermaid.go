@@ -0,0 +1,178 @@
+package dot
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// mermaidShapes maps graphviz node shapes to the brackets enclosing a node
+// label in mermaid
+var mermaidShapes = map[Shape][2]string{
+	"":              {"([", "])"},
+	"ellipse":       {"([", "])"},
+	"oval":          {"([", "])"},
+	"box":           {"[", "]"},
+	"rect":          {"[", "]"},
+	"rectangle":     {"[", "]"},
+	"square":        {"[", "]"},
+	"record":        {"[", "]"},
+	"Mrecord":       {"(", ")"},
+	"circle":        {"((", "))"},
+	"doublecircle":  {"(((", ")))"},
+	"diamond":       {"{", "}"},
+	"hexagon":       {"{{", "}}"},
+	"cylinder":      {"[(", ")]"},
+	"parallelogram": {"[/", "/]"},
+	"trapezium":     {"[/", "\\]"},
+	"invtrapezium":  {"[\\", "/]"},
+}
+
+// WriteMermaid writes the graph as a mermaid flowchart, which can be
+// rendered in markdown documents.  The flowchart direction follows RankDir.
+// Clusters become mermaid subgraphs while the contents of other subgraphs
+// are merged into their parent.  Vertex shapes, colors and edge styles are
+// mapped to their closest mermaid equivalent; Literal and RankGroup elements
+// are skipped.
+func (graph *Graph) WriteMermaid(w io.Writer) error {
+	m := &mermaidWriter{
+		ids: make(map[string]string),
+	}
+	dir := graph.RankDir
+	if dir == "" || dir == RankDirTB {
+		dir = "TD"
+	}
+	m.buf.WriteString("flowchart " + dir + "\n")
+	m.body(graph, 1)
+	for _, style := range m.styles {
+		m.buf.WriteString("    " + style + "\n")
+	}
+	_, err := io.WriteString(w, m.buf.String())
+	return err
+}
+
+type mermaidWriter struct {
+	buf       strings.Builder
+	ids       map[string]string
+	generated int
+	styles    []string
+}
+
+// id returns the mermaid ID for a vertex ID.  IDs which are not plain
+// identifiers, or are mermaid keywords, are replaced by generated ones.
+func (m *mermaidWriter) id(id string) string {
+	if mid, ok := m.ids[id]; ok {
+		return mid
+	}
+	mid := id
+	if !isPlainID(id) || strings.ToLower(id) == "end" || strings.HasPrefix(id, "n_") {
+		mid = "n_" + strconv.Itoa(m.generated)
+		m.generated++
+	}
+	m.ids[id] = mid
+	return mid
+}
+
+func (m *mermaidWriter) line(depth int, format string, args ...interface{}) {
+	m.buf.WriteString(strings.Repeat("    ", depth))
+	fmt.Fprintf(&m.buf, format, args...)
+	m.buf.WriteByte('\n')
+}
+
+func (m *mermaidWriter) body(graph *Graph, depth int) {
+	for _, elem := range graph.Body {
+		switch e := elem.(type) {
+		case *VertexDescription:
+			m.vertex(e, depth)
+		case *EdgeDescription:
+			m.edge(e, depth)
+		case *EdgeChain:
+			for _, edge := range e.Edges() {
+				m.edge(&edge, depth)
+			}
+		case *Graph:
+			if !e.IsCluster() {
+				m.body(e, depth)
+				continue
+			}
+			if e.Label != "" {
+				m.line(depth, "subgraph %s [%s]", m.id(e.Name), mermaidText(e.Label))
+			} else {
+				m.line(depth, "subgraph %s", m.id(e.Name))
+			}
+			m.body(e, depth+1)
+			m.line(depth, "end")
+		}
+	}
+}
+
+func (m *mermaidWriter) vertex(v *VertexDescription, depth int) {
+	attrs := v.attributes()
+	label, _ := findAttribute(attrs, "label")
+	if label == "" {
+		label = v.ID
+	}
+	shapeName, _ := findAttribute(attrs, "shape")
+	color, _ := findAttribute(attrs, "color")
+	fontColor, _ := findAttribute(attrs, "fontcolor")
+	style, _ := findAttribute(attrs, "style")
+	shape, ok := mermaidShapes[Shape(shapeName)]
+	if !ok {
+		shape = mermaidShapes["box"]
+	}
+	id := m.id(v.ID)
+	m.line(depth, "%s%s%s%s", id, shape[0], mermaidText(label), shape[1])
+
+	var styles []string
+	if color != "" {
+		styles = append(styles, "stroke:"+color)
+		if strings.Contains(style, "filled") {
+			styles = append(styles, "fill:"+color)
+		}
+	}
+	if fontColor != "" {
+		styles = append(styles, "color:"+fontColor)
+	}
+	if strings.Contains(style, "dashed") {
+		styles = append(styles, "stroke-dasharray:5 5")
+	}
+	if len(styles) > 0 {
+		m.styles = append(m.styles, fmt.Sprintf("style %s %s", id, strings.Join(styles, ",")))
+	}
+}
+
+func (m *mermaidWriter) edge(e *EdgeDescription, depth int) {
+	// links indexed by directed, then solid, dashed, bold and invisible
+	links := [2][4]string{
+		{"---", "-.-", "===", "~~~"},
+		{"-->", "-.->", "==>", "~~~"},
+	}
+	directed := 0
+	if e.Directed {
+		directed = 1
+	}
+	var link string
+	switch {
+	case strings.Contains(e.Style, "invis"):
+		link = links[directed][3]
+	case strings.Contains(e.Style, "dashed"), strings.Contains(e.Style, "dotted"):
+		link = links[directed][1]
+	case strings.Contains(e.Style, "bold"):
+		link = links[directed][2]
+	default:
+		link = links[directed][0]
+	}
+	for _, attr := range e.attributes() {
+		if attr.name == "label" && attr.value != "" {
+			link += "|" + mermaidText(attr.value) + "|"
+		}
+	}
+	m.line(depth, "%s %s %s", m.id(e.From.ID), link, m.id(e.To.ID))
+}
+
+// mermaidText quotes label text for mermaid, replacing double quotes with
+// their entity code
+func mermaidText(s string) string {
+	return `"` + strings.Replace(s, `"`, "#quot;", -1) + `"`
+}
@@ -0,0 +1,50 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+var mermaidGraph = `flowchart LR
+    a(["Peer #quot;A#quot;"])
+    b(["b"])
+    subgraph cluster_ipfs ["IPFS"]
+        n_0[("store")]
+    end
+    a --> n_0
+    a -.->|"replica"| b
+    b --- n_1
+    style a stroke:red,fill:red
+    style b stroke:blue
+`
+
+func TestWriteMermaid(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.RankDir = RankDirLR
+	a := &VertexDescription{ID: "a", Label: `Peer "A"`, Color: "red", Style: "filled"}
+	store := &VertexDescription{ID: "data store", Shape: "cylinder"}
+	store.AddAttribute("label", "store")
+	b := &VertexDescription{ID: "b"}
+	b.AddAttribute("color", "blue")
+	end := &VertexDescription{ID: "end"}
+	g.AddVertex(a)
+	g.AddVertex(b)
+	sub := NewCluster("ipfs")
+	sub.Label = "IPFS"
+	sub.AddVertex(store)
+	g.AddSubGraph(&sub)
+	g.AddEdge(a, store, true, "")
+	e := &EdgeDescription{From: *a, To: *b, Directed: true, Style: "dashed"}
+	e.AddAttribute("label", "replica")
+	g.Body = append(g.Body, e)
+	g.AddEdge(b, end, false, "")
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteMermaid(buf); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != mermaidGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", mermaidGraph)
+	}
+}
@@ -0,0 +1,96 @@
+package dot
+
+import (
+	"strconv"
+)
+
+// ParallelMode selects how a Graph handles edges added between vertices
+// which are already connected by an edge
+type ParallelMode int
+
+const (
+	// ParallelKeep schedules every added edge.  Graphviz draws parallel
+	// edges without labels on top of each other.
+	ParallelKeep ParallelMode = iota
+	// ParallelLabel schedules every added edge and labels the parallel
+	// edges without a label with their Key, or their position among the
+	// edges between the vertices when they have none, so that they are
+	// drawn apart
+	ParallelLabel
+	// ParallelCollapse merges parallel edges into the first one, labeled
+	// with the number of edges it stands for
+	ParallelCollapse
+)
+
+// AddEdgeWithKey constructs an edgedescription connecting the two vertices
+// given as parameters, identified among parallel edges by key, and
+// schedules it to be written in the output dotfile according to the
+// Parallel mode of the graph.  It returns the scheduled edge, which is an
+// earlier edge when the new one was collapsed into it.
+func (graph *Graph) AddEdgeWithKey(v1 *VertexDescription, v2 *VertexDescription, directed bool, style, key string) *EdgeDescription {
+	return graph.addEdge(&EdgeDescription{
+		From:     *v1,
+		To:       *v2,
+		Directed: directed,
+		Style:    style,
+		Key:      key,
+	})
+}
+
+// addEdge schedules the edge according to the Parallel mode of the graph
+func (graph *Graph) addEdge(edge *EdgeDescription) *EdgeDescription {
+	if graph.Parallel == ParallelKeep {
+		graph.Body = append(graph.Body, edge)
+		return edge
+	}
+	parallel := graph.parallelEdges(edge)
+	switch graph.Parallel {
+	case ParallelCollapse:
+		if len(parallel) > 0 {
+			first := parallel[0]
+			if first.count == 0 {
+				first.count = 1
+			}
+			first.count++
+			first.Label = strconv.Itoa(first.count)
+			return first
+		}
+	case ParallelLabel:
+		if len(parallel) > 0 {
+			for i, e := range append(parallel, edge) {
+				if e.Label != "" {
+					continue
+				}
+				e.Label = e.Key
+				if e.Label == "" {
+					e.Label = strconv.Itoa(i + 1)
+				}
+			}
+		}
+	}
+	graph.Body = append(graph.Body, edge)
+	return edge
+}
+
+// parallelEdges returns the edges of the graph body connecting the same
+// endpoints as edge, in either direction for undirected edges
+func (graph *Graph) parallelEdges(edge *EdgeDescription) []*EdgeDescription {
+	if graph.index().edges[newVertexPair(edge.From.ID, edge.To.ID, edge.Directed)] == 0 {
+		return nil
+	}
+	var parallel []*EdgeDescription
+	for _, elem := range graph.Body {
+		e, ok := elem.(*EdgeDescription)
+		if !ok || e.Directed != edge.Directed {
+			continue
+		}
+		from := endpointString(e.From.ID, e.FromPort, e.FromCompass)
+		to := endpointString(e.To.ID, e.ToPort, e.ToCompass)
+		newFrom := endpointString(edge.From.ID, edge.FromPort, edge.FromCompass)
+		newTo := endpointString(edge.To.ID, edge.ToPort, edge.ToCompass)
+		if (from == newFrom && to == newTo) || (!edge.Directed && from == newTo && to == newFrom) {
+			parallel = append(parallel, e)
+		}
+	}
+	return parallel
+}
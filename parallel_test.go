@@ -0,0 +1,82 @@
+package dot
+
+import (
+	"testing"
+)
+
+func TestParallelKeep(t *testing.T) {
+	g := NewGraph("testGraph")
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	g.AddEdge(a, b, true, "")
+	g.AddEdgeWithKey(a, b, true, "", "pin")
+
+	expected := `digraph testGraph {
+a -> b
+a -> b
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestParallelLabel(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.Parallel = ParallelLabel
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	g.AddEdge(a, b, true, "")
+	g.AddEdge(b, a, true, "")
+	g.AddEdgeWithKey(a, b, true, "", "pin")
+	e := g.AddEdgeWithKey(a, b, true, "", "unpin")
+	e.Label = "remove"
+
+	expected := `digraph testGraph {
+a -> b [ label="1" ]
+b -> a
+a -> b [ label="pin" ]
+a -> b [ label="remove" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestParallelCollapse(t *testing.T) {
+	g := NewUndirectedGraph("testGraph")
+	g.Parallel = ParallelCollapse
+	a := &VertexDescription{ID: "a"}
+	b := &VertexDescription{ID: "b"}
+	c := &VertexDescription{ID: "c"}
+	first := g.AddEdgeWithKey(a, b, false, "", "x")
+	g.AddEdge(b, a, false, "")
+	if e := g.AddEdgeWithKey(a, b, false, "", "y"); e != first {
+		t.Error("expected the edge to be collapsed into the first one")
+	}
+	g.AddEdge(a, c, false, "")
+
+	expected := `graph testGraph {
+a -- b [ label="3" ]
+a -- c
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestParallelSelfLoop(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.Parallel = ParallelCollapse
+	a := &VertexDescription{ID: "a"}
+	first := g.AddSelfLoop(a, SelfLoopOptions{})
+	if e := g.AddSelfLoop(a, SelfLoopOptions{}); e != first {
+		t.Error("expected the loop to be collapsed into the first one")
+	}
+
+	expected := `digraph testGraph {
+a:ne -> a:se [ label="2" ]
+}`
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
@@ -0,0 +1,793 @@
+package dot
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Parse reads a dot-file from r and returns the corresponding Graph.
+// Vertex and edge statements are parsed into VertexDescription and
+// EdgeDescription elements, known attributes are stored in the matching
+// struct fields and all others in Attrs.  Values a struct field cannot hold
+// are kept in Attrs, since graphviz only warns about them, see
+// ParseWithWarnings.  Line comments at the end of a vertex or edge
+// statement are stored in its Comment.  Other comments and statements
+// without a typed representation are kept as Literal elements so that
+// writing the returned graph produces an equivalent dot-file.
+func Parse(r io.Reader) (*Graph, error) {
+	graph, _, err := ParseWithWarnings(r)
+	return graph, err
+}
+
+// ParseWithWarnings is like Parse, and also returns a warning for each
+// attribute value a struct field cannot hold, such as width=wide, which is
+// kept in Attrs
+func ParseWithWarnings(r io.Reader) (*Graph, []error, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &parser{lex: &lexer{src: string(src), line: 1}}
+	if err := p.next(); err != nil {
+		return nil, nil, err
+	}
+	graph, err := p.parseGraph()
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, nil, p.errorf("unexpected %s after graph", p.tok)
+	}
+	return graph, p.warnings, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokID
+	tokKeyword
+	tokComment
+	tokEdgeOp
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// quoted is set for IDs which were written as double-quoted strings
+	quoted bool
+	line   int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of file"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// lexer splits dot source into tokens.
+type lexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (l *lexer) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", l.line, fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) peekByte(offset int) byte {
+	if l.pos+offset < len(l.src) {
+		return l.src[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\n':
+			l.line++
+		case ' ', '\t', '\r', '\f', '\v':
+		default:
+			return
+		}
+		l.pos++
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipSpace()
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, line: l.line}, nil
+	}
+	line := l.line
+	c := l.src[l.pos]
+	switch {
+	case c == '/' && l.peekByte(1) == '*':
+		end := strings.Index(l.src[l.pos+2:], "*/")
+		if end < 0 {
+			return token{}, l.errorf("unterminated comment")
+		}
+		text := l.src[l.pos : l.pos+end+4]
+		l.line += strings.Count(text, "\n")
+		l.pos += len(text)
+		return token{kind: tokComment, text: text, line: line}, nil
+	case (c == '/' && l.peekByte(1) == '/') || (c == '#' && l.atLineStart()):
+		end := strings.IndexByte(l.src[l.pos:], '\n')
+		if end < 0 {
+			end = len(l.src) - l.pos
+		}
+		text := strings.TrimRight(l.src[l.pos:l.pos+end], "\r")
+		l.pos += end
+		return token{kind: tokComment, text: text, line: line}, nil
+	case c == '-' && (l.peekByte(1) == '>' || l.peekByte(1) == '-'):
+		l.pos += 2
+		return token{kind: tokEdgeOp, text: l.src[l.pos-2 : l.pos], line: line}, nil
+	case strings.IndexByte("{}[];,=:", c) >= 0:
+		l.pos++
+		return token{kind: tokPunct, text: string(c), line: line}, nil
+	case c == '"':
+		return l.lexQuoted()
+	case c == '<':
+		return l.lexHTML()
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		start := l.pos
+		l.pos++
+		for l.pos < len(l.src) && (l.src[l.pos] == '.' || (l.src[l.pos] >= '0' && l.src[l.pos] <= '9')) {
+			l.pos++
+		}
+		text := l.src[start:l.pos]
+		if !isNumeral(text) {
+			return token{}, l.errorf("invalid numeral %q", text)
+		}
+		return token{kind: tokID, text: text, line: line}, nil
+	case isIDByte(c):
+		start := l.pos
+		for l.pos < len(l.src) && (isIDByte(l.src[l.pos]) || (l.src[l.pos] >= '0' && l.src[l.pos] <= '9')) {
+			l.pos++
+		}
+		text := l.src[start:l.pos]
+		if keywords[strings.ToLower(text)] {
+			return token{kind: tokKeyword, text: strings.ToLower(text), line: line}, nil
+		}
+		return token{kind: tokID, text: text, line: line}, nil
+	}
+	return token{}, l.errorf("unexpected character %q", c)
+}
+
+// atLineStart reports whether only whitespace precedes the current position
+// on its line.
+func (l *lexer) atLineStart() bool {
+	for i := l.pos - 1; i >= 0; i-- {
+		switch l.src[i] {
+		case '\n':
+			return true
+		case ' ', '\t', '\r':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isIDByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+// lexQuoted reads a double-quoted string, including any '+' concatenations.
+// The token text is the raw content between the quotes, with escape sequences
+// kept as is.
+func (l *lexer) lexQuoted() (token, error) {
+	line := l.line
+	var b strings.Builder
+	for {
+		l.pos++ // opening quote
+		for {
+			if l.pos >= len(l.src) {
+				return token{}, l.errorf("unterminated string")
+			}
+			c := l.src[l.pos]
+			if c == '"' {
+				l.pos++
+				break
+			}
+			if c == '\\' && l.peekByte(1) == '\n' {
+				// line continuation
+				l.line++
+				l.pos += 2
+				continue
+			}
+			if c == '\\' && l.pos+1 < len(l.src) {
+				b.WriteString(l.src[l.pos : l.pos+2])
+				l.pos += 2
+				continue
+			}
+			if c == '\n' {
+				l.line++
+			}
+			b.WriteByte(c)
+			l.pos++
+		}
+		// look for a '+' concatenation
+		save, saveLine := l.pos, l.line
+		l.skipSpace()
+		if l.peekByte(0) == '+' {
+			l.pos++
+			l.skipSpace()
+			if l.peekByte(0) == '"' {
+				continue
+			}
+		}
+		l.pos, l.line = save, saveLine
+		return token{kind: tokID, text: b.String(), quoted: true, line: line}, nil
+	}
+}
+
+// lexHTML reads an HTML string, keeping the enclosing angle brackets.
+func (l *lexer) lexHTML() (token, error) {
+	line := l.line
+	start := l.pos
+	depth := 0
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case '\n':
+			l.line++
+		}
+		l.pos++
+		if depth == 0 {
+			return token{kind: tokID, text: l.src[start:l.pos], line: line}, nil
+		}
+	}
+	return token{}, l.errorf("unterminated HTML string")
+}
+
+// parser builds a Graph from the token stream.
+type parser struct {
+	lex *lexer
+	tok token
+	// last is the token before the current token
+	last token
+	// comments seen before the current token
+	comments []token
+	// warnings about attribute values kept in Attrs
+	warnings []error
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.tok.line, fmt.Sprintf(format, args...))
+}
+
+// warn records err, if any, as a warning about the given line
+func (p *parser) warn(line int, err error) {
+	if err != nil {
+		p.warnings = append(p.warnings, fmt.Errorf("line %d: %s", line, err))
+	}
+}
+
+// next advances to the next non comment token.  Skipped comments are
+// remembered so that statement level comments can be preserved.
+func (p *parser) next() error {
+	for {
+		tok, err := p.lex.next()
+		if err != nil {
+			return err
+		}
+		if tok.kind != tokComment {
+			p.last, p.tok = p.tok, tok
+			return nil
+		}
+		p.comments = append(p.comments, tok)
+	}
+}
+
+func (p *parser) is(kind tokenKind, text string) bool {
+	return p.tok.kind == kind && p.tok.text == text
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	if !p.is(kind, text) {
+		return p.errorf("expected %q, found %s", text, p.tok)
+	}
+	return p.next()
+}
+
+// flushComments adds any pending comments to the graph body.
+func (p *parser) flushComments(graph *Graph) {
+	for _, c := range p.comments {
+		graph.Body = append(graph.Body, &Literal{Line: c.text})
+	}
+	p.comments = nil
+}
+
+func (p *parser) parseGraph() (*Graph, error) {
+	graph := &Graph{}
+	if p.is(tokKeyword, "strict") {
+		graph.IsStrict = true
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case p.is(tokKeyword, "graph"):
+		graph.IsUndirected = true
+	case p.is(tokKeyword, "digraph"):
+	default:
+		return nil, p.errorf("expected \"graph\" or \"digraph\", found %s", p.tok)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokID {
+		graph.Name = idValue(p.tok)
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	// comments preceding the graph have no place in the body
+	p.comments = nil
+	if err := p.expect(tokPunct, "{"); err != nil {
+		return nil, err
+	}
+	if err := p.parseStmtList(graph); err != nil {
+		return nil, err
+	}
+	p.comments = nil
+	return graph, nil
+}
+
+// parseStmtList parses statements into graph up to and including the
+// closing brace.
+func (p *parser) parseStmtList(graph *Graph) error {
+	for {
+		p.flushComments(graph)
+		if p.is(tokPunct, "}") {
+			return p.next()
+		}
+		if p.tok.kind == tokEOF {
+			return p.errorf("expected \"}\", found %s", p.tok)
+		}
+		n := len(graph.Body)
+		if err := p.parseStmt(graph); err != nil {
+			return err
+		}
+		if p.is(tokPunct, ";") {
+			if err := p.next(); err != nil {
+				return err
+			}
+		}
+		if len(graph.Body) > n {
+			p.attachComment(graph)
+		}
+	}
+}
+
+// attachComment sets a line comment following a vertex or edge statement on
+// the same line as the Comment of the element.  It is called after
+// statements which appended an element to the body.
+func (p *parser) attachComment(graph *Graph) {
+	if len(graph.Body) == 0 {
+		return
+	}
+	for i, c := range p.comments {
+		if c.line != p.last.line || !strings.HasPrefix(c.text, "//") {
+			continue
+		}
+		text := strings.TrimPrefix(strings.TrimPrefix(c.text, "//"), " ")
+		switch e := graph.Body[len(graph.Body)-1].(type) {
+		case *VertexDescription:
+			e.Comment = text
+		case *EdgeDescription:
+			e.Comment = text
+		default:
+			return
+		}
+		p.comments = append(p.comments[:i], p.comments[i+1:]...)
+		return
+	}
+}
+
+func (p *parser) parseStmt(graph *Graph) error {
+	switch {
+	case p.is(tokKeyword, "graph"), p.is(tokKeyword, "node"), p.is(tokKeyword, "edge"):
+		kind := p.tok.text
+		if err := p.next(); err != nil {
+			return err
+		}
+		attrs, err := p.parseAttrList()
+		if err != nil {
+			return err
+		}
+		switch {
+		case kind == "graph":
+			for _, a := range attrs {
+				p.warn(a.line, setGraphAttr(graph, a.name, a.value))
+			}
+		case hasStatements(graph):
+			// defaults only apply to statements that follow them, so
+			// keep them in place
+			graph.Body = append(graph.Body, &Literal{Line: kind + " " + formatAttrList(attrs)})
+		case kind == "node":
+			if graph.NodeDefaults == nil {
+				graph.NodeDefaults = &VertexDescription{}
+			}
+			for _, a := range attrs {
+				p.warn(a.line, setVertexAttr(graph.NodeDefaults, a.name, a.value))
+			}
+		case kind == "edge":
+			if graph.EdgeDefaults == nil {
+				graph.EdgeDefaults = &EdgeDescription{}
+			}
+			for _, a := range attrs {
+				p.warn(a.line, setEdgeAttr(graph.EdgeDefaults, a.name, a.value))
+			}
+		}
+		return nil
+	case p.is(tokKeyword, "subgraph"), p.is(tokPunct, "{"):
+		sub, err := p.parseSubgraph()
+		if err != nil {
+			return err
+		}
+		graph.Body = append(graph.Body, sub)
+		if p.tok.kind == tokEdgeOp {
+			return p.parseEdgeRHS(graph, subgraphEndpoints(sub))
+		}
+		return nil
+	case p.tok.kind == tokID:
+		id := p.tok
+		if err := p.next(); err != nil {
+			return err
+		}
+		if p.is(tokPunct, "=") {
+			if err := p.next(); err != nil {
+				return err
+			}
+			if p.tok.kind != tokID {
+				return p.errorf("expected attribute value, found %s", p.tok)
+			}
+			p.warn(p.tok.line, setGraphAttr(graph, id.text, attrValue(id.text, p.tok)))
+			return p.next()
+		}
+		from := endpoint{id: idValue(id)}
+		if err := p.parsePort(&from); err != nil {
+			return err
+		}
+		if p.tok.kind == tokEdgeOp {
+			return p.parseEdgeRHS(graph, []endpoint{from})
+		}
+		attrs, err := p.parseAttrList()
+		if err != nil {
+			return err
+		}
+		v := NewVertexDescription(idValue(id))
+		for _, a := range attrs {
+			p.warn(a.line, setVertexAttr(&v, a.name, a.value))
+		}
+		graph.AddVertex(&v)
+		return nil
+	}
+	return p.errorf("unexpected %s", p.tok)
+}
+
+func (p *parser) parseSubgraph() (*Graph, error) {
+	sub := &Graph{IsSubGraph: true}
+	if p.is(tokKeyword, "subgraph") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokID {
+			sub.Name = idValue(p.tok)
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := p.expect(tokPunct, "{"); err != nil {
+		return nil, err
+	}
+	if err := p.parseStmtList(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// endpoint is an edge operand: a vertex ID with an optional port
+type endpoint struct {
+	id, port, compass string
+}
+
+// parsePort parses an optional port following a vertex ID into ep.
+func (p *parser) parsePort(ep *endpoint) error {
+	if !p.is(tokPunct, ":") {
+		return nil
+	}
+	if err := p.next(); err != nil {
+		return err
+	}
+	if p.tok.kind != tokID {
+		return p.errorf("expected port, found %s", p.tok)
+	}
+	port := p.tok
+	if err := p.next(); err != nil {
+		return err
+	}
+	if !p.is(tokPunct, ":") {
+		// a lone compass point is not a port name
+		if !port.quoted && isCompassPoint(port.text) {
+			ep.compass = port.text
+		} else {
+			ep.port = idValue(port)
+		}
+		return nil
+	}
+	if err := p.next(); err != nil {
+		return err
+	}
+	if p.tok.kind != tokID || p.tok.quoted || !isCompassPoint(p.tok.text) {
+		return p.errorf("expected compass point, found %s", p.tok)
+	}
+	ep.port = idValue(port)
+	ep.compass = p.tok.text
+	return p.next()
+}
+
+// parseEdgeRHS parses the remainder of an edge statement whose first operand
+// has already been read.  One EdgeDescription is added for every pair of
+// vertices joined by an edge operator.
+func (p *parser) parseEdgeRHS(graph *Graph, from []endpoint) error {
+	type hop struct {
+		from, to []endpoint
+		directed bool
+	}
+	var hops []hop
+	for p.tok.kind == tokEdgeOp {
+		directed := p.tok.text == "->"
+		if err := p.next(); err != nil {
+			return err
+		}
+		var to []endpoint
+		switch {
+		case p.tok.kind == tokID:
+			ep := endpoint{id: idValue(p.tok)}
+			if err := p.next(); err != nil {
+				return err
+			}
+			if err := p.parsePort(&ep); err != nil {
+				return err
+			}
+			to = []endpoint{ep}
+		case p.is(tokKeyword, "subgraph"), p.is(tokPunct, "{"):
+			sub, err := p.parseSubgraph()
+			if err != nil {
+				return err
+			}
+			graph.Body = append(graph.Body, sub)
+			to = subgraphEndpoints(sub)
+		default:
+			return p.errorf("expected edge operand, found %s", p.tok)
+		}
+		hops = append(hops, hop{from: from, to: to, directed: directed})
+		from = to
+	}
+	attrs, err := p.parseAttrList()
+	if err != nil {
+		return err
+	}
+	for _, h := range hops {
+		for _, f := range h.from {
+			for _, t := range h.to {
+				e := &EdgeDescription{
+					From:        NewVertexDescription(f.id),
+					FromPort:    f.port,
+					FromCompass: f.compass,
+					To:          NewVertexDescription(t.id),
+					ToPort:      t.port,
+					ToCompass:   t.compass,
+					Directed:    h.directed,
+				}
+				for _, a := range attrs {
+					p.warn(a.line, setEdgeAttr(e, a.name, a.value))
+				}
+				graph.Body = append(graph.Body, e)
+			}
+		}
+	}
+	return nil
+}
+
+type parsedAttr struct {
+	name, value string
+	// line is the line of the value
+	line int
+}
+
+// parseAttrList parses zero or more bracketed attribute lists.
+func (p *parser) parseAttrList() ([]parsedAttr, error) {
+	var attrs []parsedAttr
+	for p.is(tokPunct, "[") {
+		// comments within the list are dropped
+		comments := p.comments
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		for !p.is(tokPunct, "]") {
+			if p.tok.kind != tokID {
+				return nil, p.errorf("expected attribute name, found %s", p.tok)
+			}
+			name := p.tok.text
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokPunct, "="); err != nil {
+				return nil, err
+			}
+			if p.tok.kind != tokID {
+				return nil, p.errorf("expected attribute value, found %s", p.tok)
+			}
+			attrs = append(attrs, parsedAttr{name: name, value: attrValue(name, p.tok), line: p.tok.line})
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if p.is(tokPunct, ",") || p.is(tokPunct, ";") {
+				if err := p.next(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		p.comments = comments
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	return attrs, nil
+}
+
+// attrValue returns the value of attribute name as it is stored in the
+// description structs.  Escape sequences in quoted values are preserved so
+// that they are written back unchanged.  Quoted labels which would be taken
+// for HTML strings keep their quotes, see writeAttr.
+func attrValue(name string, tok token) string {
+	if tok.quoted && htmlAttrs[name] && isHTML(tok.text) {
+		return `"` + tok.text + `"`
+	}
+	return tok.text
+}
+
+// idValue returns the ID represented by tok, undoing the escaping applied by
+// QuoteID.
+func idValue(tok token) string {
+	if !tok.quoted {
+		return tok.text
+	}
+	var b strings.Builder
+	for i := 0; i < len(tok.text); i++ {
+		if tok.text[i] == '\\' && i+1 < len(tok.text) && (tok.text[i+1] == '"' || tok.text[i+1] == '\\') {
+			i++
+		}
+		b.WriteByte(tok.text[i])
+	}
+	return b.String()
+}
+
+// formatAttrList formats parsed attributes as a dot attribute list.
+func formatAttrList(attrs []parsedAttr) string {
+	strs := make([]string, len(attrs))
+	for i, a := range attrs {
+		strs[i] = attrString(a.name, a.value)
+	}
+	return "[" + strings.Join(strs, " ") + "]"
+}
+
+// hasStatements reports whether any vertices, edges or subgraphs have been
+// added to graph
+func hasStatements(graph *Graph) bool {
+	for _, elem := range graph.Body {
+		if _, ok := elem.(*Literal); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// subgraphEndpoints returns the vertices declared in sub and its nested
+// subgraphs, which are the endpoints of edges to or from sub.
+func subgraphEndpoints(sub *Graph) []endpoint {
+	ids := subgraphVertexIDs(sub)
+	endpoints := make([]endpoint, len(ids))
+	for i, id := range ids {
+		endpoints[i] = endpoint{id: id}
+	}
+	return endpoints
+}
+
+// subgraphVertexIDs returns the IDs of the vertices declared in sub and its
+// nested subgraphs.
+func subgraphVertexIDs(sub *Graph) []string {
+	var ids []string
+	for _, elem := range sub.Body {
+		switch e := elem.(type) {
+		case *VertexDescription:
+			ids = append(ids, e.ID)
+		case *EdgeDescription:
+			ids = append(ids, e.From.ID, e.To.ID)
+		case *Graph:
+			ids = append(ids, subgraphVertexIDs(e)...)
+		}
+	}
+	seen := make(map[string]bool)
+	unique := ids[:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// setGraphAttr stores a graph attribute in the matching Graph field, or in
+// Attrs when there is none or the value is the zero value of the field.  A
+// value the field cannot hold is stored in Attrs and returned as an error.
+func setGraphAttr(graph *Graph, name, value string) error {
+	if field, ok := graphFieldIndex[name]; ok {
+		err := field.set(graph, value)
+		if err == nil && field.get(graph) != "" {
+			delete(graph.Attrs, name)
+			return nil
+		}
+		if err != nil {
+			graph.AddAttribute(name, value)
+			return err
+		}
+	}
+	graph.AddAttribute(name, value)
+	return nil
+}
+
+// setVertexAttr stores a vertex attribute in the VertexDescription field of
+// the same name, or in Attrs when there is none or the value is the zero
+// value of the field.  A value the field cannot hold is stored in Attrs and
+// returned as an error.
+func setVertexAttr(v *VertexDescription, name, value string) error {
+	if field, ok := vertexFieldIndex[name]; ok {
+		err := field.set(v, value)
+		if err == nil && field.get(v) != "" {
+			delete(v.Attrs, name)
+			return nil
+		}
+		if err != nil {
+			v.AddAttribute(name, value)
+			return err
+		}
+	}
+	v.AddAttribute(name, value)
+	return nil
+}
+
+// setEdgeAttr stores an edge attribute in the EdgeDescription field of the
+// same name, or in Attrs when there is none or the value is the zero value
+// of the field.  A value the field cannot hold is stored in Attrs and
+// returned as an error.
+func setEdgeAttr(e *EdgeDescription, name, value string) error {
+	if field, ok := edgeFieldIndex[name]; ok {
+		err := field.set(e, value)
+		if err == nil && field.get(e) != "" {
+			delete(e.Attrs, name)
+			return nil
+		}
+		if err != nil {
+			e.AddAttribute(name, value)
+			return err
+		}
+	}
+	e.AddAttribute(name, value)
+	return nil
+}
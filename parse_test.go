@@ -0,0 +1,182 @@
+package dot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	g, err := Parse(strings.NewReader(basicGraph))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	err = g.Write(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// blank lines are not preserved
+	expected := strings.Replace(basicGraph, "\n\n", "\n", -1)
+	s := buf.String()
+	if s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", expected)
+	}
+}
+
+var parseGraph = `/* leading comment */
+strict graph "my graph" {
+	rankdir=LR
+	graph [rank=same]
+	node [shape=box]
+	"peer-1" [label="Peer \"one\"", peripheries=2, tooltip=tip];
+	p2 [label=<<b>two</b>>]
+	"peer-1" -- p2 -- p3 [style=dashed weight=3]
+	subgraph inner {
+		// inner comment
+		a; b
+	} -- c
+	d
+	edge [color=red]
+}
+`
+
+func TestParse(t *testing.T) {
+	g, err := Parse(strings.NewReader(parseGraph))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.IsStrict || !g.IsUndirected || g.IsSubGraph {
+		t.Errorf("unexpected graph flags: %+v", g)
+	}
+	if g.Name != "my graph" || g.RankDir != "LR" || g.Rank != "same" {
+		t.Errorf("unexpected graph attributes: %+v", g)
+	}
+	if g.NodeDefaults == nil || g.NodeDefaults.Shape != "box" {
+		t.Errorf("unexpected node defaults %+v", g.NodeDefaults)
+	}
+	if len(g.Body) != 9 {
+		t.Fatalf("unexpected body length %d", len(g.Body))
+	}
+	lit, ok := g.Body[8].(*Literal)
+	if !ok || lit.Line != `edge [color="red"]` {
+		t.Errorf("unexpected element %#v", g.Body[8])
+	}
+	v, ok := g.Body[0].(*VertexDescription)
+	if !ok {
+		t.Fatalf("unexpected element %#v", g.Body[0])
+	}
+	if v.ID != "peer-1" || v.Label != `Peer \"one\"` || v.Peripheries != 2 || v.Tooltip != "tip" {
+		t.Errorf("unexpected vertex %+v", v)
+	}
+	v, ok = g.Body[1].(*VertexDescription)
+	if !ok || v.Label != "<<b>two</b>>" {
+		t.Errorf("unexpected element %#v", g.Body[1])
+	}
+	for i, ids := range [][2]string{{"peer-1", "p2"}, {"p2", "p3"}} {
+		e, ok := g.Body[2+i].(*EdgeDescription)
+		if !ok {
+			t.Fatalf("unexpected element %#v", g.Body[2+i])
+		}
+		if e.From.ID != ids[0] || e.To.ID != ids[1] || e.Directed || e.Style != "dashed" || e.Weight != 3 {
+			t.Errorf("unexpected edge %+v", e)
+		}
+	}
+	sub, ok := g.Body[4].(*Graph)
+	if !ok || !sub.IsSubGraph || sub.Name != "inner" || len(sub.Body) != 3 {
+		t.Fatalf("unexpected element %#v", g.Body[4])
+	}
+	if lit, ok := sub.Body[0].(*Literal); !ok || lit.Line != "// inner comment" {
+		t.Errorf("unexpected element %#v", sub.Body[0])
+	}
+	for i, from := range []string{"a", "b"} {
+		e, ok := g.Body[5+i].(*EdgeDescription)
+		if !ok || e.From.ID != from || e.To.ID != "c" {
+			t.Errorf("unexpected element %#v", g.Body[5+i])
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		``,
+		`digraph {`,
+		`digraph { a -> }`,
+		`digraph { a [label] }`,
+		`digraph { a [label="x }`,
+		`digraph { } digraph { }`,
+		`node { }`,
+	}
+	for _, test := range tests {
+		if _, err := Parse(strings.NewReader(test)); err == nil {
+			t.Errorf("expected error parsing %q", test)
+		}
+	}
+}
+
+func TestParseInvalidValues(t *testing.T) {
+	src := `digraph  {
+width="abc"
+a [label="A" peripheries="many" width="abc" ]
+a -> b [ weight="heavy" ]
+}`
+	g, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := g.FindVertex("a")
+	if v.Label != "A" || v.Attrs["width"] != "abc" || v.Attrs["peripheries"] != "many" {
+		t.Errorf("unexpected vertex %+v", v)
+	}
+	if s := writeString(t, g); s != src {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	_, warnings, err := ParseWithWarnings(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 3 || !strings.HasPrefix(warnings[0].Error(), "line 3: ") || !strings.HasPrefix(warnings[2].Error(), "line 4: ") {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+}
+
+func TestParseQuotedHTMLLabel(t *testing.T) {
+	src := `digraph  {
+a [label="<x>" ]
+b [label=<<B>b</B>> ]
+a -> b [ label="<1..*>" headlabel=<<I>n</I>> ]
+}`
+	g, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := writeString(t, g); s != src {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
+
+func TestParsePorts(t *testing.T) {
+	g, err := Parse(strings.NewReader(`digraph { a:out:se -> b:n -> c:"in 1" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []EdgeDescription{
+		{FromPort: "out", FromCompass: "se", ToCompass: "n"},
+		{FromCompass: "n", ToPort: "in 1"},
+	}
+	if len(g.Body) != len(expected) {
+		t.Fatalf("unexpected body length %d", len(g.Body))
+	}
+	for i, exp := range expected {
+		e := g.Body[i].(*EdgeDescription)
+		if e.FromPort != exp.FromPort || e.FromCompass != exp.FromCompass ||
+			e.ToPort != exp.ToPort || e.ToCompass != exp.ToCompass {
+			t.Errorf("unexpected edge %+v", e)
+		}
+	}
+	if _, err := Parse(strings.NewReader(`digraph { a:p:q -> b }`)); err == nil {
+		t.Error("expected error for invalid compass point")
+	}
+}
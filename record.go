@@ -0,0 +1,85 @@
+package dot
+
+import (
+	"strings"
+)
+
+// RecordField is a field of a record label, as used by vertices with shape
+// "record" or "Mrecord".  A field either holds text, with an optional port
+// name that edges can attach to, or a list of nested fields which graphviz
+// lays out in the opposite direction to the enclosing list.
+type RecordField struct {
+	Port   string
+	Text   string
+	Fields []RecordField
+}
+
+// Field returns a record field holding the given text
+func Field(text string) RecordField {
+	return RecordField{
+		Text: text,
+	}
+}
+
+// PortField returns a record field holding the given text which can be
+// referenced by edges through the given port name
+func PortField(port, text string) RecordField {
+	return RecordField{
+		Port: port,
+		Text: text,
+	}
+}
+
+// NestedFields returns a record field made of the given fields, flipped
+// between horizontal and vertical layout
+func NestedFields(fields ...RecordField) RecordField {
+	return RecordField{
+		Fields: fields,
+	}
+}
+
+// RecordLabel returns the label for a record vertex made of the given fields.
+// Characters with special meaning in record labels are escaped in field
+// text and port names.
+func RecordLabel(fields ...RecordField) string {
+	var b strings.Builder
+	writeRecordFields(&b, fields)
+	return b.String()
+}
+
+func writeRecordFields(b *strings.Builder, fields []RecordField) {
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteByte('|')
+		}
+		if field.Fields != nil {
+			b.WriteByte('{')
+			writeRecordFields(b, field.Fields)
+			b.WriteByte('}')
+			continue
+		}
+		if field.Port != "" {
+			// the trailing space keeps a label ending in a port from being
+			// mistaken for an html like label
+			b.WriteString("<" + EscapeRecord(field.Port) + "> ")
+		}
+		b.WriteString(EscapeRecord(field.Text))
+	}
+}
+
+var recordEscaper = strings.NewReplacer(
+	"{", `\{`,
+	"}", `\}`,
+	"|", `\|`,
+	"<", `\<`,
+	">", `\>`,
+	`"`, `\"`,
+	" ", `\ `,
+)
+
+// EscapeRecord escapes the characters which delimit record fields and ports,
+// and spaces, which graphviz would otherwise trim or collapse, so that
+// arbitrary text can be used as a record field
+func EscapeRecord(s string) string {
+	return recordEscaper.Replace(s)
+}
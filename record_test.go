@@ -0,0 +1,41 @@
+package dot
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRecordLabel(t *testing.T) {
+	label := RecordLabel(
+		PortField("in", "input"),
+		NestedFields(
+			Field("a|b"),
+			PortField("mid", "{x}"),
+			Field(`say "<hi>"`),
+		),
+		PortField("out", ""),
+	)
+	expected := `<in> input|{a\|b|<mid> \{x\}|say\ \"\<hi\>\"}|<out> `
+	if label != expected {
+		t.Errorf("unexpected label %s, expected %s", label, expected)
+	}
+
+	buf := new(bytes.Buffer)
+	v := &VertexDescription{ID: "r", Shape: "record", Label: label}
+	err := v.Write(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `r [label="` + expected + `" shape="record" ]`
+	if buf.String() != expected {
+		t.Errorf("unexpected output %s, expected %s", buf.String(), expected)
+	}
+}
+
+func TestEscapeRecord(t *testing.T) {
+	s := EscapeRecord("peer {1} | <id>")
+	expected := `peer\ \{1\}\ \|\ \<id\>`
+	if s != expected {
+		t.Errorf("unexpected escaped text %s, expected %s", s, expected)
+	}
+}
@@ -0,0 +1,74 @@
+// Package render lays out and renders dot graphs with a local graphviz
+// installation
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	dot "github.com/zenground0/go-dot"
+)
+
+// Layout engines shipped with graphviz
+const (
+	Dot       = "dot"
+	Neato     = "neato"
+	Fdp       = "fdp"
+	Sfdp      = "sfdp"
+	Circo     = "circo"
+	Twopi     = "twopi"
+	Osage     = "osage"
+	Patchwork = "patchwork"
+)
+
+// Common output formats
+const (
+	SVG   = "svg"
+	PNG   = "png"
+	PDF   = "pdf"
+	Plain = "plain"
+	Xdot  = "xdot"
+	JSON  = "json"
+)
+
+// Render pipes graph through the given graphviz engine and returns the
+// output in the given format.  The engine is either the name of a binary
+// in PATH or a path to one, and defaults to dot when empty.  If the engine
+// fails the error includes what it wrote to stderr.  The engine is killed
+// when ctx is done.
+func Render(ctx context.Context, graph *dot.Graph, format, engine string) ([]byte, error) {
+	if engine == "" {
+		engine = Dot
+	}
+	if format == "" {
+		return nil, fmt.Errorf("render: no output format")
+	}
+	path, err := exec.LookPath(engine)
+	if err != nil {
+		return nil, fmt.Errorf("render: %v", err)
+	}
+
+	in := new(bytes.Buffer)
+	if err := graph.Write(in); err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "-T"+format)
+	cmd.Stdin = in
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return nil, fmt.Errorf("render: %s: %v", engine, err)
+		}
+		return nil, fmt.Errorf("render: %s: %v: %s", engine, err, msg)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,85 @@
+package render
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	dot "github.com/zenground0/go-dot"
+)
+
+func testGraph() *dot.Graph {
+	g := dot.NewGraph("test")
+	a := dot.NewVertexDescription("a")
+	b := dot.NewVertexDescription("b")
+	g.AddEdge(&a, &b, true, "")
+	return &g
+}
+
+// fakeEngine writes a shell script standing in for a graphviz binary
+func fakeEngine(t *testing.T, script string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake engines are shell scripts")
+	}
+	dir, err := ioutil.TempDir("", "render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "engine")
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRenderPipesGraph(t *testing.T) {
+	engine := fakeEngine(t, `echo "$1"; cat`)
+	defer os.RemoveAll(filepath.Dir(engine))
+
+	out, err := Render(context.Background(), testGraph(), SVG, engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "-Tsvg\ndigraph test {\na -> b\n}"
+	if string(out) != expected {
+		t.Errorf("unexpected output: \n%s\n", out)
+	}
+}
+
+func TestRenderStderr(t *testing.T) {
+	engine := fakeEngine(t, `echo "syntax error in line 1" >&2; exit 1`)
+	defer os.RemoveAll(filepath.Dir(engine))
+
+	_, err := Render(context.Background(), testGraph(), PNG, engine)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "syntax error in line 1") {
+		t.Errorf("stderr missing from error: %s", err)
+	}
+}
+
+func TestRenderMissingEngine(t *testing.T) {
+	_, err := Render(context.Background(), testGraph(), SVG, "no-such-graphviz-engine")
+	if err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestRenderDot(t *testing.T) {
+	if _, err := exec.LookPath(Dot); err != nil {
+		t.Skip("graphviz is not installed")
+	}
+	out, err := Render(context.Background(), testGraph(), SVG, Dot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "<svg") {
+		t.Errorf("unexpected output: \n%s\n", out)
+	}
+}
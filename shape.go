@@ -0,0 +1,103 @@
+package dot
+
+import "fmt"
+
+// Shape is the shape of a vertex
+type Shape string
+
+// Vertex shapes supported by graphviz
+const (
+	ShapeBox             Shape = "box"
+	ShapePolygon         Shape = "polygon"
+	ShapeEllipse         Shape = "ellipse"
+	ShapeOval            Shape = "oval"
+	ShapeCircle          Shape = "circle"
+	ShapePoint           Shape = "point"
+	ShapeEgg             Shape = "egg"
+	ShapeTriangle        Shape = "triangle"
+	ShapePlainText       Shape = "plaintext"
+	ShapePlain           Shape = "plain"
+	ShapeDiamond         Shape = "diamond"
+	ShapeTrapezium       Shape = "trapezium"
+	ShapeParallelogram   Shape = "parallelogram"
+	ShapeHouse           Shape = "house"
+	ShapePentagon        Shape = "pentagon"
+	ShapeHexagon         Shape = "hexagon"
+	ShapeSeptagon        Shape = "septagon"
+	ShapeOctagon         Shape = "octagon"
+	ShapeDoubleCircle    Shape = "doublecircle"
+	ShapeDoubleOctagon   Shape = "doubleoctagon"
+	ShapeTripleOctagon   Shape = "tripleoctagon"
+	ShapeInvTriangle     Shape = "invtriangle"
+	ShapeInvTrapezium    Shape = "invtrapezium"
+	ShapeInvHouse        Shape = "invhouse"
+	ShapeMDiamond        Shape = "Mdiamond"
+	ShapeMSquare         Shape = "Msquare"
+	ShapeMCircle         Shape = "Mcircle"
+	ShapeRect            Shape = "rect"
+	ShapeRectangle       Shape = "rectangle"
+	ShapeSquare          Shape = "square"
+	ShapeStar            Shape = "star"
+	ShapeNone            Shape = "none"
+	ShapeUnderline       Shape = "underline"
+	ShapeCylinder        Shape = "cylinder"
+	ShapeNote            Shape = "note"
+	ShapeTab             Shape = "tab"
+	ShapeFolder          Shape = "folder"
+	ShapeBox3D           Shape = "box3d"
+	ShapeComponent       Shape = "component"
+	ShapePromoter        Shape = "promoter"
+	ShapeCDS             Shape = "cds"
+	ShapeTerminator      Shape = "terminator"
+	ShapeUTR             Shape = "utr"
+	ShapePrimerSite      Shape = "primersite"
+	ShapeRestrictionSite Shape = "restrictionsite"
+	ShapeFivePOverhang   Shape = "fivepoverhang"
+	ShapeThreePOverhang  Shape = "threepoverhang"
+	ShapeNOverhang       Shape = "noverhang"
+	ShapeAssembly        Shape = "assembly"
+	ShapeSignature       Shape = "signature"
+	ShapeInsulator       Shape = "insulator"
+	ShapeRiboSite        Shape = "ribosite"
+	ShapeRNAStab         Shape = "rnastab"
+	ShapeProteaseSite    Shape = "proteasesite"
+	ShapeProteinStab     Shape = "proteinstab"
+	ShapeRPromoter       Shape = "rpromoter"
+	ShapeRArrow          Shape = "rarrow"
+	ShapeLArrow          Shape = "larrow"
+	ShapeLPromoter       Shape = "lpromoter"
+	ShapeRecord          Shape = "record"
+	ShapeMRecord         Shape = "Mrecord"
+)
+
+var shapes = map[Shape]bool{
+	ShapeBox: true, ShapePolygon: true, ShapeEllipse: true, ShapeOval: true,
+	ShapeCircle: true, ShapePoint: true, ShapeEgg: true, ShapeTriangle: true,
+	ShapePlainText: true, ShapePlain: true, ShapeDiamond: true,
+	ShapeTrapezium: true, ShapeParallelogram: true, ShapeHouse: true,
+	ShapePentagon: true, ShapeHexagon: true, ShapeSeptagon: true,
+	ShapeOctagon: true, ShapeDoubleCircle: true, ShapeDoubleOctagon: true,
+	ShapeTripleOctagon: true, ShapeInvTriangle: true, ShapeInvTrapezium: true,
+	ShapeInvHouse: true, ShapeMDiamond: true, ShapeMSquare: true,
+	ShapeMCircle: true, ShapeRect: true, ShapeRectangle: true,
+	ShapeSquare: true, ShapeStar: true, ShapeNone: true, ShapeUnderline: true,
+	ShapeCylinder: true, ShapeNote: true, ShapeTab: true, ShapeFolder: true,
+	ShapeBox3D: true, ShapeComponent: true, ShapePromoter: true,
+	ShapeCDS: true, ShapeTerminator: true, ShapeUTR: true,
+	ShapePrimerSite: true, ShapeRestrictionSite: true,
+	ShapeFivePOverhang: true, ShapeThreePOverhang: true,
+	ShapeNOverhang: true, ShapeAssembly: true, ShapeSignature: true,
+	ShapeInsulator: true, ShapeRiboSite: true, ShapeRNAStab: true,
+	ShapeProteaseSite: true, ShapeProteinStab: true, ShapeRPromoter: true,
+	ShapeRArrow: true, ShapeLArrow: true, ShapeLPromoter: true,
+	ShapeRecord: true, ShapeMRecord: true,
+}
+
+// Validate reports whether graphviz knows the shape.  The empty shape,
+// which leaves the default ellipse, is valid.
+func (s Shape) Validate() error {
+	if s != "" && !shapes[s] {
+		return fmt.Errorf("unknown shape %q", string(s))
+	}
+	return nil
+}
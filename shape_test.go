@@ -0,0 +1,28 @@
+package dot
+
+import (
+	"testing"
+)
+
+func TestShapeValidate(t *testing.T) {
+	for _, s := range []Shape{"", ShapeBox, ShapeMRecord, ShapeCylinder, ShapeLPromoter} {
+		if err := s.Validate(); err != nil {
+			t.Errorf("shape %q should be valid: %s", s, err)
+		}
+	}
+	for _, s := range []Shape{"bx", "Box", "mrecord"} {
+		if err := s.Validate(); err == nil {
+			t.Errorf("shape %q should be invalid", s)
+		}
+	}
+}
+
+func TestShapeOutput(t *testing.T) {
+	v := VertexDescription{ID: "a", Shape: ShapeDoubleCircle}
+	g := NewGraph("testGraph")
+	g.AddVertex(&v)
+	expected := "digraph testGraph {\na [shape=\"doublecircle\" ]\n}"
+	if s := writeString(t, &g); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+}
@@ -0,0 +1,84 @@
+package dot
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/zenground0/go-dot/htmllabel"
+)
+
+// LabelTemplate sets the label of the vertex to the output of the
+// text/template tmpl executed with data.  The values printed by the actions
+// of the template are escaped, so that they are shown verbatim: as HTML
+// text when the template is an HTML-like label enclosed in '<' and '>', and
+// with backslashes escaped and line breaks written as \n otherwise.  The
+// text of the template itself is used as is.
+func (v *VertexDescription) LabelTemplate(tmpl string, data interface{}) error {
+	label, err := executeLabelTemplate(tmpl, data)
+	if err != nil {
+		return err
+	}
+	v.Label = label
+	return nil
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\r\n", `\n`, "\n", `\n`)
+
+// executeLabelTemplate executes a label template, escaping the values
+// printed by its actions
+func executeLabelTemplate(tmpl string, data interface{}) (string, error) {
+	escape := labelValueEscaper.Replace
+	if isHTML(strings.TrimSpace(tmpl)) {
+		escape = htmllabel.Escape
+	}
+	t, err := template.New("label").Funcs(template.FuncMap{
+		"escapeLabelValue": func(value interface{}) string {
+			return escape(fmt.Sprint(value))
+		},
+	}).Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	for _, defined := range t.Templates() {
+		if defined.Tree != nil {
+			escapeActions(defined.Tree.Root)
+		}
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// escapeActions appends the escapeLabelValue function to the pipelines of
+// the actions printing a value in the template tree
+func escapeActions(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeActions(child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) == 0 {
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Args:     []parse.Node{parse.NewIdentifier("escapeLabelValue")},
+			})
+		}
+	case *parse.IfNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.RangeNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.WithNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	}
+}
@@ -0,0 +1,46 @@
+package dot
+
+import (
+	"testing"
+)
+
+func TestLabelTemplate(t *testing.T) {
+	peer := struct {
+		Name  string
+		Addrs []string
+		Pins  int
+	}{
+		Name:  `peer "1" \ <main>`,
+		Addrs: []string{"/ip4/1.2.3.4", "/ip6/::1"},
+		Pins:  3,
+	}
+
+	v := &VertexDescription{ID: "peer"}
+	err := v.LabelTemplate(`{{.Name}}\n{{range .Addrs}}{{.}}\l{{end}}{{if .Pins}}pins: {{.Pins}}{{end}}`, peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `peer "1" \\ <main>\n/ip4/1.2.3.4\l/ip6/::1\lpins: 3`
+	if v.Label != expected {
+		t.Errorf("unexpected label %s, expected %s", v.Label, expected)
+	}
+
+	err = v.LabelTemplate(`<<B>{{.Name}}</B>{{with $n := .Pins}}<BR/>{{$n}} pins{{end}}>`, peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `<<B>peer &quot;1&quot; \ &lt;main&gt;</B><BR/>3 pins>`
+	if v.Label != expected {
+		t.Errorf("unexpected label %s, expected %s", v.Label, expected)
+	}
+
+	if err := v.LabelTemplate(`{{.Name`, peer); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+	if err := v.LabelTemplate(`{{.Missing}}`, peer); err == nil {
+		t.Error("expected an error for a missing field")
+	}
+	if v.Label != expected {
+		t.Errorf("label changed by failed template: %s", v.Label)
+	}
+}
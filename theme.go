@@ -0,0 +1,97 @@
+package dot
+
+// Theme bundles the look of a graph: graph attributes, node and edge
+// defaults and a palette of colors to tell groups of vertices apart
+type Theme struct {
+	// Graph holds the graph attributes of the theme.  Its name, body and
+	// defaults are ignored.
+	Graph Graph
+	Node  VertexDescription
+	Edge  EdgeDescription
+
+	Palette []Color
+}
+
+// Apply sets the attributes and node and edge defaults of the theme on the
+// graph, replacing values already set
+func (t Theme) Apply(graph *Graph) {
+	src := t.Graph
+	src.Body = nil
+	src.NodeDefaults = nil
+	src.EdgeDefaults = nil
+	if len(t.Node.attributes()) > 0 {
+		src.NodeDefaults = &t.Node
+	}
+	if len(t.Edge.attributes()) > 0 {
+		src.EdgeDefaults = &t.Edge
+	}
+	graph.Merge(&src, MergeOptions{Overwrite: true})
+}
+
+// Color returns the i-th color of the palette, starting over once all
+// colors have been used.  It returns the empty color if the palette is
+// empty.
+func (t Theme) Color(i int) Color {
+	if len(t.Palette) == 0 {
+		return ""
+	}
+	return t.Palette[i%len(t.Palette)]
+}
+
+// CleanTheme returns a light theme of rounded boxes with a sans-serif font
+// and the Brewer set2 palette
+func CleanTheme() Theme {
+	return Theme{
+		Graph: Graph{
+			NodeSep:  0.4,
+			RankSep:  0.6,
+			FontName: "Helvetica",
+		},
+		Node: VertexDescription{
+			Shape:     ShapeBox,
+			Style:     "rounded,filled",
+			Color:     "#4a4a4a",
+			FillColor: "#f5f5f5",
+			FontName:  "Helvetica",
+			FontSize:  11,
+		},
+		Edge: EdgeDescription{
+			Color:     "#4a4a4a",
+			ArrowSize: 0.7,
+			FontSize:  9,
+			Attrs:     map[string]string{"fontname": "Helvetica"},
+		},
+		Palette: []Color{
+			"#66c2a5", "#fc8d62", "#8da0cb", "#e78ac3",
+			"#a6d854", "#ffd92f", "#e5c494", "#b3b3b3",
+		},
+	}
+}
+
+// DarkTheme returns a theme of light text and lines on a dark background,
+// with the Brewer dark2 palette
+func DarkTheme() Theme {
+	return Theme{
+		Graph: Graph{
+			BgColor:   "#1e1e1e",
+			FontName:  "Helvetica",
+			FontColor: "#e0e0e0",
+		},
+		Node: VertexDescription{
+			Shape:     ShapeBox,
+			Style:     "filled",
+			Color:     "#8f8f8f",
+			FillColor: "#2d2d2d",
+			FontColor: "#e0e0e0",
+			FontName:  "Helvetica",
+		},
+		Edge: EdgeDescription{
+			Color: "#8f8f8f",
+			Attrs: map[string]string{"fontcolor": "#e0e0e0", "fontname": "Helvetica"},
+		},
+		Palette: []Color{
+			"#1b9e77", "#d95f02", "#7570b3", "#e7298a",
+			"#66a61e", "#e6ab02", "#a6761d", "#666666",
+		},
+	}
+}
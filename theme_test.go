@@ -0,0 +1,61 @@
+package dot
+
+import (
+	"testing"
+)
+
+var themedGraph = `digraph testGraph {
+bgcolor="#1e1e1e"
+fontname="Helvetica"
+fontcolor="#e0e0e0"
+node [color="#8f8f8f" style="filled" fontcolor="#e0e0e0" fontname="Helvetica" shape="box" fillcolor="#2d2d2d" ]
+edge [ color="#8f8f8f" fontcolor="#e0e0e0" fontname="Helvetica" ]
+a [color="#1b9e77" ]
+b [color="#d95f02" ]
+a -> b
+}`
+
+func TestThemeApply(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.BgColor = "white"
+	g.SetNodeDefaults(VertexDescription{Shape: ShapeEllipse})
+	theme := DarkTheme()
+	theme.Apply(&g)
+
+	a := &VertexDescription{ID: "a", Color: string(theme.Color(0))}
+	b := &VertexDescription{ID: "b", Color: string(theme.Color(1))}
+	g.AddVertex(a)
+	g.AddVertex(b)
+	g.AddEdge(a, b, true, "")
+	if s := writeString(t, &g); s != themedGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+	if err := g.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestThemes(t *testing.T) {
+	for _, theme := range []Theme{CleanTheme(), DarkTheme()} {
+		for _, c := range theme.Palette {
+			if err := c.Validate(); err != nil {
+				t.Error(err)
+			}
+		}
+		if theme.Color(len(theme.Palette)) != theme.Palette[0] {
+			t.Error("palette does not wrap around")
+		}
+
+		// applying a theme twice must not change the graph
+		g := NewGraph("testGraph")
+		theme.Apply(&g)
+		s := g.String()
+		theme.Apply(&g)
+		if g.String() != s {
+			t.Errorf("theme not idempotent: \n%s\n", g.String())
+		}
+	}
+	if (Theme{}).Color(3) != "" {
+		t.Error("expected no color for an empty palette")
+	}
+}
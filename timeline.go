@@ -0,0 +1,56 @@
+package dot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RankByTime ranks the vertices with the given IDs by their timestamp, so
+// that events are drawn in chronological columns.  Timestamps are truncated
+// to a multiple of bucket, or used as they are if bucket is not positive,
+// and the vertices of each bucket get a rank=same group, in chronological
+// order and then by ID.  Invisible edges from a vertex of each bucket to a
+// vertex of the next keep the buckets in order.  The RankDir of the graph is
+// set to LR when the graph is not a subgraph and has none.  An error is
+// returned, and the graph left unchanged, if an ID is not a vertex of the
+// graph.
+func (graph *Graph) RankByTime(times map[string]time.Time, bucket time.Duration) error {
+	ids := make([]string, 0, len(times))
+	for id := range times {
+		if !graph.HasVertex(id) {
+			return fmt.Errorf("graph %s has no vertex %s", graph.Name, id)
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		ti, tj := times[ids[i]], times[ids[j]]
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return ids[i] < ids[j]
+	})
+
+	if !graph.IsSubGraph && graph.RankDir == "" {
+		graph.RankDir = RankDirLR
+	}
+	var group *RankGroup
+	var current time.Time
+	for _, id := range ids {
+		t := times[id]
+		if bucket > 0 {
+			t = t.Truncate(bucket)
+		}
+		if group != nil && t.Equal(current) {
+			group.IDs = append(group.IDs, id)
+			continue
+		}
+		if group != nil {
+			graph.AddInvisibleEdge(&VertexDescription{ID: group.IDs[0]}, &VertexDescription{ID: id})
+		}
+		group = &RankGroup{Rank: "same", IDs: []string{id}}
+		current = t
+		graph.Body = append(graph.Body, group)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package dot
+
+import (
+	"testing"
+	"time"
+)
+
+var timelineGraph = `digraph events {
+rankdir="LR"
+pin -> allocate
+allocate -> pinned
+allocate -> retry
+retry -> pinned
+{ rank=same; pin; }
+pin -> allocate [ style="invis" ]
+{ rank=same; allocate; retry; }
+allocate -> pinned [ style="invis" ]
+{ rank=same; pinned; }
+}`
+
+func TestRankByTime(t *testing.T) {
+	g := NewGraph("events")
+	for _, e := range [][2]string{{"pin", "allocate"}, {"allocate", "pinned"}, {"allocate", "retry"}, {"retry", "pinned"}} {
+		g.AddEdge(&VertexDescription{ID: e[0]}, &VertexDescription{ID: e[1]}, true, "")
+	}
+	start := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	times := map[string]time.Time{
+		"pin":      start,
+		"retry":    start.Add(90 * time.Second),
+		"allocate": start.Add(70 * time.Second),
+		"pinned":   start.Add(3 * time.Minute),
+	}
+	if err := g.RankByTime(times, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if s := writeString(t, &g); s != timelineGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	g = NewGraph("events")
+	times["unpin"] = start
+	if err := g.RankByTime(times, time.Minute); err == nil {
+		t.Error("expected an error for an unknown vertex")
+	}
+	if len(g.Body) != 0 || g.RankDir != "" {
+		t.Error("graph changed by a failed RankByTime")
+	}
+}
@@ -0,0 +1,92 @@
+package dot
+
+import (
+	"fmt"
+)
+
+// PeerStatus is the state of a peer of an ipfs-cluster
+type PeerStatus string
+
+// Peer statuses styled by ClusterTopology
+const (
+	PeerOnline  PeerStatus = "online"
+	PeerOffline PeerStatus = "offline"
+	PeerError   PeerStatus = "error"
+)
+
+// Peer is a peer of an ipfs-cluster.  Name is the label of the peer, which
+// defaults to its shortened ID.
+type Peer struct {
+	ID     string
+	Name   string
+	Status PeerStatus
+}
+
+// PeerConnection connects two peers identified by their IDs
+type PeerConnection struct {
+	From, To string
+}
+
+// peerLabelLimit is the length of the labels of peers without a name
+const peerLabelLimit = 12
+
+// peerStyles holds the look of the peers of each status
+var peerStyles = map[PeerStatus]VertexDescription{
+	PeerOnline:  {Shape: ShapeBox, Style: "rounded,filled", Color: "#1b9e77", FillColor: "#d9f0e3"},
+	PeerOffline: {Shape: ShapeBox, Style: "rounded,filled,dashed", Color: "#7f7f7f", FillColor: "#eeeeee", FontColor: "#7f7f7f"},
+	PeerError:   {Shape: ShapeOctagon, Style: "filled", Color: "#d95f02", FillColor: "#fde0cc"},
+}
+
+// offlineEdge is the look of the connections to peers which are not online
+var offlineEdge = EdgeDescription{Style: "dashed", Color: "#7f7f7f"}
+
+// ClusterTopology returns a graph of the peers of an ipfs-cluster and their
+// connections, drawn with CleanTheme.  Peers are styled after their status
+// and have it as their class, so that SVG output can be styled further.
+// Peers with an unknown status are drawn as empty dashed boxes.  Connections to
+// peers which are not online are dashed.  Vertex IDs are computed by
+// Graph.SanitizeID, so that SourceID maps them back to peer IDs.  It is an
+// error for peers to share an ID or for a connection to refer to an unknown
+// peer.
+func ClusterTopology(name string, peers []Peer, connections []PeerConnection) (*Graph, error) {
+	graph := NewGraphWithCapacity(name, len(peers), len(connections))
+	CleanTheme().Apply(&graph)
+	vertices := make(map[string]*VertexDescription, len(peers))
+	status := make(map[string]PeerStatus, len(peers))
+	for _, peer := range peers {
+		if _, ok := vertices[peer.ID]; ok {
+			return nil, fmt.Errorf("peer %s: duplicate peer ID", peer.ID)
+		}
+		v, ok := peerStyles[peer.Status]
+		if !ok {
+			v = VertexDescription{Style: "rounded,dashed"}
+		}
+		v.ID = graph.SanitizeID(peer.ID)
+		v.Class = string(peer.Status)
+		if peer.Name != "" {
+			v.Label = peer.Name
+			v.Tooltip = peer.ID
+		} else {
+			v.Label = peer.ID
+			v.TruncateLabel(peerLabelLimit, true)
+		}
+		vertices[peer.ID] = &v
+		status[peer.ID] = peer.Status
+		graph.AddVertex(&v)
+	}
+	for _, conn := range connections {
+		from, to := vertices[conn.From], vertices[conn.To]
+		for _, id := range []string{conn.From, conn.To} {
+			if vertices[id] == nil {
+				return nil, fmt.Errorf("connection %s -> %s: unknown peer %s", conn.From, conn.To, id)
+			}
+		}
+		edge := &EdgeDescription{From: *from, To: *to, Directed: true}
+		if status[conn.From] != PeerOnline || status[conn.To] != PeerOnline {
+			edge.Style = offlineEdge.Style
+			edge.Color = offlineEdge.Color
+		}
+		graph.addEdge(edge)
+	}
+	return &graph, nil
+}
@@ -0,0 +1,52 @@
+package dot
+
+import (
+	"testing"
+)
+
+var topologyGraph = `digraph cluster {
+nodesep="0.4"
+ranksep="0.6"
+fontname="Helvetica"
+node [color="#4a4a4a" style="rounded,filled" fontname="Helvetica" shape="box" fillcolor="#f5f5f5" fontsize="11" ]
+edge [ arrowsize="0.7" color="#4a4a4a" fontsize="9" fontname="Helvetica" ]
+_12D3KooWAbcdefghijk_a0b33dae [label="peer0" color="#1b9e77" style="rounded,filled" shape="box" tooltip="12D3KooWAbcdefghijk" fillcolor="#d9f0e3" class="online" ]
+_12D3KooWLmnopqrstuv_f77e5193 [label="12D3KooWLmn…" color="#7f7f7f" style="rounded,filled,dashed" fontcolor="#7f7f7f" shape="box" tooltip="12D3KooWLmnopqrstuv" fillcolor="#eeeeee" class="offline" ]
+QmPeer2 [label="peer2" color="#d95f02" style="filled" shape="octagon" tooltip="QmPeer2" fillcolor="#fde0cc" class="error" ]
+QmPeer3 [label="peer3" style="rounded,dashed" tooltip="QmPeer3" class="joining" ]
+_12D3KooWAbcdefghijk_a0b33dae -> QmPeer2 [ style="dashed" color="#7f7f7f" ]
+_12D3KooWAbcdefghijk_a0b33dae -> _12D3KooWLmnopqrstuv_f77e5193 [ style="dashed" color="#7f7f7f" ]
+}`
+
+func TestClusterTopology(t *testing.T) {
+	peers := []Peer{
+		{ID: "12D3KooWAbcdefghijk", Name: "peer0", Status: PeerOnline},
+		{ID: "12D3KooWLmnopqrstuv", Status: PeerOffline},
+		{ID: "QmPeer2", Name: "peer2", Status: PeerError},
+		{ID: "QmPeer3", Name: "peer3", Status: "joining"},
+	}
+	connections := []PeerConnection{
+		{From: "12D3KooWAbcdefghijk", To: "QmPeer2"},
+		{From: "12D3KooWAbcdefghijk", To: "12D3KooWLmnopqrstuv"},
+	}
+	g, err := ClusterTopology("cluster", peers, connections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := writeString(t, g); s != topologyGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+	if err := g.Validate(); err != nil {
+		t.Error(err)
+	}
+	if id, ok := g.SourceID(SanitizeID("QmPeer3")); !ok || id != "QmPeer3" {
+		t.Errorf("unexpected source ID %s", id)
+	}
+
+	if _, err := ClusterTopology("cluster", peers[:1], connections); err == nil {
+		t.Error("expected an error for a connection to an unknown peer")
+	}
+	if _, err := ClusterTopology("cluster", append(peers, peers[0]), nil); err == nil {
+		t.Error("expected an error for a duplicate peer")
+	}
+}
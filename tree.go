@@ -0,0 +1,30 @@
+package dot
+
+// AddTree adds the tree rooted at root to the graph, calling children with
+// the ID of each vertex to get its children.  Vertices are added in
+// depth-first order, each followed by the edges to its children, which are
+// directed unless the graph is undirected.  Vertices with children have
+// ordering=out, unless they set an Ordering, so that children are drawn in
+// the order children returns them.  A vertex met again, when children
+// describes a graph which is not a tree, is connected to its new parent but
+// neither added nor expanded again.
+func (graph *Graph) AddTree(root *VertexDescription, children func(id string) []*VertexDescription) {
+	graph.addTree(root, children, make(map[string]bool))
+}
+
+func (graph *Graph) addTree(v *VertexDescription, children func(id string) []*VertexDescription, seen map[string]bool) {
+	seen[v.ID] = true
+	kids := children(v.ID)
+	if len(kids) > 0 && v.Ordering == "" {
+		v.Ordering = OrderingOut
+	}
+	graph.AddVertex(v)
+	for _, child := range kids {
+		graph.AddEdge(v, child, !graph.IsUndirected, "")
+	}
+	for _, child := range kids {
+		if !seen[child.ID] {
+			graph.addTree(child, children, seen)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package dot
+
+import (
+	"testing"
+)
+
+var treeGraph = `digraph pins {
+root [label="/" ordering="out" ]
+root -> docs
+root -> src
+docs [label="docs" ordering="out" ]
+docs -> readme
+readme [label="README.md" ]
+src [label="src" ordering="out" ]
+src -> main
+src -> readme
+main [label="main.go" ]
+}`
+
+func TestAddTree(t *testing.T) {
+	vertices := map[string]*VertexDescription{
+		"root":   {ID: "root", Label: "/"},
+		"docs":   {ID: "docs", Label: "docs"},
+		"src":    {ID: "src", Label: "src"},
+		"readme": {ID: "readme", Label: "README.md"},
+		"main":   {ID: "main", Label: "main.go"},
+	}
+	tree := map[string][]string{
+		"root": {"docs", "src"},
+		"docs": {"readme"},
+		"src":  {"main", "readme"},
+	}
+	children := func(id string) []*VertexDescription {
+		var kids []*VertexDescription
+		for _, kid := range tree[id] {
+			kids = append(kids, vertices[kid])
+		}
+		return kids
+	}
+
+	g := NewGraph("pins")
+	g.AddTree(vertices["root"], children)
+	if s := writeString(t, &g); s != treeGraph {
+		t.Errorf("unexpected output: \n%s\n", s)
+	}
+
+	tree["main"] = []string{"root"}
+	g = NewGraph("pins")
+	g.AddTree(vertices["root"], children)
+	if !g.HasEdge("main", "root") || len(g.Body) != 11 {
+		t.Errorf("unexpected graph for a cyclic tree: \n%s\n", writeString(t, &g))
+	}
+}
@@ -0,0 +1,178 @@
+package dot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validate lints the graph before it is handed to graphviz, which rejects
+// or silently ignores many of these problems:
+//
+//   - the root graph has no name
+//   - vertices declared twice with conflicting attribute values
+//   - edges to vertices which are not declared anywhere in the graph
+//   - undirected edges in a digraph, and directed edges in a graph
+//   - unknown shapes, invalid colors and image attributes of vertices,
+//     including the node defaults, invalid colors and directions of edges,
+//     and invalid colors of graphs.  Colors of vertices with a ColorScheme
+//     are not checked.
+//   - self-loops, when DAG is set on the graph
+//
+// Subgraphs are checked along with the graph.  The problems found are
+// returned as an ErrorList, or nil if there are none.
+func (graph *Graph) Validate() error {
+	v := &validator{
+		root:     graph,
+		vertices: make(map[string]*VertexDescription),
+	}
+	if graph.Name == "" && !graph.IsSubGraph {
+		v.errorf("graph has no name")
+	}
+	v.declare(graph)
+	v.validateGraph(graph)
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// validator holds the state of a Graph.Validate
+type validator struct {
+	root *Graph
+	// vertices holds the declared vertices, merged by ID
+	vertices map[string]*VertexDescription
+	errs     ErrorList
+}
+
+func (v *validator) errorf(format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+// declare records the vertices declared in the graph and its subgraphs,
+// reporting conflicting declarations
+func (v *validator) declare(graph *Graph) {
+	for _, elem := range graph.Body {
+		switch e := elem.(type) {
+		case *VertexDescription:
+			existing, ok := v.vertices[e.ID]
+			if !ok {
+				existing = &VertexDescription{ID: e.ID}
+				v.vertices[e.ID] = existing
+			}
+			if err := mergeVertex(existing, e, false); err != nil {
+				v.errs = append(v.errs, err)
+			}
+		case *Graph:
+			v.declare(e)
+		}
+	}
+}
+
+func (v *validator) validateGraph(graph *Graph) {
+	for _, c := range []string{graph.Color, graph.BgColor, graph.FillColor, graph.FontColor} {
+		if c == "" {
+			continue
+		}
+		if err := Color(c).Validate(); err != nil {
+			v.errorf("graph %s: %v", graph.Name, err)
+		}
+	}
+	if graph.NodeDefaults != nil {
+		if err := graph.NodeDefaults.validate(); err != nil {
+			v.errorf("graph %s node defaults: %v", graph.Name, err)
+		}
+	}
+	if graph.EdgeDefaults != nil {
+		if err := graph.EdgeDefaults.validate(); err != nil {
+			v.errorf("graph %s edge defaults: %v", graph.Name, err)
+		}
+	}
+	for _, elem := range graph.Body {
+		switch e := elem.(type) {
+		case *VertexDescription:
+			if err := e.validate(); err != nil {
+				v.errorf("vertex %s: %v", e.ID, err)
+			}
+		case *EdgeDescription:
+			v.validateEdge(e)
+		case *EdgeChain:
+			for _, edge := range e.Edges() {
+				v.validateEdge(&edge)
+			}
+		case *Graph:
+			v.validateGraph(e)
+		}
+	}
+}
+
+func (v *validator) validateEdge(e *EdgeDescription) {
+	arrow := "--"
+	if e.Directed {
+		arrow = "->"
+	}
+	name := fmt.Sprintf("edge %s %s %s", e.From.ID, arrow, e.To.ID)
+	for _, id := range []string{e.From.ID, e.To.ID} {
+		if v.vertices[id] == nil {
+			v.errorf("%s: undeclared vertex %s", name, id)
+		}
+	}
+	if !v.root.IsSubGraph {
+		if e.Directed && v.root.IsUndirected {
+			v.errorf("%s: directed edge in undirected graph %s", name, v.root.Name)
+		} else if !e.Directed && !v.root.IsUndirected {
+			v.errorf("%s: undirected edge in directed graph %s", name, v.root.Name)
+		}
+	}
+	if v.root.DAG && e.From.ID == e.To.ID {
+		v.errorf("%s: self-loop in DAG %s", name, v.root.Name)
+	}
+	if err := e.validate(); err != nil {
+		v.errorf("%s: %v", name, err)
+	}
+}
+
+func (v *VertexDescription) validate() error {
+	if err := v.Shape.Validate(); err != nil {
+		return err
+	}
+	if err := v.validateImage(); err != nil {
+		return err
+	}
+	if v.ColorScheme != "" {
+		return nil
+	}
+	for _, c := range []string{v.Color, v.FontColor, v.FillColor} {
+		if c == "" {
+			continue
+		}
+		if err := Color(c).Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *EdgeDescription) validate() error {
+	switch e.Dir {
+	case "", DirForward, DirBack, DirBoth, DirNone:
+	default:
+		return fmt.Errorf("unknown dir %q", string(e.Dir))
+	}
+	if e.Color != "" {
+		return Color(e.Color).Validate()
+	}
+	return nil
+}
+
+// ErrorList collects several errors, such as the problems found while
+// building a graph
+type ErrorList []error
+
+// Error returns the messages of the errors, one per line
+func (errs ErrorList) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
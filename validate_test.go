@@ -0,0 +1,94 @@
+package dot
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.AddVertex(&VertexDescription{ID: "a", Shape: ShapeBox, Color: "red"})
+	g.AddVertex(&VertexDescription{ID: "b", Color: "3", ColorScheme: "blues9"})
+	sub := NewCluster("sub")
+	sub.AddVertex(&VertexDescription{ID: "c", FontColor: "#0000ff"})
+	g.AddSubGraph(&sub)
+	if err := g.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		v   VertexDescription
+		err string
+	}{
+		{VertexDescription{ID: "d", Shape: "bx"}, `vertex d: unknown shape "bx"`},
+		{VertexDescription{ID: "e", Color: "rde"}, `vertex e: unknown color name "rde"`},
+		{VertexDescription{ID: "f", FontColor: "#12"}, `vertex f: color "#12": expected 6 or 8 hex digits`},
+	}
+	for _, test := range tests {
+		bad := NewCluster("bad")
+		v := test.v
+		bad.AddVertex(&v)
+		g.Body = append(g.Body, &bad)
+		err := g.Validate()
+		if err == nil || err.Error() != test.err {
+			t.Errorf("unexpected error: %v", err)
+		}
+		g.Body = g.Body[:len(g.Body)-1]
+	}
+
+	g.SetNodeDefaults(VertexDescription{Shape: "nope"})
+	if err := g.Validate(); err == nil || err.Error() != `graph testGraph node defaults: unknown shape "nope"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateLint(t *testing.T) {
+	g := NewGraph("")
+	g.AddVertex(&VertexDescription{ID: "a", Color: "red"})
+	g.AddVertex(&VertexDescription{ID: "b"})
+	sub := NewCluster("sub")
+	sub.AddVertex(&VertexDescription{ID: "a", Color: "blue"})
+	g.AddSubGraph(&sub)
+	g.AddEdge(&VertexDescription{ID: "a"}, &VertexDescription{ID: "c"}, true, "")
+	g.AddEdge(&VertexDescription{ID: "a"}, &VertexDescription{ID: "b"}, false, "")
+	g.AddEdgeChain(true, "", &VertexDescription{ID: "b"}, &VertexDescription{ID: "a"})
+	g.SetEdgeDefaults(EdgeDescription{Dir: "up"})
+
+	expected := `graph has no name
+vertex a: conflicting values "red" and "blue" for attribute color
+graph  edge defaults: unknown dir "up"
+edge a -> c: undeclared vertex c
+edge a -- b: undirected edge in directed graph `
+	err := g.Validate()
+	if err == nil || err.Error() != expected {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if errs, ok := err.(ErrorList); !ok || len(errs) != 5 {
+		t.Errorf("expected 5 errors, found %#v", err)
+	}
+
+	u := NewUndirectedGraph("u")
+	u.AddVertex(&VertexDescription{ID: "a"})
+	u.AddEdge(&VertexDescription{ID: "a"}, &VertexDescription{ID: "a"}, true, "")
+	u.Body[1].(*EdgeDescription).Color = "bleu"
+	expected = `edge a -> a: directed edge in undirected graph u
+edge a -> a: unknown color name "bleu"`
+	if err := u.Validate(); err == nil || err.Error() != expected {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateDAG(t *testing.T) {
+	g := NewGraph("dag")
+	a := &VertexDescription{ID: "a"}
+	g.AddVertex(a)
+	g.AddSelfLoop(a, SelfLoopOptions{})
+	if err := g.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	g.DAG = true
+	expected := `edge a -> a: self-loop in DAG dag`
+	if err := g.Validate(); err == nil || err.Error() != expected {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
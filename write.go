@@ -0,0 +1,330 @@
+package dot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// WriteOptions control how Graph.WriteWithOptions formats a dot-file
+type WriteOptions struct {
+	// Canonical writes the attributes of every element sorted by name,
+	// instead of typed fields first in declaration order
+	Canonical bool
+	// SortBody writes the elements of the graph and its subgraphs sorted:
+	// literals first in their original order, then vertices by ID, edges
+	// by endpoints, edge chains, rank groups and finally subgraphs by name
+	SortBody bool
+
+	// Indent is written once per nesting level before every statement
+	Indent string
+	// AlignAttrs pads the IDs of the vertices in each graph body to the
+	// same width so that their attribute lists line up
+	AlignAttrs bool
+	// WrapWidth, when positive, writes the attributes of statements longer
+	// than this many bytes one per line
+	WrapWidth int
+
+	// StrictIDs makes the write fail on IDs which are dot keywords, or
+	// start with a digit without being numerals, instead of quoting them.
+	// Such IDs are usually mistakes, such as a vertex named after a number
+	// with a unit.
+	StrictIDs bool
+
+	// BufferSize, when positive, collects the output in a buffer of this
+	// many bytes which is written to the writer each time it fills up, and
+	// once more when the graph is written, instead of issuing small writes
+	// for every element.  Errors of the writer are then reported for the
+	// element being written when the buffer was written.
+	BufferSize int
+
+	// ParallelSubgraphs, when greater than one, writes up to this many
+	// subgraphs of the graph concurrently into separate buffers, which are
+	// then written in order.  Subgraphs must not be shared between the
+	// graph and its subgraphs or be modified during the write.
+	ParallelSubgraphs int
+
+	// ctx, when set, aborts the write once it is done
+	ctx context.Context
+	// depth is the nesting level of the graph being written
+	depth int
+	// idWidth is the width vertex IDs are padded to
+	idWidth int
+}
+
+// optionWriter is implemented by elements whose output depends on the
+// WriteOptions in use
+type optionWriter interface {
+	writeWithOptions(w io.Writer, opts WriteOptions) error
+}
+
+// order returns attrs in the order they should be written
+func (opts WriteOptions) order(attrs []attribute) []attribute {
+	if opts.Canonical {
+		sort.SliceStable(attrs, func(i, j int) bool {
+			return attrs[i].name < attrs[j].name
+		})
+	}
+	return attrs
+}
+
+// checkIDs returns an error for the first ID rejected by StrictIDs
+func (opts WriteOptions) checkIDs(ids ...string) error {
+	if !opts.StrictIDs {
+		return nil
+	}
+	for _, id := range ids {
+		if err := checkStrictID(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indent returns the indentation for the given nesting level
+func (opts WriteOptions) indent(level int) string {
+	return strings.Repeat(opts.Indent, level)
+}
+
+// statement formats a statement made of head followed by an attribute list.
+// Vertex style lists are written as [a="1" b="2" ], while edge style lists
+// are written as [ a="1" b="2" ] and omitted when empty.
+func (opts WriteOptions) statement(head string, attrs []attribute, edge bool) string {
+	attrs = opts.order(attrs)
+	if edge && len(attrs) == 0 {
+		return head
+	}
+
+	var b strings.Builder
+	b.Grow(len(head) + 4 + attrsLen(attrs))
+	b.WriteString(head)
+	if edge {
+		b.WriteString(" [ ")
+		for i, attr := range attrs {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			writeAttr(&b, attr.name, attr.value)
+		}
+		b.WriteString(" ]")
+	} else {
+		b.WriteString(" [")
+		for _, attr := range attrs {
+			writeAttr(&b, attr.name, attr.value)
+			b.WriteByte(' ')
+		}
+		b.WriteByte(']')
+	}
+	if opts.WrapWidth <= 0 || len(attrs) == 0 || len(opts.Indent)*(opts.depth+1)+b.Len() <= opts.WrapWidth {
+		return b.String()
+	}
+
+	b.Reset()
+	b.WriteString(strings.TrimRight(head, " "))
+	b.WriteString(" [\n")
+	for _, attr := range attrs {
+		b.WriteString(opts.indent(opts.depth + 2))
+		writeAttr(&b, attr.name, attr.value)
+		b.WriteByte('\n')
+	}
+	b.WriteString(opts.indent(opts.depth + 1))
+	b.WriteByte(']')
+	return b.String()
+}
+
+// attrsLen returns an estimate of the length of the attribute list
+func attrsLen(attrs []attribute) int {
+	n := 0
+	for _, attr := range attrs {
+		n += len(attr.name) + len(attr.value) + 4
+	}
+	return n
+}
+
+// vertexIDWidth returns the width of the longest vertex ID in body
+func vertexIDWidth(body []Element) int {
+	width := 0
+	for _, elem := range body {
+		if v, ok := elem.(*VertexDescription); ok {
+			if l := len(QuoteID(v.ID)); l > width {
+				width = l
+			}
+		}
+	}
+	return width
+}
+
+// elementRank returns the position of an element kind in a sorted body
+func elementRank(elem Element) int {
+	switch elem.(type) {
+	case *Literal:
+		return 0
+	case *VertexDescription:
+		return 1
+	case *EdgeDescription:
+		return 2
+	case *EdgeChain:
+		return 3
+	case *RankGroup:
+		return 4
+	case *Graph:
+		return 6
+	}
+	return 5
+}
+
+// elementKey returns the key by which elements of the same kind are sorted
+func elementKey(elem Element) string {
+	switch e := elem.(type) {
+	case *VertexDescription:
+		return e.ID
+	case *EdgeDescription:
+		return e.From.ID + "\x00" + e.To.ID
+	case *EdgeChain:
+		var key string
+		for _, v := range e.Vertices {
+			key += v.ID + "\x00"
+		}
+		return key
+	case *Graph:
+		return e.Name
+	}
+	return ""
+}
+
+// sortedBody returns a sorted copy of body, as written with
+// WriteOptions.SortBody
+func sortedBody(body []Element) []Element {
+	sorted := make([]Element, len(body))
+	copy(sorted, body)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := elementRank(sorted[i]), elementRank(sorted[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return elementKey(sorted[i]) < elementKey(sorted[j])
+	})
+	return sorted
+}
+
+// WriteError reports an error writing an element of a graph
+type WriteError struct {
+	// Element describes the element, such as "vertex a" or
+	// "edge a -> b"
+	Element string
+	// Offset is the number of bytes of the dot-file written before the
+	// element
+	Offset int64
+	Err    error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("writing %s at byte %d: %v", e.Element, e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// writeError wraps err in a WriteError for the given element, unless it
+// already is one for a nested element
+func writeError(element string, offset int64, err error) error {
+	if _, ok := err.(*WriteError); ok {
+		return err
+	}
+	return &WriteError{Element: element, Offset: offset, Err: err}
+}
+
+// describeElement returns the description of an element used in errors
+func describeElement(elem Element) string {
+	switch e := elem.(type) {
+	case *VertexDescription:
+		return "vertex " + e.ID
+	case *EdgeDescription:
+		return "edge " + e.From.ID + " -> " + e.To.ID
+	case *EdgeChain:
+		if len(e.Vertices) > 0 {
+			return "edge chain from " + e.Vertices[0].ID
+		}
+		return "edge chain"
+	case *RankGroup:
+		return "rank group"
+	case *Literal:
+		return "literal"
+	case *Graph:
+		return "subgraph " + e.Name
+	}
+	return fmt.Sprintf("element %T", elem)
+}
+
+// renderedElement is an element of a graph body written to a buffer ahead
+// of the rest of the body
+type renderedElement struct {
+	buf bytes.Buffer
+	err error
+}
+
+// renderSubgraphs writes the subgraphs of body concurrently, with at most
+// opts.ParallelSubgraphs at a time, and returns them by position in body.
+// The subgraphs of the subgraphs are written sequentially.
+func renderSubgraphs(body []Element, indent string, opts WriteOptions) []*renderedElement {
+	rendered := make([]*renderedElement, len(body))
+	workers := opts.ParallelSubgraphs
+	opts.ParallelSubgraphs = 0
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, elem := range body {
+		if _, ok := elem.(*Graph); !ok {
+			continue
+		}
+		r := &renderedElement{}
+		rendered[i] = r
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(elem Element) {
+			defer wg.Done()
+			r.err = writeElement(&countingWriter{w: &r.buf}, elem, indent, opts)
+			<-sem
+		}(elem)
+	}
+	wg.Wait()
+	return rendered
+}
+
+// writeRendered writes an element rendered by renderSubgraphs, moving the
+// offset of its errors to the position of the element in the output
+func writeRendered(cw *countingWriter, elem Element, r *renderedElement) error {
+	offset := cw.n
+	if werr, ok := r.err.(*WriteError); ok {
+		return &WriteError{Element: werr.Element, Offset: offset + werr.Offset, Err: werr.Err}
+	} else if r.err != nil {
+		return r.err
+	}
+	if _, err := cw.Write(r.buf.Bytes()); err != nil {
+		return writeError(describeElement(elem), offset, err)
+	}
+	return nil
+}
+
+// countingWriter counts the bytes written to w
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+func (cw *countingWriter) WriteString(s string) (int, error) {
+	n, err := io.WriteString(cw.w, s)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,369 @@
+package dot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func buildUnsortedGraph() Graph {
+	g := NewGraph("testGraph")
+	g.RankDir = RankDirLR
+	g.AddAttribute("compound", "true")
+	sub := NewCluster("b")
+	sub.Label = "B"
+	sub.Color = "red"
+	sub.AddVertex(&VertexDescription{ID: "y", Label: "Y", Color: "blue"})
+	sub.AddVertex(&VertexDescription{ID: "x"})
+	g.AddSubGraph(&sub)
+	c := &VertexDescription{ID: "c", Shape: "box", Label: "C"}
+	c.AddAttribute("fixedsize", "true")
+	a := &VertexDescription{ID: "a"}
+	g.AddVertex(c)
+	g.AddEdge(c, a, true, "dashed")
+	g.AddComment("vertices")
+	g.AddVertex(a)
+	g.AddEdge(a, c, true, "")
+	return g
+}
+
+func TestWriteCanonical(t *testing.T) {
+	g := buildUnsortedGraph()
+	buf := new(bytes.Buffer)
+	err := g.WriteWithOptions(buf, WriteOptions{Canonical: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph testGraph {
+compound="true"
+rankdir="LR"
+subgraph cluster_b {
+color="red"
+label="B"
+y [color="blue" label="Y" ]
+x []
+}
+c [fixedsize="true" label="C" shape="box" ]
+c -> a [ style="dashed" ]
+/* vertices */
+a []
+a -> c
+}`
+	if s := buf.String(); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", expected)
+	}
+}
+
+func TestWriteSortBody(t *testing.T) {
+	g := buildUnsortedGraph()
+	buf := new(bytes.Buffer)
+	err := g.WriteWithOptions(buf, WriteOptions{SortBody: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph testGraph {
+rankdir="LR"
+compound="true"
+/* vertices */
+a []
+c [label="C" shape="box" fixedsize="true" ]
+a -> c
+c -> a [ style="dashed" ]
+subgraph cluster_b {
+label="B"
+color="red"
+x []
+y [label="Y" color="blue" ]
+}
+}`
+	if s := buf.String(); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", expected)
+	}
+}
+
+func TestWriteIndent(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.RankDir = RankDirLR
+	sub := NewCluster("sub")
+	sub.Label = "Sub"
+	sub.AddVertex(&VertexDescription{ID: "a", Label: "A"})
+	sub.AddVertex(&VertexDescription{ID: "long_id", Label: "Long", Color: "red", Shape: "box"})
+	g.AddSubGraph(&sub)
+	g.AddNewLine()
+	g.AddVertex(&VertexDescription{ID: "b"})
+	g.AddEdge(&VertexDescription{ID: "a"}, &VertexDescription{ID: "b"}, true, "dashed")
+
+	buf := new(bytes.Buffer)
+	err := g.WriteWithOptions(buf, WriteOptions{
+		Indent:     "\t",
+		AlignAttrs: true,
+		WrapWidth:  40,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph testGraph {
+	rankdir="LR"
+	subgraph cluster_sub {
+		label="Sub"
+		a       [label="A" ]
+		long_id [
+			label="Long"
+			color="red"
+			shape="box"
+		]
+	}
+
+	b []
+	a -> b [ style="dashed" ]
+}`
+	if s := buf.String(); s != expected {
+		t.Errorf("unexpected output: \n%s\n", s)
+		t.Errorf("expected output: \n%s", expected)
+	}
+}
+
+// cancelWriter cancels a context once it has been written to n times
+type cancelWriter struct {
+	buf    bytes.Buffer
+	n      int
+	cancel context.CancelFunc
+}
+
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	w.n--
+	if w.n == 0 {
+		w.cancel()
+	}
+	return w.buf.Write(p)
+}
+
+func TestWriteContext(t *testing.T) {
+	g := NewGraph("testGraph")
+	sub := NewCluster("sub")
+	for i := 0; i < 100; i++ {
+		sub.AddVertex(&VertexDescription{ID: "v" + strconv.Itoa(i)})
+	}
+	g.AddSubGraph(&sub)
+
+	buf := new(bytes.Buffer)
+	if err := g.WriteContext(context.Background(), buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != g.String() {
+		t.Errorf("unexpected output: \n%s\n", buf)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &cancelWriter{n: 10, cancel: cancel}
+	if err := g.WriteContext(ctx, w); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if lines := strings.Count(w.buf.String(), "\n"); lines > 10 {
+		t.Errorf("write not stopped, %d lines written", lines)
+	}
+}
+
+// failWriter accepts limit bytes and fails the writes after that
+type failWriter struct {
+	limit int
+}
+
+var errFull = errors.New("disk full")
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errFull
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestWriteErrors(t *testing.T) {
+	g := NewGraph("testGraph")
+	sub := NewCluster("sub")
+	sub.AddVertex(&VertexDescription{ID: "a"})
+	sub.AddVertex(&VertexDescription{ID: "b"})
+	g.AddSubGraph(&sub)
+	s := g.String()
+
+	tests := []struct {
+		limit   int
+		element string
+		offset  int64
+	}{
+		{0, "graph testGraph", 0},
+		// fails on the newline after the vertex
+		{len("digraph testGraph {\nsubgraph cluster_sub {\na []"), "vertex a", 43},
+		{len("digraph testGraph {\nsubgraph cluster_sub {\na []\nb [") + 1, "vertex b", 48},
+		{len(s) - 1, "graph testGraph", int64(len(s) - 1)},
+	}
+	for _, test := range tests {
+		err := g.Write(&failWriter{limit: test.limit})
+		var werr *WriteError
+		if !errors.As(err, &werr) || !errors.Is(err, errFull) {
+			t.Errorf("unexpected error %v", err)
+			continue
+		}
+		if werr.Element != test.element || werr.Offset != test.offset {
+			t.Errorf("limit %d: unexpected error %v", test.limit, err)
+		}
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.AddVertex(&VertexDescription{ID: "a"})
+	var _ io.WriterTo = &g
+
+	buf := new(bytes.Buffer)
+	n, err := g.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) || buf.String() != g.String() {
+		t.Errorf("unexpected output %d: \n%s\n", n, buf)
+	}
+
+	n, err = g.WriteTo(&failWriter{limit: 5})
+	if n != 5 || !errors.Is(err, errFull) {
+		t.Errorf("unexpected result %d, %v", n, err)
+	}
+}
+
+// callWriter counts the calls to Write
+type callWriter struct {
+	buf   bytes.Buffer
+	calls int
+}
+
+func (w *callWriter) Write(p []byte) (int, error) {
+	w.calls++
+	return w.buf.Write(p)
+}
+
+func TestWriteBuffered(t *testing.T) {
+	g := buildUnsortedGraph()
+	unbuffered := &callWriter{}
+	if err := g.WriteWithOptions(unbuffered, WriteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	buffered := &callWriter{}
+	if err := g.WriteWithOptions(buffered, WriteOptions{BufferSize: 4096}); err != nil {
+		t.Fatal(err)
+	}
+	if buffered.buf.String() != unbuffered.buf.String() {
+		t.Errorf("unexpected output: \n%s\n", buffered.buf.String())
+	}
+	if buffered.calls != 1 || unbuffered.calls < 10 {
+		t.Errorf("unexpected number of writes %d buffered, %d unbuffered", buffered.calls, unbuffered.calls)
+	}
+
+	// the buffer is written each time it fills up
+	small := &callWriter{}
+	if err := g.WriteWithOptions(small, WriteOptions{BufferSize: 16}); err != nil {
+		t.Fatal(err)
+	}
+	if small.buf.String() != unbuffered.buf.String() || small.calls < 2 {
+		t.Errorf("unexpected output in %d writes: \n%s\n", small.calls, small.buf.String())
+	}
+
+	// errors writing the remaining buffer are reported for the graph
+	err := g.WriteWithOptions(&failWriter{limit: 5}, WriteOptions{BufferSize: 4096})
+	var werr *WriteError
+	if !errors.As(err, &werr) || !errors.Is(err, errFull) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if werr.Element != "graph testGraph" || werr.Offset != 5 {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func BenchmarkGraphWriteBuffered(b *testing.B) {
+	g := NewGraphWithCapacity("bench", 10000, 10000)
+	prev := &VertexDescription{ID: "v0"}
+	for i := 0; i < 10000; i++ {
+		v := &VertexDescription{ID: "v" + strconv.Itoa(i), Label: "vertex " + strconv.Itoa(i), Shape: "box"}
+		g.AddVertex(v)
+		g.AddEdge(prev, v, true, "dashed")
+		prev = v
+	}
+	for _, size := range []int{0, 4096, 65536} {
+		b.Run("BufferSize="+strconv.Itoa(size), func(b *testing.B) {
+			w := &callWriter{}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				w.buf.Reset()
+				if err := g.WriteWithOptions(w, WriteOptions{BufferSize: size}); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(w.calls)/float64(b.N), "writes/op")
+		})
+	}
+}
+
+func TestWriteParallelSubgraphs(t *testing.T) {
+	g := NewGraph("testGraph")
+	g.AddVertex(&VertexDescription{ID: "root"})
+	for i := 0; i < 8; i++ {
+		cluster := g.AddCluster("cluster " + strconv.Itoa(i))
+		for j := 0; j < 10; j++ {
+			cluster.AddVertex(&VertexDescription{ID: "v" + strconv.Itoa(i) + "_" + strconv.Itoa(j)})
+		}
+		cluster.AddCluster("nested").AddVertex(&VertexDescription{ID: "n" + strconv.Itoa(i)})
+		g.AddComment("after cluster " + strconv.Itoa(i))
+	}
+
+	opts := WriteOptions{Indent: "\t"}
+	expected := new(bytes.Buffer)
+	if err := g.WriteWithOptions(expected, opts); err != nil {
+		t.Fatal(err)
+	}
+	opts.ParallelSubgraphs = 3
+	buf := new(bytes.Buffer)
+	if err := g.WriteWithOptions(buf, opts); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != expected.String() {
+		t.Errorf("unexpected output: \n%s\n", buf)
+	}
+
+	// errors are reported at the same offset as when writing sequentially
+	g.Body[3].(*Graph).AddVertex(&VertexDescription{ID: "node"})
+	opts.StrictIDs = true
+	seqErr := g.WriteWithOptions(new(bytes.Buffer), WriteOptions{Indent: "\t", StrictIDs: true})
+	err := g.WriteWithOptions(new(bytes.Buffer), opts)
+	if err == nil || seqErr == nil || err.Error() != seqErr.Error() {
+		t.Errorf("unexpected error %v, expected %v", err, seqErr)
+	}
+}
+
+func BenchmarkWriteParallelSubgraphs(b *testing.B) {
+	g := NewGraph("bench")
+	for i := 0; i < 16; i++ {
+		cluster := g.AddCluster("cluster " + strconv.Itoa(i))
+		for j := 0; j < 2000; j++ {
+			cluster.AddVertex(&VertexDescription{ID: "v" + strconv.Itoa(i) + "_" + strconv.Itoa(j), Label: "vertex", Shape: "box"})
+		}
+	}
+	for _, workers := range []int{0, 4} {
+		b.Run("ParallelSubgraphs="+strconv.Itoa(workers), func(b *testing.B) {
+			buf := new(bytes.Buffer)
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				if err := g.WriteWithOptions(buf, WriteOptions{ParallelSubgraphs: workers}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}